@@ -23,12 +23,14 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/managementasset"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/ratelimit"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/store"
 	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -64,6 +66,8 @@ func main() {
 	var antigravityLogin bool
 	var projectID string
 	var vertexImport string
+	var recordFixtures string
+	var fixturesOut string
 	var configPath string
 	var password string
 
@@ -79,6 +83,8 @@ func main() {
 	flag.StringVar(&projectID, "project_id", "", "Project ID (Gemini only, not required)")
 	flag.StringVar(&configPath, "config", DefaultConfigPath, "Configure File Path")
 	flag.StringVar(&vertexImport, "vertex-import", "", "Import Vertex service account key JSON file")
+	flag.StringVar(&recordFixtures, "record-fixtures", "", "Record translator fixtures from a request log file or directory")
+	flag.StringVar(&fixturesOut, "fixtures-out", "fixtures", "Output directory for -record-fixtures")
 	flag.StringVar(&password, "password", "", "")
 
 	flag.CommandLine.Usage = func() {
@@ -447,6 +453,9 @@ func main() {
 	if vertexImport != "" {
 		// Handle Vertex service account import
 		cmd.DoVertexImport(cfg, vertexImport)
+	} else if recordFixtures != "" {
+		// Handle translator fixture recording from request logs
+		cmd.DoRecordFixtures(recordFixtures, fixturesOut)
 	} else if login {
 		// Handle Google/Gemini login
 		cmd.DoLogin(cfg, projectID, options)
@@ -473,7 +482,84 @@ func main() {
 			return
 		}
 		// Start the main proxy service
+		usagePersistence := startUsagePersistence(cfg, configFilePath, pgStoreDSN, pgStoreSchema)
 		managementasset.StartAutoUpdater(context.Background(), configFilePath)
 		cmd.StartService(cfg, configFilePath, password)
+		if usagePersistence != nil {
+			if errClose := usagePersistence.Close(); errClose != nil {
+				log.Errorf("failed to close usage persistence: %v", errClose)
+			}
+		}
+	}
+}
+
+// startUsagePersistence creates the configured usage sink, restores persisted statistics
+// and registers the persistence plugin. It returns nil when persistence is disabled or fails.
+func startUsagePersistence(cfg *config.Config, configFilePath, pgStoreDSN, pgStoreSchema string) *usage.PersistentPlugin {
+	settings := cfg.UsagePersistence
+	if !settings.Enabled {
+		return nil
+	}
+	if !cfg.UsageStatisticsEnabled {
+		log.Warn("usage persistence is enabled but usage-statistics-enabled is false; records will not be persisted")
+	}
+
+	backend := settings.Backend
+	if backend == "" {
+		backend = "jsonl"
+		if pgStoreDSN != "" {
+			backend = "postgres"
+		}
+	}
+
+	var sink usage.Sink
+	switch backend {
+	case "postgres":
+		if pgStoreDSN == "" {
+			log.Error("usage persistence backend postgres requires PGSTORE_DSN")
+			return nil
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		pgSink, errSink := usage.NewPostgresSink(ctx, usage.PostgresSinkConfig{DSN: pgStoreDSN, Schema: pgStoreSchema})
+		cancel()
+		if errSink != nil {
+			log.Errorf("failed to initialize usage persistence: %v", errSink)
+			return nil
+		}
+		sink = pgSink
+	case "jsonl":
+		path := settings.Path
+		if path == "" {
+			path = filepath.Join(filepath.Dir(configFilePath), "usage", "usage.jsonl")
+		}
+		jsonlSink, errSink := usage.NewJSONLSink(path)
+		if errSink != nil {
+			log.Errorf("failed to initialize usage persistence: %v", errSink)
+			return nil
+		}
+		sink = jsonlSink
+	default:
+		log.Errorf("unsupported usage persistence backend: %s", backend)
+		return nil
+	}
+
+	plugin := usage.NewPersistentPlugin(sink, time.Duration(settings.RetentionDays)*24*time.Hour)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	result, errRestore := plugin.Restore(ctx, usage.GetRequestStatistics())
+	cancel()
+	if errRestore != nil {
+		log.Errorf("failed to restore persisted usage statistics: %v", errRestore)
+	} else {
+		log.Infof("usage persistence enabled (%s backend), restored %d records", backend, result.Added)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	tokens, errTokens := plugin.TokensSince(ctx, ratelimit.StartOfDay(time.Now()))
+	cancel()
+	if errTokens != nil {
+		log.Errorf("failed to restore daily token usage for rate limits: %v", errTokens)
+	} else {
+		ratelimit.Default().RestoreTokens(tokens)
 	}
+	coreusage.RegisterPlugin(plugin)
+	return plugin
 }
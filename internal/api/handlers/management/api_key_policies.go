@@ -0,0 +1,220 @@
+package management
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+// GetAPIKeys lists client API keys together with their virtual key policies.
+func (h *Handler) GetAPIKeys(c *gin.Context) {
+	policies := h.cfg.APIKeyPolicies
+	if policies == nil {
+		policies = []config.APIKeyPolicy{}
+	}
+	c.JSON(http.StatusOK, gin.H{"api-keys": h.cfg.APIKeys, "api-key-policies": policies})
+}
+
+// PutAPIKeys replaces all client API keys. The body is either a list of key strings, which
+// keeps the policies of retained keys, or a list of virtual key objects, which also
+// replaces every policy.
+func (h *Handler) PutAPIKeys(c *gin.Context) {
+	data, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read body"})
+		return
+	}
+	if policies, ok := decodeAPIKeyPolicies(data); ok {
+		keys := make([]string, 0, len(policies))
+		for _, policy := range policies {
+			if errMsg := validateAPIKeyPolicy(policy); errMsg != "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": errMsg})
+				return
+			}
+			keys = append(keys, strings.TrimSpace(policy.APIKey))
+		}
+		h.cfg.APIKeys = keys
+		h.cfg.APIKeyPolicies = policies
+		h.cfg.SanitizeAPIKeyPolicies()
+		h.cfg.Access.Providers = nil
+		h.persist(c)
+		return
+	}
+	restoreBody(c, data)
+	before := append([]string(nil), h.cfg.APIKeys...)
+	h.putStringList(c, func(v []string) {
+		h.cfg.APIKeys = append([]string(nil), v...)
+		h.cfg.Access.Providers = nil
+	}, func() { h.dropRemovedKeyPolicies(before) })
+}
+
+// PatchAPIKeys edits client API keys. A body of {"value": {...}} with a virtual key object
+// adds the key or replaces its policy; the legacy old/new and index/value forms rename or
+// replace keys and carry their policies along.
+func (h *Handler) PatchAPIKeys(c *gin.Context) {
+	data, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read body"})
+		return
+	}
+	var body struct {
+		Value json.RawMessage `json:"value"`
+		Old   *string         `json:"old"`
+		New   *string         `json:"new"`
+		Index *int            `json:"index"`
+	}
+	_ = json.Unmarshal(data, &body)
+	if trimmed := bytes.TrimSpace(body.Value); len(trimmed) > 0 && trimmed[0] == '{' {
+		var policy config.APIKeyPolicy
+		if err = json.Unmarshal(trimmed, &policy); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+			return
+		}
+		if errMsg := validateAPIKeyPolicy(policy); errMsg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errMsg})
+			return
+		}
+		h.upsertAPIKeyPolicy(policy)
+		h.persist(c)
+		return
+	}
+
+	var oldKey, newKey string
+	switch {
+	case body.Index != nil && *body.Index >= 0 && *body.Index < len(h.cfg.APIKeys):
+		oldKey = h.cfg.APIKeys[*body.Index]
+		_ = json.Unmarshal(body.Value, &newKey)
+	case body.Old != nil && body.New != nil:
+		oldKey, newKey = *body.Old, *body.New
+	}
+	restoreBody(c, data)
+	before := append([]string(nil), h.cfg.APIKeys...)
+	h.patchStringList(c, &h.cfg.APIKeys, func() {
+		h.cfg.Access.Providers = nil
+		h.renameKeyPolicy(oldKey, newKey)
+		h.dropRemovedKeyPolicies(before)
+	})
+}
+
+// DeleteAPIKeys removes client API keys by index or value, together with their policies.
+func (h *Handler) DeleteAPIKeys(c *gin.Context) {
+	before := append([]string(nil), h.cfg.APIKeys...)
+	h.deleteFromStringList(c, &h.cfg.APIKeys, func() {
+		h.cfg.Access.Providers = nil
+		h.dropRemovedKeyPolicies(before)
+	})
+}
+
+// decodeAPIKeyPolicies parses a list (or {"items": [...]}) of virtual key objects.
+func decodeAPIKeyPolicies(data []byte) ([]config.APIKeyPolicy, bool) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		var obj struct {
+			Items []json.RawMessage `json:"items"`
+		}
+		if errObj := json.Unmarshal(data, &obj); errObj != nil {
+			return nil, false
+		}
+		raw = obj.Items
+	}
+	if len(raw) == 0 {
+		return nil, false
+	}
+	policies := make([]config.APIKeyPolicy, 0, len(raw))
+	for _, item := range raw {
+		if trimmed := bytes.TrimSpace(item); len(trimmed) == 0 || trimmed[0] != '{' {
+			return nil, false
+		}
+		var policy config.APIKeyPolicy
+		if err := json.Unmarshal(item, &policy); err != nil {
+			return nil, false
+		}
+		policies = append(policies, policy)
+	}
+	return policies, true
+}
+
+func validateAPIKeyPolicy(policy config.APIKeyPolicy) string {
+	if strings.TrimSpace(policy.APIKey) == "" {
+		return "api-key is required"
+	}
+	if raw := strings.TrimSpace(policy.ExpiresAt); raw != "" {
+		if _, err := time.Parse(time.RFC3339, raw); err != nil {
+			return "expires-at must be an RFC 3339 timestamp"
+		}
+	}
+	return ""
+}
+
+// upsertAPIKeyPolicy stores policy and makes sure its key is accepted.
+func (h *Handler) upsertAPIKeyPolicy(policy config.APIKeyPolicy) {
+	policy.APIKey = strings.TrimSpace(policy.APIKey)
+	known := false
+	for _, key := range h.cfg.APIKeys {
+		if key == policy.APIKey {
+			known = true
+			break
+		}
+	}
+	if !known {
+		h.cfg.APIKeys = append(h.cfg.APIKeys, policy.APIKey)
+		h.cfg.Access.Providers = nil
+	}
+	h.cfg.APIKeyPolicies = append(h.cfg.APIKeyPolicies, policy)
+	h.cfg.SanitizeAPIKeyPolicies()
+}
+
+// renameKeyPolicy moves the policy of a replaced key to its new value.
+func (h *Handler) renameKeyPolicy(oldKey, newKey string) {
+	if oldKey == "" || newKey == "" || oldKey == newKey {
+		return
+	}
+	if _, exists := h.cfg.APIKeyPolicyFor(newKey); exists {
+		return
+	}
+	for i := range h.cfg.APIKeyPolicies {
+		if h.cfg.APIKeyPolicies[i].APIKey == oldKey {
+			h.cfg.APIKeyPolicies[i].APIKey = newKey
+			return
+		}
+	}
+}
+
+// dropRemovedKeyPolicies deletes policies of keys that were listed in before but are no
+// longer accepted.
+func (h *Handler) dropRemovedKeyPolicies(before []string) {
+	if len(h.cfg.APIKeyPolicies) == 0 {
+		return
+	}
+	current := make(map[string]struct{}, len(h.cfg.APIKeys))
+	for _, key := range h.cfg.APIKeys {
+		current[key] = struct{}{}
+	}
+	removed := make(map[string]struct{})
+	for _, key := range before {
+		if _, ok := current[key]; !ok {
+			removed[key] = struct{}{}
+		}
+	}
+	if len(removed) == 0 {
+		return
+	}
+	out := make([]config.APIKeyPolicy, 0, len(h.cfg.APIKeyPolicies))
+	for _, policy := range h.cfg.APIKeyPolicies {
+		if _, ok := removed[policy.APIKey]; !ok {
+			out = append(out, policy)
+		}
+	}
+	h.cfg.APIKeyPolicies = out
+}
+
+// restoreBody makes an already consumed request body readable again.
+func restoreBody(c *gin.Context, data []byte) {
+	c.Request.Body = io.NopCloser(bytes.NewReader(data))
+}
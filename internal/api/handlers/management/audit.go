@@ -0,0 +1,285 @@
+package management
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/watcher/diff"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	auditLogFileName     = "management-audit.log"
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 500
+)
+
+// AuditEntry records a single mutating management request.
+type AuditEntry struct {
+	ID         int64     `json:"id"`
+	Timestamp  time.Time `json:"timestamp"`
+	RemoteAddr string    `json:"remote_addr"`
+	// Actor names the management key used: a scoped key name, "secret-key", "env" or "local".
+	Actor    string `json:"actor,omitempty"`
+	Method   string `json:"method"`
+	Endpoint string `json:"endpoint"`
+	Status   int    `json:"status"`
+	// Changes lists the redacted config and auth file changes caused by the request.
+	Changes []string `json:"changes,omitempty"`
+}
+
+// auditLog appends entries as JSON lines to a file that is never rewritten.
+type auditLog struct {
+	mu     sync.Mutex
+	path   string
+	nextID int64
+}
+
+// auditLog returns the audit log rooted in the current log directory.
+func (h *Handler) auditLog() *auditLog {
+	path := filepath.Join(h.logDirectory(), auditLogFileName)
+
+	h.auditMu.Lock()
+	defer h.auditMu.Unlock()
+	if h.audit == nil || h.audit.path != path {
+		h.audit = &auditLog{path: path}
+	}
+	return h.audit
+}
+
+func (a *auditLog) append(entry AuditEntry) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.nextID == 0 {
+		entries, err := a.readLocked()
+		if err != nil {
+			return err
+		}
+		a.nextID = 1
+		if n := len(entries); n > 0 {
+			a.nextID = entries[n-1].ID + 1
+		}
+	}
+	entry.ID = a.nextID
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(a.path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(a.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	if _, err = f.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	a.nextID++
+	return nil
+}
+
+func (a *auditLog) read() ([]AuditEntry, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.readLocked()
+}
+
+func (a *auditLog) readLocked() ([]AuditEntry, error) {
+	f, err := os.Open(a.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var entry AuditEntry
+		if errUnmarshal := json.Unmarshal(scanner.Bytes(), &entry); errUnmarshal != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// AuditMiddleware records every mutating management request together with a redacted
+// diff of the configuration and auth files before and after the handler ran.
+func (h *Handler) AuditMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			c.Next()
+			return
+		}
+
+		beforeCfg := snapshotConfig(h.cfg)
+		beforeAuths := h.snapshotAuthFiles()
+		c.Next()
+
+		changes := diff.BuildConfigChangeDetails(beforeCfg, snapshotConfig(h.cfg))
+		changes = append(changes, diffAuthFiles(beforeAuths, h.snapshotAuthFiles())...)
+		entry := AuditEntry{
+			Timestamp:  time.Now().UTC(),
+			RemoteAddr: c.ClientIP(),
+			Actor:      c.GetString(managementActorKey),
+			Method:     c.Request.Method,
+			Endpoint:   c.Request.URL.Path,
+			Status:     c.Writer.Status(),
+			Changes:    changes,
+		}
+		if err := h.auditLog().append(entry); err != nil {
+			log.Errorf("management audit: failed to record %s %s: %v", entry.Method, entry.Endpoint, err)
+		}
+	}
+}
+
+// snapshotConfig deep-copies cfg so later in-place mutations do not affect the snapshot.
+func snapshotConfig(cfg *config.Config) *config.Config {
+	if cfg == nil {
+		return &config.Config{}
+	}
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return &config.Config{}
+	}
+	var out config.Config
+	if err = yaml.Unmarshal(data, &out); err != nil {
+		return &config.Config{}
+	}
+	return &out
+}
+
+// snapshotAuthFiles maps auth IDs to their enabled state.
+func (h *Handler) snapshotAuthFiles() map[string]bool {
+	if h.authManager == nil {
+		return nil
+	}
+	auths := h.authManager.List()
+	out := make(map[string]bool, len(auths))
+	for _, auth := range auths {
+		if auth != nil {
+			out[auth.ID] = !auth.Disabled
+		}
+	}
+	return out
+}
+
+func diffAuthFiles(before, after map[string]bool) []string {
+	var changes []string
+	for id, enabled := range after {
+		previous, existed := before[id]
+		switch {
+		case !existed:
+			changes = append(changes, fmt.Sprintf("auth-files: added %s", id))
+		case previous && !enabled:
+			changes = append(changes, fmt.Sprintf("auth-files: disabled %s", id))
+		case !previous && enabled:
+			changes = append(changes, fmt.Sprintf("auth-files: enabled %s", id))
+		}
+	}
+	for id := range before {
+		if _, exists := after[id]; !exists {
+			changes = append(changes, fmt.Sprintf("auth-files: removed %s", id))
+		}
+	}
+	sort.Strings(changes)
+	return changes
+}
+
+// GetAuditLog returns recorded management mutations, newest first.
+// Supported filters: method, endpoint (substring), remote, actor, after and before (unix seconds).
+// Pagination uses limit (default 50, max 500) and offset.
+func (h *Handler) GetAuditLog(c *gin.Context) {
+	limit, err := parseLimit(c.Query("limit"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid limit: %v", err)})
+		return
+	}
+	if limit == 0 {
+		limit = defaultAuditPageSize
+	}
+	if limit > maxAuditPageSize {
+		limit = maxAuditPageSize
+	}
+	offset := 0
+	if raw := strings.TrimSpace(c.Query("offset")); raw != "" {
+		if offset, err = strconv.Atoi(raw); err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset: must be a non-negative integer"})
+			return
+		}
+	}
+
+	entries, err := h.auditLog().read()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to read audit log: %v", err)})
+		return
+	}
+
+	method := strings.ToUpper(strings.TrimSpace(c.Query("method")))
+	endpoint := strings.TrimSpace(c.Query("endpoint"))
+	remote := strings.TrimSpace(c.Query("remote"))
+	actor := strings.TrimSpace(c.Query("actor"))
+	after := parseCutoff(c.Query("after"))
+	before := parseCutoff(c.Query("before"))
+
+	filtered := make([]AuditEntry, 0, len(entries))
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		if method != "" && entry.Method != method {
+			continue
+		}
+		if endpoint != "" && !strings.Contains(entry.Endpoint, endpoint) {
+			continue
+		}
+		if remote != "" && entry.RemoteAddr != remote {
+			continue
+		}
+		if actor != "" && entry.Actor != actor {
+			continue
+		}
+		if after > 0 && entry.Timestamp.Unix() <= after {
+			continue
+		}
+		if before > 0 && entry.Timestamp.Unix() >= before {
+			continue
+		}
+		filtered = append(filtered, entry)
+	}
+
+	total := len(filtered)
+	page := []AuditEntry{}
+	if offset < total {
+		end := offset + limit
+		if end > total {
+			end = total
+		}
+		page = filtered[offset:end]
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"entries": page,
+		"total":   total,
+		"limit":   limit,
+		"offset":  offset,
+	})
+}
@@ -0,0 +1,59 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestAuditMiddleware_RecordsMutationsWithPagination(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{cfg: &config.Config{}, logDir: t.TempDir()}
+
+	router := gin.New()
+	router.Use(h.AuditMiddleware())
+	router.PUT("/v0/management/debug", func(c *gin.Context) {
+		h.cfg.Debug = true
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	router.DELETE("/v0/management/api-keys", func(c *gin.Context) { c.Status(http.StatusNotFound) })
+	router.GET("/v0/management/audit", h.GetAuditLog)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodPut, "/v0/management/debug", strings.NewReader(`{"value":true}`)),
+		httptest.NewRequest(http.MethodGet, "/v0/management/audit", nil),
+		httptest.NewRequest(http.MethodDelete, "/v0/management/api-keys", nil),
+	} {
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	var page struct {
+		Entries []AuditEntry `json:"entries"`
+		Total   int          `json:"total"`
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v0/management/audit?limit=1", nil))
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatalf("decode audit page: %v", err)
+	}
+	if page.Total != 2 || len(page.Entries) != 1 {
+		t.Fatalf("audit page = %+v, want 1 of 2 entries", page)
+	}
+	if got := page.Entries[0]; got.ID != 2 || got.Method != http.MethodDelete || got.Status != http.StatusNotFound {
+		t.Fatalf("newest entry = %+v, want the DELETE", got)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v0/management/audit?method=put", nil))
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatalf("decode filtered page: %v", err)
+	}
+	if page.Total != 1 || len(page.Entries[0].Changes) != 1 || page.Entries[0].Changes[0] != "debug: false -> true" {
+		t.Fatalf("filtered page = %+v, want debug change", page)
+	}
+}
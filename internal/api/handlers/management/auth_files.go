@@ -325,6 +325,36 @@ func (h *Handler) GetAuthFileModels(c *gin.Context) {
 	c.JSON(200, gin.H{"models": result})
 }
 
+// ProbeAuthFile runs a health probe on a specific auth file right away and returns its result.
+func (h *Handler) ProbeAuthFile(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	name := c.Query("name")
+	if name == "" {
+		c.JSON(400, gin.H{"error": "name is required"})
+		return
+	}
+	var authID string
+	for _, auth := range h.authManager.List() {
+		if auth.FileName == name || auth.ID == name {
+			authID = auth.ID
+			break
+		}
+	}
+	if authID == "" {
+		c.JSON(404, gin.H{"error": "auth file not found"})
+		return
+	}
+	probe, err := h.authManager.ProbeAuth(c.Request.Context(), authID)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"id": authID, "probe": probe})
+}
+
 // List auth files from disk when the auth manager is unavailable.
 func (h *Handler) listAuthFilesFromDisk(c *gin.Context) {
 	entries, err := os.ReadDir(h.cfg.AuthDir)
@@ -412,6 +442,14 @@ func (h *Handler) buildAuthFileEntry(auth *coreauth.Auth) gin.H {
 	if !auth.LastRefreshedAt.IsZero() {
 		entry["last_refresh"] = auth.LastRefreshedAt
 	}
+	if auth.RateLimits != nil {
+		entry["rate_limits"] = auth.RateLimits
+	}
+	if h.authManager != nil {
+		if probe, ok := h.authManager.LastProbe(auth.ID); ok {
+			entry["last_probe"] = probe
+		}
+	}
 	if path != "" {
 		entry["path"] = path
 		entry["source"] = "file"
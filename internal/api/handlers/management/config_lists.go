@@ -7,6 +7,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/routing"
 )
 
 // Generic helpers for list[string]
@@ -104,21 +105,6 @@ func (h *Handler) deleteFromStringList(c *gin.Context, target *[]string, after f
 	c.JSON(400, gin.H{"error": "missing index or value"})
 }
 
-// api-keys
-func (h *Handler) GetAPIKeys(c *gin.Context) { c.JSON(200, gin.H{"api-keys": h.cfg.APIKeys}) }
-func (h *Handler) PutAPIKeys(c *gin.Context) {
-	h.putStringList(c, func(v []string) {
-		h.cfg.APIKeys = append([]string(nil), v...)
-		h.cfg.Access.Providers = nil
-	}, nil)
-}
-func (h *Handler) PatchAPIKeys(c *gin.Context) {
-	h.patchStringList(c, &h.cfg.APIKeys, func() { h.cfg.Access.Providers = nil })
-}
-func (h *Handler) DeleteAPIKeys(c *gin.Context) {
-	h.deleteFromStringList(c, &h.cfg.APIKeys, func() { h.cfg.Access.Providers = nil })
-}
-
 // gemini-api-key: []GeminiKey
 func (h *Handler) GetGeminiKeys(c *gin.Context) {
 	c.JSON(200, gin.H{"gemini-api-key": h.cfg.GeminiKey})
@@ -913,3 +899,105 @@ func (h *Handler) GetAmpForceModelMappings(c *gin.Context) {
 func (h *Handler) PutAmpForceModelMappings(c *gin.Context) {
 	h.updateBoolField(c, func(v bool) { h.cfg.AmpCode.ForceModelMappings = v })
 }
+
+// model-routing: []ModelRoute
+func (h *Handler) GetModelRouting(c *gin.Context) {
+	if h == nil || h.cfg == nil {
+		c.JSON(200, gin.H{"model-routing": []config.ModelRoute{}})
+		return
+	}
+	routes := h.cfg.ModelRouting
+	if routes == nil {
+		routes = []config.ModelRoute{}
+	}
+	c.JSON(200, gin.H{"model-routing": routes})
+}
+
+// PutModelRouting replaces all model routing rules.
+func (h *Handler) PutModelRouting(c *gin.Context) {
+	var body struct {
+		Value []config.ModelRoute `json:"value"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": "invalid body"})
+		return
+	}
+	if err := routing.ValidateRoutes(body.Value); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	h.cfg.ModelRouting = body.Value
+	h.cfg.SanitizeModelRouting()
+	h.persist(c)
+}
+
+// PatchModelRouting adds routes or replaces existing ones with the same match.
+func (h *Handler) PatchModelRouting(c *gin.Context) {
+	var body struct {
+		Value []config.ModelRoute `json:"value"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || len(body.Value) == 0 {
+		c.JSON(400, gin.H{"error": "invalid body"})
+		return
+	}
+	if err := routing.ValidateRoutes(body.Value); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	existing := make(map[string]int, len(h.cfg.ModelRouting))
+	for i, route := range h.cfg.ModelRouting {
+		existing[strings.TrimSpace(route.Match)] = i
+	}
+	for _, route := range body.Value {
+		match := strings.TrimSpace(route.Match)
+		if idx, ok := existing[match]; ok {
+			h.cfg.ModelRouting[idx] = route
+			continue
+		}
+		h.cfg.ModelRouting = append(h.cfg.ModelRouting, route)
+		existing[match] = len(h.cfg.ModelRouting) - 1
+	}
+	h.cfg.SanitizeModelRouting()
+	h.persist(c)
+}
+
+// DeleteModelRouting removes routes by match, or all routes when no match is given.
+func (h *Handler) DeleteModelRouting(c *gin.Context) {
+	if match := strings.TrimSpace(c.Query("match")); match != "" {
+		out := make([]config.ModelRoute, 0, len(h.cfg.ModelRouting))
+		for _, route := range h.cfg.ModelRouting {
+			if strings.TrimSpace(route.Match) != match {
+				out = append(out, route)
+			}
+		}
+		if len(out) == len(h.cfg.ModelRouting) {
+			c.JSON(404, gin.H{"error": "item not found"})
+			return
+		}
+		h.cfg.ModelRouting = out
+		h.persist(c)
+		return
+	}
+
+	var body struct {
+		Value []string `json:"value"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || len(body.Value) == 0 {
+		h.cfg.ModelRouting = nil
+		h.persist(c)
+		return
+	}
+	toRemove := make(map[string]bool, len(body.Value))
+	for _, match := range body.Value {
+		toRemove[strings.TrimSpace(match)] = true
+	}
+	out := make([]config.ModelRoute, 0, len(h.cfg.ModelRouting))
+	for _, route := range h.cfg.ModelRouting {
+		if !toRemove[strings.TrimSpace(route.Match)] {
+			out = append(out, route)
+		}
+	}
+	h.cfg.ModelRouting = out
+	h.persist(c)
+}
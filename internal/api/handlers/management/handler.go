@@ -40,6 +40,9 @@ type Handler struct {
 	allowRemoteOverride bool
 	envSecret           string
 	logDir              string
+	auditMu             sync.Mutex
+	audit               *auditLog
+	verifiedKeys        sync.Map // sha256 of a provided secret -> matching scoped key hash
 }
 
 // NewHandler creates a new management handler instance.
@@ -102,10 +105,12 @@ func (h *Handler) Middleware() gin.HandlerFunc {
 		var (
 			allowRemote bool
 			secretHash  string
+			scopedKeys  []config.ManagementKey
 		)
 		if cfg != nil {
 			allowRemote = cfg.RemoteManagement.AllowRemote
 			secretHash = cfg.RemoteManagement.SecretKey
+			scopedKeys = cfg.RemoteManagement.Keys
 		}
 		if h.allowRemoteOverride {
 			allowRemote = true
@@ -151,7 +156,7 @@ func (h *Handler) Middleware() gin.HandlerFunc {
 				h.attemptsMu.Unlock()
 			}
 		}
-		if secretHash == "" && envSecret == "" {
+		if secretHash == "" && envSecret == "" && len(scopedKeys) == 0 {
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "remote management key not set"})
 			return
 		}
@@ -181,6 +186,7 @@ func (h *Handler) Middleware() gin.HandlerFunc {
 		if localClient {
 			if lp := h.localPassword; lp != "" {
 				if subtle.ConstantTimeCompare([]byte(provided), []byte(lp)) == 1 {
+					c.Set(managementActorKey, "local")
 					c.Next()
 					return
 				}
@@ -196,16 +202,27 @@ func (h *Handler) Middleware() gin.HandlerFunc {
 				}
 				h.attemptsMu.Unlock()
 			}
+			c.Set(managementActorKey, "env")
 			c.Next()
 			return
 		}
 
+		actor := "secret-key"
+		var scopes []string
 		if secretHash == "" || bcrypt.CompareHashAndPassword([]byte(secretHash), []byte(provided)) != nil {
-			if !localClient {
-				fail()
+			key, ok := h.matchManagementKey(scopedKeys, provided)
+			if !ok {
+				if !localClient {
+					fail()
+				}
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid management key"})
+				return
+			}
+			actor = key.Name
+			scopes = managementKeyScopes(key)
+			if scopes == nil {
+				scopes = []string{}
 			}
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid management key"})
-			return
 		}
 
 		if !localClient {
@@ -217,6 +234,12 @@ func (h *Handler) Middleware() gin.HandlerFunc {
 			h.attemptsMu.Unlock()
 		}
 
+		if scopes != nil && !scopesAllow(scopes, c.Request.Method, c.Request.URL.Path) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "management key not permitted for this endpoint"})
+			return
+		}
+
+		c.Set(managementActorKey, actor)
 		c.Next()
 	}
 }
@@ -0,0 +1,297 @@
+package management
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Management route groups that scoped keys are granted access to.
+const (
+	ScopeUsage     = "usage"
+	ScopeLogs      = "logs"
+	ScopeKeys      = "keys"
+	ScopeAuthFiles = "auth-files"
+	ScopeConfig    = "config"
+	// ScopeAdmin covers every group, including management key administration.
+	ScopeAdmin = "admin"
+)
+
+// scopeReadSuffix limits a scope to read-only (GET) requests.
+const scopeReadSuffix = ":read"
+
+// managementActorKey stores the authenticated management identity on the gin context.
+const managementActorKey = "managementActor"
+
+// managementRoles maps predefined role names to their scopes.
+var managementRoles = map[string][]string{
+	"admin":           {ScopeAdmin},
+	"usage-viewer":    {ScopeUsage + scopeReadSuffix},
+	"log-viewer":      {ScopeLogs + scopeReadSuffix},
+	"key-admin":       {ScopeKeys},
+	"auth-file-admin": {ScopeAuthFiles},
+	"config-admin":    {ScopeConfig},
+}
+
+// managementRouteGroups assigns management paths (relative to /v0/management) to scope
+// groups. Paths not listed here require the admin scope, as do the raw config endpoints
+// and the localhost restriction, which expose or replace every secret including the
+// management keys themselves.
+var managementRouteGroups = map[string]string{
+	"/usage":                    ScopeUsage,
+	"/queue":                    ScopeUsage,
+	"/logs":                     ScopeLogs,
+	"/request-error-logs":       ScopeLogs,
+	"/request-log-by-id":        ScopeLogs,
+	"/audit":                    ScopeLogs,
+	"/api-keys":                 ScopeKeys,
+	"/gemini-api-key":           ScopeKeys,
+	"/claude-api-key":           ScopeKeys,
+	"/codex-api-key":            ScopeKeys,
+	"/openai-compatibility":     ScopeKeys,
+	"/ampcode/upstream-api-key": ScopeKeys,
+	"/auth-files":               ScopeAuthFiles,
+	"/vertex/import":            ScopeAuthFiles,
+	"/oauth-callback":           ScopeAuthFiles,
+	"/get-auth-status":          ScopeAuthFiles,
+	"/management-keys":          ScopeAdmin,
+	"/config":                   ScopeAdmin,
+	"/config.yaml":              ScopeAdmin,
+	"/ampcode/restrict-management-to-localhost": ScopeAdmin,
+	"/debug":                    ScopeConfig,
+	"/logging-to-file":          ScopeConfig,
+	"/usage-statistics-enabled": ScopeConfig,
+	"/proxy-url":                ScopeConfig,
+	"/quota-exceeded":           ScopeConfig,
+	"/request-log":              ScopeConfig,
+	"/request-retry":            ScopeConfig,
+	"/max-retry-interval":       ScopeConfig,
+	"/codex-settings":           ScopeConfig,
+	"/ws-auth":                  ScopeConfig,
+	"/oauth-excluded-models":    ScopeConfig,
+	"/model-routing":            ScopeConfig,
+	"/ampcode":                  ScopeConfig,
+	"/latest-version":           "",
+}
+
+// managementRouteGroup returns the scope group guarding path.
+func managementRouteGroup(path string) string {
+	rel := strings.TrimPrefix(path, "/v0/management")
+	for candidate := rel; candidate != "" && candidate != "/"; {
+		if group, ok := managementRouteGroups[candidate]; ok {
+			return group
+		}
+		idx := strings.LastIndex(candidate, "/")
+		if idx <= 0 {
+			break
+		}
+		candidate = candidate[:idx]
+	}
+	if strings.HasSuffix(rel, "-auth-url") {
+		return ScopeAuthFiles
+	}
+	return ScopeAdmin
+}
+
+// managementKeyScopes expands a key's role and explicit scopes.
+func managementKeyScopes(key config.ManagementKey) []string {
+	scopes := append([]string(nil), managementRoles[strings.ToLower(strings.TrimSpace(key.Role))]...)
+	return append(scopes, key.Scopes...)
+}
+
+// scopesAllow reports whether scopes grant method access to path.
+func scopesAllow(scopes []string, method, path string) bool {
+	group := managementRouteGroup(path)
+	if group == "" {
+		return true
+	}
+	readOnly := method == http.MethodGet || method == http.MethodHead
+	for _, scope := range scopes {
+		scope = normalizeScope(scope)
+		if scope == ScopeAdmin || scope == group {
+			return true
+		}
+		if readOnly && scope == group+scopeReadSuffix {
+			return true
+		}
+	}
+	return false
+}
+
+// matchManagementKey returns the scoped key matching provided, consulting a cache of
+// previously verified secrets so repeated requests avoid bcrypt comparisons.
+func (h *Handler) matchManagementKey(keys []config.ManagementKey, provided string) (config.ManagementKey, bool) {
+	sum := sha256.Sum256([]byte(provided))
+	fingerprint := hex.EncodeToString(sum[:])
+	if cached, ok := h.verifiedKeys.Load(fingerprint); ok {
+		for _, key := range keys {
+			if key.Key == cached.(string) {
+				return key, true
+			}
+		}
+	}
+	for _, key := range keys {
+		if bcrypt.CompareHashAndPassword([]byte(key.Key), []byte(provided)) == nil {
+			h.verifiedKeys.Store(fingerprint, key.Key)
+			return key, true
+		}
+	}
+	return config.ManagementKey{}, false
+}
+
+// managementKeyView is the listing form of a scoped key; secrets are never returned.
+type managementKeyView struct {
+	Name   string   `json:"name"`
+	Role   string   `json:"role,omitempty"`
+	Scopes []string `json:"scopes,omitempty"`
+}
+
+// GetManagementKeys lists scoped management keys without their secrets.
+func (h *Handler) GetManagementKeys(c *gin.Context) {
+	views := []managementKeyView{}
+	if h != nil && h.cfg != nil {
+		for _, key := range h.cfg.RemoteManagement.Keys {
+			views = append(views, managementKeyView{Name: key.Name, Role: key.Role, Scopes: key.Scopes})
+		}
+	}
+	roles := make([]string, 0, len(managementRoles))
+	for role := range managementRoles {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	c.JSON(http.StatusOK, gin.H{"management-keys": views, "roles": roles})
+}
+
+// PutManagementKeys replaces all scoped management keys.
+func (h *Handler) PutManagementKeys(c *gin.Context) {
+	var body struct {
+		Value []config.ManagementKey `json:"value"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	keys, ok := h.prepareManagementKeys(c, body.Value, nil)
+	if !ok {
+		return
+	}
+	h.cfg.RemoteManagement.Keys = keys
+	h.cfg.SanitizeManagementKeys()
+	h.persist(c)
+}
+
+// PatchManagementKeys adds keys or updates existing ones by name. An empty key keeps
+// the stored secret.
+func (h *Handler) PatchManagementKeys(c *gin.Context) {
+	var body struct {
+		Value []config.ManagementKey `json:"value"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || len(body.Value) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	existing := make(map[string]int, len(h.cfg.RemoteManagement.Keys))
+	for i, key := range h.cfg.RemoteManagement.Keys {
+		existing[key.Name] = i
+	}
+	keys, ok := h.prepareManagementKeys(c, body.Value, existing)
+	if !ok {
+		return
+	}
+	for _, key := range keys {
+		if idx, found := existing[key.Name]; found {
+			if key.Key == "" {
+				key.Key = h.cfg.RemoteManagement.Keys[idx].Key
+			}
+			h.cfg.RemoteManagement.Keys[idx] = key
+			continue
+		}
+		h.cfg.RemoteManagement.Keys = append(h.cfg.RemoteManagement.Keys, key)
+		existing[key.Name] = len(h.cfg.RemoteManagement.Keys) - 1
+	}
+	h.cfg.SanitizeManagementKeys()
+	h.persist(c)
+}
+
+// DeleteManagementKeys removes a scoped key by name, or all keys when no name is given.
+func (h *Handler) DeleteManagementKeys(c *gin.Context) {
+	name := strings.TrimSpace(c.Query("name"))
+	if name == "" {
+		h.cfg.RemoteManagement.Keys = nil
+		h.persist(c)
+		return
+	}
+	out := make([]config.ManagementKey, 0, len(h.cfg.RemoteManagement.Keys))
+	for _, key := range h.cfg.RemoteManagement.Keys {
+		if key.Name != name {
+			out = append(out, key)
+		}
+	}
+	if len(out) == len(h.cfg.RemoteManagement.Keys) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "item not found"})
+		return
+	}
+	h.cfg.RemoteManagement.Keys = out
+	h.persist(c)
+}
+
+// prepareManagementKeys validates names, roles and scopes and hashes plaintext secrets.
+// Keys listed in existing may omit the secret.
+func (h *Handler) prepareManagementKeys(c *gin.Context, keys []config.ManagementKey, existing map[string]int) ([]config.ManagementKey, bool) {
+	out := make([]config.ManagementKey, 0, len(keys))
+	for _, key := range keys {
+		key.Name = strings.TrimSpace(key.Name)
+		key.Role = strings.ToLower(strings.TrimSpace(key.Role))
+		if key.Name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "management key name is required"})
+			return nil, false
+		}
+		if _, found := existing[key.Name]; !found && strings.TrimSpace(key.Key) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "management key secret is required for " + key.Name})
+			return nil, false
+		}
+		if _, known := managementRoles[key.Role]; key.Role != "" && !known {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown management role " + key.Role})
+			return nil, false
+		}
+		for _, scope := range key.Scopes {
+			if !validScope(scope) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "unknown management scope " + scope})
+				return nil, false
+			}
+		}
+		if key.Role == "" && len(key.Scopes) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "management key " + key.Name + " needs a role or scopes"})
+			return nil, false
+		}
+		hashed, err := config.HashManagementKey(key.Key)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to hash management key"})
+			return nil, false
+		}
+		key.Key = hashed
+		out = append(out, key)
+	}
+	return out, true
+}
+
+// normalizeScope folds case and surrounding space so stored scopes match the way they
+// were validated.
+func normalizeScope(scope string) string {
+	return strings.ToLower(strings.TrimSpace(scope))
+}
+
+func validScope(scope string) bool {
+	scope = strings.TrimSuffix(normalizeScope(scope), scopeReadSuffix)
+	switch scope {
+	case ScopeUsage, ScopeLogs, ScopeKeys, ScopeAuthFiles, ScopeConfig, ScopeAdmin:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package management
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestMiddleware_EnforcesScopedManagementKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	hashed, err := config.HashManagementKey("viewer-secret")
+	if err != nil {
+		t.Fatalf("HashManagementKey() error = %v", err)
+	}
+	h := NewHandler(&config.Config{RemoteManagement: config.RemoteManagement{
+		AllowRemote: true,
+		Keys:        []config.ManagementKey{{Name: "dashboard", Key: hashed, Role: "usage-viewer"}},
+	}}, "", nil)
+
+	router := gin.New()
+	router.Use(h.Middleware())
+	ok := func(c *gin.Context) { c.String(http.StatusOK, c.GetString(managementActorKey)) }
+	router.GET("/v0/management/usage", ok)
+	router.POST("/v0/management/usage/import", ok)
+	router.GET("/v0/management/auth-files/download", ok)
+
+	cases := []struct {
+		method, path, key string
+		want              int
+	}{
+		{http.MethodGet, "/v0/management/usage", "viewer-secret", http.StatusOK},
+		{http.MethodPost, "/v0/management/usage/import", "viewer-secret", http.StatusForbidden},
+		{http.MethodGet, "/v0/management/auth-files/download", "viewer-secret", http.StatusForbidden},
+		{http.MethodGet, "/v0/management/usage", "wrong", http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		req.Header.Set("Authorization", "Bearer "+tc.key)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("%s %s with %q = %d, want %d", tc.method, tc.path, tc.key, rec.Code, tc.want)
+		}
+		if tc.want == http.StatusOK && rec.Body.String() != "dashboard" {
+			t.Errorf("actor = %q, want dashboard", rec.Body.String())
+		}
+	}
+}
+
+func TestScopesAllow_ConfigAdminCannotReachAdminRoutes(t *testing.T) {
+	configAdmin := managementKeyScopes(config.ManagementKey{Role: " Config-Admin "})
+	cases := []struct {
+		method, path string
+		want         bool
+	}{
+		{http.MethodPut, "/v0/management/debug", true},
+		{http.MethodPatch, "/v0/management/quota-exceeded/switch-project", true},
+		{http.MethodGet, "/v0/management/config", false},
+		{http.MethodGet, "/v0/management/config.yaml", false},
+		{http.MethodPut, "/v0/management/config.yaml", false},
+		{http.MethodPut, "/v0/management/ampcode/restrict-management-to-localhost", false},
+		{http.MethodPut, "/v0/management/management-keys", false},
+		{http.MethodGet, "/v0/management/some-future-endpoint", false},
+	}
+	for _, tc := range cases {
+		if got := scopesAllow(configAdmin, tc.method, tc.path); got != tc.want {
+			t.Errorf("config-admin %s %s = %v, want %v", tc.method, tc.path, got, tc.want)
+		}
+	}
+	if !scopesAllow([]string{" CONFIG "}, http.MethodPut, "/v0/management/debug") {
+		t.Error("scopes are not normalized like validScope")
+	}
+	if !scopesAllow([]string{"Usage:Read"}, http.MethodGet, "/v0/management/usage") {
+		t.Error("read-only scopes are not normalized like validScope")
+	}
+	if !scopesAllow([]string{ScopeAdmin}, http.MethodPut, "/v0/management/config.yaml") {
+		t.Error("admin cannot replace config.yaml")
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 )
 
 type usageExportPayload struct {
@@ -29,9 +30,23 @@ func (h *Handler) GetUsageStatistics(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"usage":           snapshot,
 		"failed_requests": snapshot.FailureCount,
+		"queue":           h.queueStats(),
 	})
 }
 
+// GetQueueStatus returns the depth of the queue holding requests while every credential
+// for their model is cooling down.
+func (h *Handler) GetQueueStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, h.queueStats())
+}
+
+func (h *Handler) queueStats() coreauth.QueueStats {
+	if h == nil || h.authManager == nil {
+		return coreauth.QueueStats{}
+	}
+	return h.authManager.QueueStats()
+}
+
 // ExportUsageStatistics returns a complete usage snapshot for backup/migration.
 func (h *Handler) ExportUsageStatistics(c *gin.Context) {
 	var snapshot usage.StatisticsSnapshot
@@ -0,0 +1,97 @@
+// Package middleware provides HTTP middleware components for the CLI Proxy API server.
+// This file contains the per-client-key rate limiting middleware.
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/ratelimit"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+)
+
+// RateLimitMiddleware enforces the per-client-key limits tracked by limiter.
+// It must run after authentication so the client key is available as "apiKey".
+// Rejected requests receive 429 with a Retry-After header and an error body in
+// the client's dialect.
+func RateLimitMiddleware(limiter *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limiter == nil {
+			c.Next()
+			return
+		}
+		apiKey := c.GetString("apiKey")
+		limit, ok := limiter.Limits(apiKey)
+		if apiKey == "" || !ok || c.Request.Method == http.MethodGet {
+			c.Next()
+			return
+		}
+
+		stream := limit.MaxConcurrentStreams > 0 && isStreamingRequest(c)
+		release, err := limiter.Acquire(apiKey, stream)
+		if err != nil {
+			var limitErr *ratelimit.LimitError
+			if !errors.As(err, &limitErr) {
+				c.Next()
+				return
+			}
+			retryAfter := int(math.Ceil(limitErr.RetryAfter.Seconds()))
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			body := handlers.BuildErrorResponseBodyForFormat(requestFormat(c.Request.URL.Path), http.StatusTooManyRequests, limitErr.Reason)
+			c.Data(http.StatusTooManyRequests, "application/json", body)
+			c.Abort()
+			return
+		}
+		defer release()
+
+		c.Next()
+	}
+}
+
+// requestFormat infers the client dialect from the request path. Amp provider aliases
+// (/api/provider/{provider}/...) are classified by the path after the provider.
+func requestFormat(path string) string {
+	if rest, ok := strings.CutPrefix(path, "/api/provider/"); ok {
+		if idx := strings.IndexByte(rest, '/'); idx >= 0 {
+			path = rest[idx:]
+		}
+	}
+	switch {
+	case strings.HasPrefix(path, "/v1/messages"):
+		return "claude"
+	case strings.HasPrefix(path, "/v1beta/"), strings.HasPrefix(path, "/v1beta1/"):
+		return "gemini"
+	case strings.HasPrefix(path, "/v1internal"):
+		return "gemini-cli"
+	default:
+		return "openai"
+	}
+}
+
+// isStreamingRequest reports whether the request asks for a streamed response.
+// The request body is restored so subsequent handlers can read it.
+func isStreamingRequest(c *gin.Context) bool {
+	path := c.Request.URL.Path
+	if strings.Contains(path, "streamGenerateContent") || c.Query("alt") == "sse" {
+		return true
+	}
+	if c.Request.Body == nil {
+		return false
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return false
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	return gjson.GetBytes(body, "stream").Bool()
+}
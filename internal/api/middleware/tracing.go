@@ -0,0 +1,47 @@
+// Package middleware provides HTTP middleware components for the CLI Proxy API server.
+// This file contains the inbound request tracing middleware.
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/tracing"
+)
+
+// TracingMiddleware starts a server span for every AI API request, continuing the trace
+// from the client's traceparent header when present. It must run after the request
+// logger so the request ID is available and recorded on the span.
+func TracingMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := logging.GetGinRequestID(c)
+		if requestID == "" || !tracing.Enabled() {
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+		if parent, ok := tracing.ParseTraceparent(c.GetHeader(tracing.TraceparentHeader)); ok {
+			ctx = tracing.ContextWithRemoteParent(ctx, parent)
+		}
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+		ctx, span := tracing.Start(ctx, c.Request.Method+" "+route, tracing.SpanKindServer)
+		span.SetAttribute("http.request.method", c.Request.Method)
+		span.SetAttribute("http.route", route)
+		span.SetAttribute("cliproxy.request_id", requestID)
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttribute("http.response.status_code", status)
+		if status >= http.StatusInternalServerError {
+			span.SetStatusError(http.StatusText(status))
+		}
+		span.End()
+	}
+}
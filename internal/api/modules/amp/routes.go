@@ -8,7 +8,9 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/api/middleware"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/ratelimit"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers/claude"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers/gemini"
@@ -205,14 +207,24 @@ func (m *AmpModule) registerManagementRoutes(engine *gin.Engine, baseHandler *ha
 	// Route POST model calls through Gemini bridge with FallbackHandler.
 	// FallbackHandler checks provider -> mapping -> proxy fallback automatically.
 	// All other methods (e.g., GET model listing) always proxy to upstream to preserve Amp CLI behavior.
-	ampAPI.Any("/provider/google/v1beta1/*path", func(c *gin.Context) {
-		if c.Request.Method == "POST" {
-			if path := c.Param("path"); strings.Contains(path, "/models/") {
-				// POST with /models/ path -> use Gemini bridge with fallback handler
-				// FallbackHandler will check provider/mapping and proxy if needed
-				geminiV1Beta1Handler(c)
-				return
-			}
+	// Model calls are charged against the client key limits like the /v1beta routes.
+	rateLimit := middleware.RateLimitMiddleware(ratelimit.Default())
+	isModelCall := func(c *gin.Context) bool {
+		return c.Request.Method == "POST" && strings.Contains(c.Param("path"), "/models/")
+	}
+	limitModelCalls := func(c *gin.Context) {
+		if isModelCall(c) {
+			rateLimit(c)
+			return
+		}
+		c.Next()
+	}
+	ampAPI.Any("/provider/google/v1beta1/*path", limitModelCalls, func(c *gin.Context) {
+		if isModelCall(c) {
+			// POST with /models/ path -> use Gemini bridge with fallback handler
+			// FallbackHandler will check provider/mapping and proxy if needed
+			geminiV1Beta1Handler(c)
+			return
 		}
 		// Non-POST or no local provider available -> proxy upstream
 		proxyHandler(c)
@@ -240,10 +252,13 @@ func (m *AmpModule) registerProviderAliases(engine *gin.Engine, baseHandler *han
 	}, m.modelMapper, m.forceModelMappings)
 
 	// Provider-specific routes under /api/provider/:provider
+	// The aliases serve the same handlers as /v1 and /v1beta, so they are charged against
+	// the same client key limits.
 	ampProviders := engine.Group("/api/provider")
 	if auth != nil {
 		ampProviders.Use(auth)
 	}
+	ampProviders.Use(middleware.RateLimitMiddleware(ratelimit.Default()))
 
 	provider := ampProviders.Group("/:provider")
 
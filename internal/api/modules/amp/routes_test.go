@@ -6,7 +6,10 @@ import (
 	"testing"
 
 	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/ratelimit"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
 )
 
 func TestRegisterManagementRoutes(t *testing.T) {
@@ -379,3 +382,29 @@ func TestLocalhostOnlyMiddleware_HotReload(t *testing.T) {
 		t.Errorf("Expected 403 after re-enabling restriction, got %d", w.Code)
 	}
 }
+
+func TestRegisterProviderAliases_AppliesClientRateLimits(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ratelimit.Default().SetLimits([]config.APIKeyLimit{{APIKey: "amp-limited", RequestsPerMinute: 1}})
+	t.Cleanup(func() { ratelimit.Default().SetLimits(nil) })
+	// Use up the key's only request of this minute.
+	if _, err := ratelimit.Default().Acquire("amp-limited", false); err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+
+	r := gin.New()
+	auth := func(c *gin.Context) { c.Set("apiKey", "amp-limited") }
+	m := &AmpModule{authMiddleware_: auth}
+	m.registerProviderAliases(r, &handlers.BaseAPIHandler{}, auth)
+
+	for _, path := range []string{"/api/provider/openai/v1/chat/completions", "/api/provider/anthropic/v1/messages"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, nil))
+		if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+			t.Fatalf("%s = %d, want 429 with Retry-After", path, w.Code)
+		}
+		if path == "/api/provider/anthropic/v1/messages" && gjson.GetBytes(w.Body.Bytes(), "type").String() != "error" {
+			t.Fatalf("%s body = %s, want a Claude error", path, w.Body.String())
+		}
+	}
+}
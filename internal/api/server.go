@@ -23,9 +23,15 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/api/middleware"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/api/modules"
 	ampmodule "github.com/router-for-me/CLIProxyAPI/v6/internal/api/modules/amp"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/batch"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/guardrail"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/managementasset"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/metrics"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/ratelimit"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/tracing"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
@@ -147,6 +153,9 @@ type Server struct {
 	wsAuthChanged func(bool, bool)
 	wsAuthEnabled atomic.Bool
 
+	// batches runs OpenAI Batch API emulation jobs.
+	batches *batch.Manager
+
 	// management handler
 	mgmt *managementHandlers.Handler
 
@@ -201,6 +210,7 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	// Add middleware
 	engine.Use(logging.GinLogrusLogger())
 	engine.Use(logging.GinLogrusRecovery())
+	engine.Use(middleware.TracingMiddleware())
 	for _, mw := range optionState.extraMiddleware {
 		engine.Use(mw)
 	}
@@ -248,6 +258,13 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	// Save initial YAML snapshot
 	s.oldConfigYaml, _ = yaml.Marshal(cfg)
 	s.applyAccessConfig(nil, cfg)
+	ratelimit.Default().SetLimits(cfg.APIKeyLimits)
+	tracing.Configure(cfg.Tracing)
+	guardrail.Configure(cfg.Guardrails)
+	s.applyResponseCacheConfig(cfg)
+	s.applyResponsesStoreConfig(cfg)
+	s.batches = batch.NewManager(openai.NewBatchExecutor(s.handlers))
+	s.applyBatchConfig(cfg)
 	if authManager != nil {
 		authManager.SetRetryConfig(cfg.RequestRetry, time.Duration(cfg.MaxRetryInterval)*time.Second)
 	}
@@ -286,7 +303,7 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	}
 
 	// Register management routes when configuration or environment secrets are available.
-	hasManagementSecret := cfg.RemoteManagement.SecretKey != "" || envManagementSecret
+	hasManagementSecret := hasManagementKeys(cfg) || envManagementSecret
 	s.managementRoutesEnabled.Store(hasManagementSecret)
 	if hasManagementSecret {
 		s.registerManagementRoutes()
@@ -314,10 +331,12 @@ func (s *Server) setupRoutes() {
 	geminiCLIHandlers := gemini.NewGeminiCLIAPIHandler(s.handlers)
 	claudeCodeHandlers := claude.NewClaudeCodeAPIHandler(s.handlers)
 	openaiResponsesHandlers := openai.NewOpenAIResponsesAPIHandler(s.handlers)
+	openaiEmbeddingsHandlers := openai.NewOpenAIEmbeddingsAPIHandler(s.handlers)
+	openaiBatchHandlers := openai.NewOpenAIBatchAPIHandler(s.handlers, s.batches)
 
 	// OpenAI compatible API routes
 	v1 := s.engine.Group("/v1")
-	v1.Use(AuthMiddleware(s.accessManager))
+	v1.Use(AuthMiddleware(s.accessManager), middleware.RateLimitMiddleware(ratelimit.Default()))
 	{
 		v1.GET("/models", s.unifiedModelsHandler(openaiHandlers, claudeCodeHandlers))
 		v1.POST("/chat/completions", openaiHandlers.ChatCompletions)
@@ -325,17 +344,33 @@ func (s *Server) setupRoutes() {
 		v1.POST("/messages", claudeCodeHandlers.ClaudeMessages)
 		v1.POST("/messages/count_tokens", claudeCodeHandlers.ClaudeCountTokens)
 		v1.POST("/responses", openaiResponsesHandlers.Responses)
+		v1.GET("/responses/:id", openaiResponsesHandlers.GetResponse)
+		v1.DELETE("/responses/:id", openaiResponsesHandlers.DeleteResponse)
+		v1.GET("/responses/:id/input_items", openaiResponsesHandlers.ListResponseInputItems)
+		v1.POST("/embeddings", openaiEmbeddingsHandlers.Embeddings)
+		v1.POST("/files", openaiBatchHandlers.UploadFile)
+		v1.GET("/files", openaiBatchHandlers.ListFiles)
+		v1.GET("/files/:id", openaiBatchHandlers.GetFile)
+		v1.GET("/files/:id/content", openaiBatchHandlers.GetFileContent)
+		v1.DELETE("/files/:id", openaiBatchHandlers.DeleteFile)
+		v1.POST("/batches", openaiBatchHandlers.CreateBatch)
+		v1.GET("/batches", openaiBatchHandlers.ListBatches)
+		v1.GET("/batches/:id", openaiBatchHandlers.GetBatch)
+		v1.POST("/batches/:id/cancel", openaiBatchHandlers.CancelBatch)
 	}
 
 	// Gemini compatible API routes
 	v1beta := s.engine.Group("/v1beta")
-	v1beta.Use(AuthMiddleware(s.accessManager))
+	v1beta.Use(AuthMiddleware(s.accessManager), middleware.RateLimitMiddleware(ratelimit.Default()))
 	{
 		v1beta.GET("/models", geminiHandlers.GeminiModels)
 		v1beta.POST("/models/*action", geminiHandlers.GeminiHandler)
 		v1beta.GET("/models/*action", geminiHandlers.GeminiGetHandler)
+		v1beta.GET("/batches/:id", geminiHandlers.GeminiBatchGet)
 	}
 
+	s.engine.GET("/metrics", s.metricsAvailabilityMiddleware(), s.serveMetrics)
+
 	// Root endpoint
 	s.engine.GET("/", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
@@ -343,6 +378,7 @@ func (s *Server) setupRoutes() {
 			"endpoints": []string{
 				"POST /v1/chat/completions",
 				"POST /v1/completions",
+				"POST /v1/embeddings",
 				"GET /v1/models",
 			},
 		})
@@ -473,9 +509,15 @@ func (s *Server) registerManagementRoutes() {
 	log.Info("management routes registered after secret key configuration")
 
 	mgmt := s.engine.Group("/v0/management")
-	mgmt.Use(s.managementAvailabilityMiddleware(), s.mgmt.Middleware())
+	mgmt.Use(s.managementAvailabilityMiddleware(), s.mgmt.Middleware(), s.mgmt.AuditMiddleware())
 	{
+		mgmt.GET("/audit", s.mgmt.GetAuditLog)
+		mgmt.GET("/management-keys", s.mgmt.GetManagementKeys)
+		mgmt.PUT("/management-keys", s.mgmt.PutManagementKeys)
+		mgmt.PATCH("/management-keys", s.mgmt.PatchManagementKeys)
+		mgmt.DELETE("/management-keys", s.mgmt.DeleteManagementKeys)
 		mgmt.GET("/usage", s.mgmt.GetUsageStatistics)
+		mgmt.GET("/queue", s.mgmt.GetQueueStatus)
 		mgmt.GET("/usage/export", s.mgmt.ExportUsageStatistics)
 		mgmt.POST("/usage/import", s.mgmt.ImportUsageStatistics)
 		mgmt.GET("/config", s.mgmt.GetConfig)
@@ -550,6 +592,11 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.PUT("/ampcode/force-model-mappings", s.mgmt.PutAmpForceModelMappings)
 		mgmt.PATCH("/ampcode/force-model-mappings", s.mgmt.PutAmpForceModelMappings)
 
+		mgmt.GET("/model-routing", s.mgmt.GetModelRouting)
+		mgmt.PUT("/model-routing", s.mgmt.PutModelRouting)
+		mgmt.PATCH("/model-routing", s.mgmt.PatchModelRouting)
+		mgmt.DELETE("/model-routing", s.mgmt.DeleteModelRouting)
+
 		mgmt.GET("/request-retry", s.mgmt.GetRequestRetry)
 		mgmt.PUT("/request-retry", s.mgmt.PutRequestRetry)
 		mgmt.PATCH("/request-retry", s.mgmt.PutRequestRetry)
@@ -583,6 +630,7 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.GET("/auth-files", s.mgmt.ListAuthFiles)
 		mgmt.GET("/auth-files/models", s.mgmt.GetAuthFileModels)
 		mgmt.GET("/auth-files/download", s.mgmt.DownloadAuthFile)
+		mgmt.POST("/auth-files/probe", s.mgmt.ProbeAuthFile)
 		mgmt.POST("/auth-files", s.mgmt.UploadAuthFile)
 		mgmt.DELETE("/auth-files", s.mgmt.DeleteAuthFile)
 		mgmt.POST("/vertex/import", s.mgmt.ImportVertexCredential)
@@ -599,6 +647,11 @@ func (s *Server) registerManagementRoutes() {
 	}
 }
 
+// hasManagementKeys reports whether cfg configures a management secret or scoped keys.
+func hasManagementKeys(cfg *config.Config) bool {
+	return cfg != nil && (cfg.RemoteManagement.SecretKey != "" || len(cfg.RemoteManagement.Keys) > 0)
+}
+
 func (s *Server) managementAvailabilityMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if !s.managementRoutesEnabled.Load() {
@@ -609,6 +662,65 @@ func (s *Server) managementAvailabilityMiddleware() gin.HandlerFunc {
 	}
 }
 
+func (s *Server) applyResponseCacheConfig(cfg *config.Config) {
+	baseDir := s.currentPath
+	if s.configFilePath != "" {
+		baseDir = filepath.Dir(s.configFilePath)
+	}
+	if err := cache.DefaultResponseCache().Configure(cfg.ResponseCache, baseDir); err != nil {
+		log.Errorf("failed to configure response cache: %v", err)
+	}
+}
+
+func (s *Server) applyResponsesStoreConfig(cfg *config.Config) {
+	baseDir := s.currentPath
+	if s.configFilePath != "" {
+		baseDir = filepath.Dir(s.configFilePath)
+	}
+	if err := cache.DefaultResponsesStore().Configure(cfg.ResponsesStore, baseDir); err != nil {
+		log.Errorf("failed to configure responses store: %v", err)
+	}
+}
+
+func (s *Server) applyBatchConfig(cfg *config.Config) {
+	baseDir := s.currentPath
+	if s.configFilePath != "" {
+		baseDir = filepath.Dir(s.configFilePath)
+	}
+	s.batches.SetAPIKeys(cfg.APIKeys)
+	if err := s.batches.Configure(cfg.Batch, baseDir); err != nil {
+		log.Errorf("failed to configure batch API: %v", err)
+	}
+}
+
+func (s *Server) metricsAvailabilityMiddleware() gin.HandlerFunc {
+	authenticate := AuthMiddleware(s.accessManager)
+	return func(c *gin.Context) {
+		cfg := s.cfg
+		if cfg == nil || !cfg.Metrics.Enabled {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+		if cfg.Metrics.RequireAuth {
+			authenticate(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+func (s *Server) serveMetrics(c *gin.Context) {
+	var auths []*auth.Auth
+	if s.handlers != nil && s.handlers.AuthManager != nil {
+		auths = s.handlers.AuthManager.List()
+	}
+	c.Header("Content-Type", metrics.ContentType)
+	c.Status(http.StatusOK)
+	if err := metrics.Default().Write(c.Writer, auths); err != nil {
+		log.Debugf("failed to write metrics: %v", err)
+	}
+}
+
 func (s *Server) serveManagementControlPanel(c *gin.Context) {
 	cfg := s.cfg
 	if cfg == nil || cfg.RemoteManagement.DisableControlPanel {
@@ -798,6 +910,12 @@ func (s *Server) Stop(ctx context.Context) error {
 		return fmt.Errorf("failed to shutdown HTTP server: %v", err)
 	}
 
+	// Interrupt running batches; they resume from their recorded progress on the next start.
+	s.batches.Stop()
+
+	// Flush spans recorded for the requests drained above.
+	tracing.Shutdown(ctx)
+
 	log.Debug("API server stopped")
 	return nil
 }
@@ -912,9 +1030,9 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 
 	prevSecretEmpty := true
 	if oldCfg != nil {
-		prevSecretEmpty = oldCfg.RemoteManagement.SecretKey == ""
+		prevSecretEmpty = !hasManagementKeys(oldCfg)
 	}
-	newSecretEmpty := cfg.RemoteManagement.SecretKey == ""
+	newSecretEmpty := !hasManagementKeys(cfg)
 	if s.envManagementSecret {
 		s.registerManagementRoutes()
 		if s.managementRoutesEnabled.CompareAndSwap(false, true) {
@@ -943,6 +1061,12 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 	}
 
 	s.applyAccessConfig(oldCfg, cfg)
+	ratelimit.Default().SetLimits(cfg.APIKeyLimits)
+	tracing.Configure(cfg.Tracing)
+	guardrail.Configure(cfg.Guardrails)
+	s.applyResponseCacheConfig(cfg)
+	s.applyResponsesStoreConfig(cfg)
+	s.applyBatchConfig(cfg)
 	s.cfg = cfg
 	s.wsAuthEnabled.Store(cfg.WebsocketAuth)
 	if oldCfg != nil && s.wsAuthChanged != nil && oldCfg.WebsocketAuth != cfg.WebsocketAuth {
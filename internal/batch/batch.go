@@ -0,0 +1,629 @@
+// Package batch emulates the OpenAI Files and Batch APIs. Uploaded JSONL files are kept on
+// disk and each batch runs its requests in the background through a shared worker pool,
+// writing OpenAI-style output and error files. Batch state is persisted so unfinished
+// batches resume after a restart.
+package batch
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultConcurrency bounds concurrent batch requests when no concurrency is configured.
+	DefaultConcurrency = 4
+	// DefaultMaxFileBytes bounds uploads when no size limit is configured.
+	DefaultMaxFileBytes = 200 << 20
+
+	// completionWindow is the only completion window batches accept.
+	completionWindow = "24h"
+)
+
+// Endpoints accepted for batches.
+const (
+	EndpointChatCompletions = "/v1/chat/completions"
+	EndpointResponses       = "/v1/responses"
+	EndpointEmbeddings      = "/v1/embeddings"
+)
+
+// File purposes.
+const (
+	PurposeBatch       = "batch"
+	PurposeBatchOutput = "batch_output"
+)
+
+// Batch statuses.
+const (
+	StatusValidating = "validating"
+	StatusFailed     = "failed"
+	StatusInProgress = "in_progress"
+	StatusFinalizing = "finalizing"
+	StatusCompleted  = "completed"
+	StatusExpired    = "expired"
+	StatusCancelling = "cancelling"
+	StatusCancelled  = "cancelled"
+)
+
+var (
+	// ErrNotFound is returned for unknown files and batches, including those owned by
+	// another client key.
+	ErrNotFound = errors.New("batch: not found")
+	// ErrFileTooLarge is returned when an upload exceeds the configured size limit.
+	ErrFileTooLarge = errors.New("batch: file exceeds the maximum size")
+	// ErrDisabled is returned while the batch API is disabled.
+	ErrDisabled = errors.New("batch: the batch API is disabled")
+)
+
+// RequestError reports an invalid request parameter.
+type RequestError struct {
+	Param   string
+	Message string
+}
+
+func (e *RequestError) Error() string { return e.Message }
+
+// File is an uploaded or generated file in the OpenAI files format.
+type File struct {
+	ID        string `json:"id"`
+	Object    string `json:"object"`
+	Bytes     int64  `json:"bytes"`
+	CreatedAt int64  `json:"created_at"`
+	Filename  string `json:"filename"`
+	Purpose   string `json:"purpose"`
+}
+
+// fileRecord is the persisted form of a File.
+type fileRecord struct {
+	File
+	Owner string `json:"owner,omitempty"`
+}
+
+// RequestCounts tallies the requests of a batch.
+type RequestCounts struct {
+	Total     int `json:"total"`
+	Completed int `json:"completed"`
+	Failed    int `json:"failed"`
+}
+
+// Error describes a problem with a batch input line.
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Line    int    `json:"line,omitempty"`
+}
+
+// Errors lists the validation errors of a failed batch.
+type Errors struct {
+	Object string  `json:"object"`
+	Data   []Error `json:"data"`
+}
+
+// Batch is a batch in the OpenAI batch format.
+type Batch struct {
+	ID               string            `json:"id"`
+	Object           string            `json:"object"`
+	Endpoint         string            `json:"endpoint"`
+	Errors           *Errors           `json:"errors"`
+	InputFileID      string            `json:"input_file_id"`
+	CompletionWindow string            `json:"completion_window"`
+	Status           string            `json:"status"`
+	OutputFileID     *string           `json:"output_file_id"`
+	ErrorFileID      *string           `json:"error_file_id"`
+	CreatedAt        int64             `json:"created_at"`
+	InProgressAt     *int64            `json:"in_progress_at"`
+	ExpiresAt        int64             `json:"expires_at"`
+	FinalizingAt     *int64            `json:"finalizing_at"`
+	CompletedAt      *int64            `json:"completed_at"`
+	FailedAt         *int64            `json:"failed_at"`
+	ExpiredAt        *int64            `json:"expired_at"`
+	CancellingAt     *int64            `json:"cancelling_at"`
+	CancelledAt      *int64            `json:"cancelled_at"`
+	RequestCounts    RequestCounts     `json:"request_counts"`
+	Metadata         map[string]string `json:"metadata"`
+}
+
+// batchRecord is the persisted form of a Batch. Only the owner hash is stored; the client
+// key a batch runs under is looked up among the configured keys when it starts.
+type batchRecord struct {
+	Batch
+	Owner string `json:"owner,omitempty"`
+}
+
+// CreateBatchRequest holds the parameters of POST /v1/batches.
+type CreateBatchRequest struct {
+	InputFileID      string            `json:"input_file_id"`
+	Endpoint         string            `json:"endpoint"`
+	CompletionWindow string            `json:"completion_window"`
+	Metadata         map[string]string `json:"metadata"`
+}
+
+// Result is the outcome of one batch request.
+type Result struct {
+	StatusCode int
+	Body       []byte
+	// RetryAfter is the wait the provider asked for before retrying, if any.
+	RetryAfter time.Duration
+}
+
+// ExecuteFunc runs one batch request for endpoint on behalf of apiKey.
+type ExecuteFunc func(ctx context.Context, apiKey, endpoint string, body []byte) Result
+
+// Manager stores batch files and runs batches.
+type Manager struct {
+	execute ExecuteFunc
+	now     func() time.Time
+
+	mu       sync.Mutex
+	cfg      config.BatchConfig
+	keys     map[string]string
+	dir      string
+	maxBytes int64
+	files    map[string]*fileRecord
+	batches  map[string]*batchRecord
+	running  map[string]context.CancelFunc
+	slots    chan struct{}
+	ctx      context.Context
+	stop     context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// NewManager constructs a disabled manager that runs batch requests with execute.
+func NewManager(execute ExecuteFunc) *Manager {
+	return &Manager{execute: execute, now: time.Now}
+}
+
+// Configure applies cfg. Enabling the manager loads persisted files and batches from disk
+// and resumes unfinished batches; baseDir anchors the default directory.
+func (m *Manager) Configure(cfg config.BatchConfig, baseDir string) error {
+	dir := strings.TrimSpace(cfg.Path)
+	if dir == "" {
+		dir = filepath.Join(baseDir, "batches")
+	}
+	m.mu.Lock()
+	unchanged := m.stop != nil && cfg.Enabled && m.cfg == cfg && m.dir == dir
+	m.mu.Unlock()
+	if unchanged {
+		return nil
+	}
+	m.Stop()
+	if !cfg.Enabled {
+		m.mu.Lock()
+		m.cfg = cfg
+		m.mu.Unlock()
+		return nil
+	}
+
+	for _, sub := range []string{"files", "batches"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o700); err != nil {
+			return fmt.Errorf("batch: create directory: %w", err)
+		}
+	}
+	files, err := loadRecords[fileRecord](filepath.Join(dir, "files"))
+	if err != nil {
+		return err
+	}
+	batches, err := loadRecords[batchRecord](filepath.Join(dir, "batches"))
+	if err != nil {
+		return err
+	}
+	concurrency := cfg.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	maxBytes := int64(cfg.MaxFileSizeMB) << 20
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxFileBytes
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cfg = cfg
+	m.dir = dir
+	m.maxBytes = maxBytes
+	m.files = files
+	m.batches = batches
+	m.running = make(map[string]context.CancelFunc)
+	m.slots = make(chan struct{}, concurrency)
+	m.ctx, m.stop = context.WithCancel(context.Background())
+	for id, rec := range batches {
+		switch rec.Status {
+		case StatusValidating, StatusInProgress, StatusFinalizing, StatusCancelling:
+			m.startLocked(id)
+		}
+	}
+	return nil
+}
+
+// SetAPIKeys replaces the client keys batches may run under. A batch whose key is no
+// longer configured fails when it starts or resumes.
+func (m *Manager) SetAPIKeys(keys []string) {
+	byOwner := make(map[string]string, len(keys))
+	for _, key := range keys {
+		if key != "" {
+			byOwner[ownerOf(key)] = key
+		}
+	}
+	m.mu.Lock()
+	m.keys = byOwner
+	m.mu.Unlock()
+}
+
+// keyForLocked returns the configured client key of owner. Batches created without a
+// key run without one.
+func (m *Manager) keyForLocked(owner string) (string, bool) {
+	if owner == "" {
+		return "", true
+	}
+	key, ok := m.keys[owner]
+	return key, ok
+}
+
+// Enabled reports whether the batch API is serving requests.
+func (m *Manager) Enabled() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stop != nil
+}
+
+// Stop halts running batches and waits for in-flight requests. Unfinished batches keep
+// their state and resume when the manager is enabled again.
+func (m *Manager) Stop() {
+	m.mu.Lock()
+	stop := m.stop
+	m.stop = nil
+	m.mu.Unlock()
+	if stop != nil {
+		stop()
+		m.wg.Wait()
+	}
+}
+
+// CreateFile stores an uploaded batch input file.
+func (m *Manager) CreateFile(apiKey, filename, purpose string, content io.Reader) (File, error) {
+	if purpose != PurposeBatch {
+		return File{}, &RequestError{Param: "purpose", Message: fmt.Sprintf("Invalid purpose %q: only %q is supported.", purpose, PurposeBatch)}
+	}
+	m.mu.Lock()
+	dir, maxBytes, enabled := m.dir, m.maxBytes, m.stop != nil
+	m.mu.Unlock()
+	if !enabled {
+		return File{}, ErrDisabled
+	}
+
+	id := newID("file-")
+	path := filepath.Join(dir, "files", id+".jsonl")
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return File{}, fmt.Errorf("batch: create file: %w", err)
+	}
+	written, errCopy := io.Copy(out, io.LimitReader(content, maxBytes+1))
+	errClose := out.Close()
+	if errCopy == nil && written > maxBytes {
+		errCopy = ErrFileTooLarge
+	}
+	if errCopy == nil {
+		errCopy = errClose
+	}
+	if errCopy != nil {
+		_ = os.Remove(path)
+		return File{}, errCopy
+	}
+
+	rec := &fileRecord{
+		File: File{
+			ID:        id,
+			Object:    "file",
+			Bytes:     written,
+			CreatedAt: m.now().Unix(),
+			Filename:  filepath.Base(filename),
+			Purpose:   purpose,
+		},
+		Owner: ownerOf(apiKey),
+	}
+	if err = m.saveFile(rec); err != nil {
+		_ = os.Remove(path)
+		return File{}, err
+	}
+	return rec.File, nil
+}
+
+// GetFile returns a file owned by apiKey.
+func (m *Manager) GetFile(apiKey, id string) (File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rec, err := m.fileLocked(apiKey, id)
+	if err != nil {
+		return File{}, err
+	}
+	return rec.File, nil
+}
+
+// ListFiles returns the files owned by apiKey, newest first, optionally filtered by purpose.
+func (m *Manager) ListFiles(apiKey, purpose string) []File {
+	owner := ownerOf(apiKey)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	files := make([]File, 0)
+	for _, rec := range m.files {
+		if rec.Owner == owner && (purpose == "" || rec.Purpose == purpose) {
+			files = append(files, rec.File)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].CreatedAt == files[j].CreatedAt {
+			return files[i].ID > files[j].ID
+		}
+		return files[i].CreatedAt > files[j].CreatedAt
+	})
+	return files
+}
+
+// OpenFile opens the content of a file owned by apiKey.
+func (m *Manager) OpenFile(apiKey, id string) (io.ReadCloser, File, error) {
+	m.mu.Lock()
+	rec, err := m.fileLocked(apiKey, id)
+	dir := m.dir
+	m.mu.Unlock()
+	if err != nil {
+		return nil, File{}, err
+	}
+	content, err := os.Open(filepath.Join(dir, "files", id+".jsonl"))
+	if err != nil {
+		return nil, File{}, fmt.Errorf("batch: open file: %w", err)
+	}
+	return content, rec.File, nil
+}
+
+// DeleteFile removes a file owned by apiKey.
+func (m *Manager) DeleteFile(apiKey, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, err := m.fileLocked(apiKey, id); err != nil {
+		return err
+	}
+	delete(m.files, id)
+	for _, path := range []string{filepath.Join(m.dir, "files", id+".json"), filepath.Join(m.dir, "files", id+".jsonl")} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("batch: delete file: %w", err)
+		}
+	}
+	return nil
+}
+
+// CreateBatch validates req and starts a batch over its input file.
+func (m *Manager) CreateBatch(apiKey string, req CreateBatchRequest) (Batch, error) {
+	switch req.Endpoint {
+	case EndpointChatCompletions, EndpointResponses, EndpointEmbeddings:
+	default:
+		return Batch{}, &RequestError{Param: "endpoint", Message: fmt.Sprintf("Unsupported endpoint %q: use %s, %s or %s.", req.Endpoint, EndpointChatCompletions, EndpointResponses, EndpointEmbeddings)}
+	}
+	if req.CompletionWindow != completionWindow {
+		return Batch{}, &RequestError{Param: "completion_window", Message: fmt.Sprintf("Invalid completion_window %q: only %q is supported.", req.CompletionWindow, completionWindow)}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stop == nil {
+		return Batch{}, ErrDisabled
+	}
+	input, err := m.fileLocked(apiKey, req.InputFileID)
+	if err != nil {
+		return Batch{}, &RequestError{Param: "input_file_id", Message: fmt.Sprintf("No file found with id %q.", req.InputFileID)}
+	}
+	if input.Purpose != PurposeBatch {
+		return Batch{}, &RequestError{Param: "input_file_id", Message: fmt.Sprintf("File %q does not have purpose %q.", req.InputFileID, PurposeBatch)}
+	}
+
+	now := m.now()
+	rec := &batchRecord{
+		Batch: Batch{
+			ID:               newID("batch_"),
+			Object:           "batch",
+			Endpoint:         req.Endpoint,
+			InputFileID:      req.InputFileID,
+			CompletionWindow: req.CompletionWindow,
+			Status:           StatusValidating,
+			CreatedAt:        now.Unix(),
+			ExpiresAt:        now.Add(24 * time.Hour).Unix(),
+			Metadata:         req.Metadata,
+		},
+		Owner: ownerOf(apiKey),
+	}
+	if err = m.saveBatchLocked(rec); err != nil {
+		return Batch{}, err
+	}
+	if apiKey != "" {
+		// The key just authenticated this request, so it is configured.
+		if m.keys == nil {
+			m.keys = make(map[string]string)
+		}
+		m.keys[rec.Owner] = apiKey
+	}
+	m.batches[rec.ID] = rec
+	m.startLocked(rec.ID)
+	return rec.Batch, nil
+}
+
+// GetBatch returns a batch owned by apiKey.
+func (m *Manager) GetBatch(apiKey, id string) (Batch, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rec, err := m.batchLocked(apiKey, id)
+	if err != nil {
+		return Batch{}, err
+	}
+	return rec.Batch, nil
+}
+
+// ListBatches returns up to limit batches owned by apiKey, newest first, starting after
+// the batch with ID after. It also reports whether more batches follow.
+func (m *Manager) ListBatches(apiKey, after string, limit int) ([]Batch, bool) {
+	owner := ownerOf(apiKey)
+	m.mu.Lock()
+	batches := make([]Batch, 0)
+	for _, rec := range m.batches {
+		if rec.Owner == owner {
+			batches = append(batches, rec.Batch)
+		}
+	}
+	m.mu.Unlock()
+	sort.Slice(batches, func(i, j int) bool {
+		if batches[i].CreatedAt == batches[j].CreatedAt {
+			return batches[i].ID > batches[j].ID
+		}
+		return batches[i].CreatedAt > batches[j].CreatedAt
+	})
+	if after != "" {
+		for i, b := range batches {
+			if b.ID == after {
+				batches = batches[i+1:]
+				break
+			}
+		}
+	}
+	if len(batches) > limit {
+		return batches[:limit], true
+	}
+	return batches, false
+}
+
+// CancelBatch stops a validating or running batch. Requests already finished are kept in
+// its output files.
+func (m *Manager) CancelBatch(apiKey, id string) (Batch, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rec, err := m.batchLocked(apiKey, id)
+	if err != nil {
+		return Batch{}, err
+	}
+	switch rec.Status {
+	case StatusValidating, StatusInProgress:
+	case StatusCancelling:
+		return rec.Batch, nil
+	default:
+		return Batch{}, &RequestError{Message: fmt.Sprintf("Cannot cancel a batch with status %q.", rec.Status)}
+	}
+	rec.Status = StatusCancelling
+	rec.CancellingAt = m.timestamp()
+	if err = m.saveBatchLocked(rec); err != nil {
+		return Batch{}, err
+	}
+	if cancel := m.running[id]; cancel != nil {
+		cancel()
+	}
+	return rec.Batch, nil
+}
+
+func (m *Manager) fileLocked(apiKey, id string) (*fileRecord, error) {
+	if m.stop == nil {
+		return nil, ErrDisabled
+	}
+	rec, ok := m.files[id]
+	if !ok || rec.Owner != ownerOf(apiKey) {
+		return nil, ErrNotFound
+	}
+	return rec, nil
+}
+
+func (m *Manager) batchLocked(apiKey, id string) (*batchRecord, error) {
+	if m.stop == nil {
+		return nil, ErrDisabled
+	}
+	rec, ok := m.batches[id]
+	if !ok || rec.Owner != ownerOf(apiKey) {
+		return nil, ErrNotFound
+	}
+	return rec, nil
+}
+
+func (m *Manager) saveFile(rec *fileRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := writeJSON(filepath.Join(m.dir, "files", rec.ID+".json"), rec); err != nil {
+		return err
+	}
+	m.files[rec.ID] = rec
+	return nil
+}
+
+func (m *Manager) saveBatchLocked(rec *batchRecord) error {
+	return writeJSON(filepath.Join(m.dir, "batches", rec.ID+".json"), rec)
+}
+
+func (m *Manager) timestamp() *int64 {
+	now := m.now().Unix()
+	return &now
+}
+
+// loadRecords reads every JSON record in dir, keyed by file name.
+func loadRecords[T any](dir string) (map[string]*T, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("batch: read directory: %w", err)
+	}
+	records := make(map[string]*T)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		data, errRead := os.ReadFile(filepath.Join(dir, name))
+		if errRead != nil {
+			return nil, fmt.Errorf("batch: read %s: %w", name, errRead)
+		}
+		record := new(T)
+		if errDecode := json.Unmarshal(data, record); errDecode != nil {
+			log.Warnf("batch: skipping unreadable record %s: %v", name, errDecode)
+			continue
+		}
+		records[strings.TrimSuffix(name, ".json")] = record
+	}
+	return records, nil
+}
+
+// writeJSON atomically replaces path with the JSON encoding of v.
+func writeJSON(path string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("batch: encode %s: %w", filepath.Base(path), err)
+	}
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("batch: write %s: %w", filepath.Base(path), err)
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("batch: commit %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+func newID(prefix string) string {
+	var buf [12]byte
+	_, _ = rand.Read(buf[:])
+	return prefix + hex.EncodeToString(buf[:])
+}
+
+// ownerOf derives the owner recorded for a client key.
+func ownerOf(apiKey string) string {
+	if apiKey == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte("batch\x00" + apiKey))
+	return hex.EncodeToString(sum[:])
+}
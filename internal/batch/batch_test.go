@@ -0,0 +1,198 @@
+package batch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+func waitForStatus(t *testing.T, m *Manager, apiKey, id string, statuses ...string) Batch {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		b, err := m.GetBatch(apiKey, id)
+		if err != nil {
+			t.Fatalf("GetBatch() error: %v", err)
+		}
+		for _, status := range statuses {
+			if b.Status == status {
+				return b
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("batch status = %s, want one of %v", b.Status, statuses)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func readFile(t *testing.T, m *Manager, apiKey, id string) string {
+	t.Helper()
+	content, _, err := m.OpenFile(apiKey, id)
+	if err != nil {
+		t.Fatalf("OpenFile(%s) error: %v", id, err)
+	}
+	defer func() { _ = content.Close() }()
+	data, _ := io.ReadAll(content)
+	return string(data)
+}
+
+func TestManager_RunsBatchAndRetriesCooldowns(t *testing.T) {
+	var throttled atomic.Bool
+	m := NewManager(func(_ context.Context, apiKey, endpoint string, body []byte) Result {
+		if apiKey != "key-a" || endpoint != EndpointChatCompletions {
+			return Result{StatusCode: http.StatusForbidden, Body: []byte(`{"error":{"message":"wrong key"}}`)}
+		}
+		switch gjson.GetBytes(body, "messages.0.content").String() {
+		case "cooldown":
+			if throttled.CompareAndSwap(false, true) {
+				return Result{StatusCode: http.StatusTooManyRequests, RetryAfter: time.Millisecond}
+			}
+		case "bad":
+			return Result{StatusCode: http.StatusBadRequest, Body: []byte(`{"error":{"message":"bad request"}}`)}
+		}
+		return Result{StatusCode: http.StatusOK, Body: []byte(`{"object":"chat.completion"}`)}
+	})
+	if err := m.Configure(config.BatchConfig{Enabled: true, Concurrency: 2}, t.TempDir()); err != nil {
+		t.Fatalf("Configure() error: %v", err)
+	}
+	t.Cleanup(m.Stop)
+
+	input := strings.Join([]string{
+		`{"custom_id":"a","method":"POST","url":"/v1/chat/completions","body":{"model":"m","messages":[{"role":"user","content":"hi"}]}}`,
+		`{"custom_id":"b","method":"POST","url":"/v1/chat/completions","body":{"model":"m","messages":[{"role":"user","content":"cooldown"}]}}`,
+		`{"custom_id":"c","method":"POST","url":"/v1/chat/completions","body":{"model":"m","messages":[{"role":"user","content":"bad"}]}}`,
+	}, "\n")
+	file, err := m.CreateFile("key-a", "input.jsonl", PurposeBatch, strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("CreateFile() error: %v", err)
+	}
+	if _, err = m.GetFile("key-b", file.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("GetFile() by another key = %v, want ErrNotFound", err)
+	}
+
+	created, err := m.CreateBatch("key-a", CreateBatchRequest{InputFileID: file.ID, Endpoint: EndpointChatCompletions, CompletionWindow: "24h"})
+	if err != nil {
+		t.Fatalf("CreateBatch() error: %v", err)
+	}
+	done := waitForStatus(t, m, "key-a", created.ID, StatusCompleted)
+	if done.RequestCounts != (RequestCounts{Total: 3, Completed: 2, Failed: 1}) {
+		t.Fatalf("request counts = %+v", done.RequestCounts)
+	}
+	if done.OutputFileID == nil || done.ErrorFileID == nil {
+		t.Fatalf("output/error files = %v/%v", done.OutputFileID, done.ErrorFileID)
+	}
+	output := readFile(t, m, "key-a", *done.OutputFileID)
+	if strings.Count(output, "\n") != 2 || !strings.Contains(output, `"custom_id":"b"`) || !strings.Contains(output, `"status_code":200`) {
+		t.Fatalf("output file = %s", output)
+	}
+	if errorsOut := readFile(t, m, "key-a", *done.ErrorFileID); !strings.Contains(errorsOut, `"custom_id":"c"`) || !strings.Contains(errorsOut, `"status_code":400`) {
+		t.Fatalf("error file = %s", errorsOut)
+	}
+}
+
+func TestManager_FailsInvalidInputAndResumesAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	release := make(chan struct{})
+	var calls atomic.Int32
+	execute := func(ctx context.Context, _, _ string, _ []byte) Result {
+		calls.Add(1)
+		select {
+		case <-release:
+			return Result{StatusCode: http.StatusOK, Body: []byte(`{}`)}
+		case <-ctx.Done():
+			return Result{StatusCode: http.StatusInternalServerError}
+		}
+	}
+	m := NewManager(execute)
+	if err := m.Configure(config.BatchConfig{Enabled: true, Concurrency: 1}, dir); err != nil {
+		t.Fatalf("Configure() error: %v", err)
+	}
+
+	invalid, _ := m.CreateFile("", "bad.jsonl", PurposeBatch, strings.NewReader("{\"custom_id\":\"x\",\"method\":\"GET\",\"url\":\"/v1/embeddings\",\"body\":{\"model\":\"m\"}}\nnot json\n"))
+	failed, _ := m.CreateBatch("", CreateBatchRequest{InputFileID: invalid.ID, Endpoint: EndpointEmbeddings, CompletionWindow: "24h"})
+	failed = waitForStatus(t, m, "", failed.ID, StatusFailed)
+	if failed.Errors == nil || len(failed.Errors.Data) != 2 || failed.Errors.Data[1].Line != 2 {
+		t.Fatalf("validation errors = %+v", failed.Errors)
+	}
+
+	var input bytes.Buffer
+	for _, id := range []string{"one", "two"} {
+		input.WriteString(`{"custom_id":"` + id + `","method":"POST","url":"/v1/embeddings","body":{"model":"m","input":"x"}}` + "\n")
+	}
+	file, _ := m.CreateFile("", "in.jsonl", PurposeBatch, &input)
+	running, err := m.CreateBatch("", CreateBatchRequest{InputFileID: file.ID, Endpoint: EndpointEmbeddings, CompletionWindow: "24h"})
+	if err != nil {
+		t.Fatalf("CreateBatch() error: %v", err)
+	}
+	waitForStatus(t, m, "", running.ID, StatusInProgress)
+	m.Stop()
+
+	close(release)
+	restarted := NewManager(execute)
+	if err = restarted.Configure(config.BatchConfig{Enabled: true}, dir); err != nil {
+		t.Fatalf("Configure() after restart error: %v", err)
+	}
+	t.Cleanup(restarted.Stop)
+	done := waitForStatus(t, restarted, "", running.ID, StatusCompleted)
+	if done.RequestCounts.Completed != 2 || done.RequestCounts.Failed != 0 {
+		t.Fatalf("resumed counts = %+v", done.RequestCounts)
+	}
+}
+
+func TestManager_PersistsOwnerOnlyAndFailsRevokedKeyOnResume(t *testing.T) {
+	dir := t.TempDir()
+	release := make(chan struct{})
+	execute := func(ctx context.Context, _, _ string, _ []byte) Result {
+		select {
+		case <-release:
+			return Result{StatusCode: http.StatusOK, Body: []byte(`{}`)}
+		case <-ctx.Done():
+			return Result{StatusCode: http.StatusInternalServerError}
+		}
+	}
+	m := NewManager(execute)
+	m.SetAPIKeys([]string{"secret-key"})
+	if err := m.Configure(config.BatchConfig{Enabled: true, Concurrency: 1}, dir); err != nil {
+		t.Fatalf("Configure() error: %v", err)
+	}
+	input := `{"custom_id":"one","method":"POST","url":"/v1/embeddings","body":{"model":"m","input":"x"}}`
+	file, _ := m.CreateFile("secret-key", "in.jsonl", PurposeBatch, strings.NewReader(input))
+	running, err := m.CreateBatch("secret-key", CreateBatchRequest{InputFileID: file.ID, Endpoint: EndpointEmbeddings, CompletionWindow: "24h"})
+	if err != nil {
+		t.Fatalf("CreateBatch() error: %v", err)
+	}
+	waitForStatus(t, m, "secret-key", running.ID, StatusInProgress)
+	m.Stop()
+	close(release)
+
+	persisted, err := os.ReadFile(filepath.Join(dir, "batches", "batches", running.ID+".json"))
+	if err != nil {
+		t.Fatalf("read batch record: %v", err)
+	}
+	if strings.Contains(string(persisted), "secret-key") {
+		t.Fatalf("batch record stores the client key: %s", persisted)
+	}
+
+	restarted := NewManager(execute)
+	restarted.SetAPIKeys([]string{"other-key"})
+	if err = restarted.Configure(config.BatchConfig{Enabled: true}, dir); err != nil {
+		t.Fatalf("Configure() after restart error: %v", err)
+	}
+	t.Cleanup(restarted.Stop)
+	failed := waitForStatus(t, restarted, "secret-key", running.ID, StatusFailed)
+	if failed.Errors == nil || len(failed.Errors.Data) != 1 || failed.Errors.Data[0].Code != "api_key_revoked" {
+		t.Fatalf("revoked key errors = %+v", failed.Errors)
+	}
+}
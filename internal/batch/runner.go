@@ -0,0 +1,474 @@
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// maxLineAttempts bounds how often a request is retried while credentials cool down.
+	maxLineAttempts = 6
+	// maxRetryWait caps the wait between attempts of one request.
+	maxRetryWait = time.Minute
+	// maxReportedErrors bounds the validation errors kept on a failed batch.
+	maxReportedErrors = 100
+)
+
+// line is one request of a batch input file.
+type line struct {
+	customID string
+	body     []byte
+}
+
+// resultLine is one entry of a batch output or error file.
+type resultLine struct {
+	ID       string         `json:"id"`
+	CustomID string         `json:"custom_id"`
+	Response *lineResponse  `json:"response"`
+	Error    *lineResultErr `json:"error"`
+}
+
+type lineResponse struct {
+	StatusCode int             `json:"status_code"`
+	RequestID  string          `json:"request_id"`
+	Body       json.RawMessage `json:"body"`
+}
+
+type lineResultErr struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// startLocked runs batch id in the background.
+func (m *Manager) startLocked(id string) {
+	ctx, cancel := context.WithCancel(m.ctx)
+	m.running[id] = cancel
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer func() {
+			m.mu.Lock()
+			delete(m.running, id)
+			m.mu.Unlock()
+			cancel()
+		}()
+		m.run(ctx, id)
+	}()
+}
+
+// run drives a batch from validation to a final status. When the manager stops, it returns
+// without touching the batch status so the batch resumes on the next start.
+func (m *Manager) run(ctx context.Context, id string) {
+	m.mu.Lock()
+	rec := m.batches[id]
+	dir := m.dir
+	snapshot := *rec
+	apiKey, keyFound := m.keyForLocked(rec.Owner)
+	m.mu.Unlock()
+
+	if !keyFound {
+		m.update(id, func(rec *batchRecord) {
+			switch rec.Status {
+			case StatusValidating, StatusInProgress, StatusFinalizing:
+				rec.Status = StatusFailed
+				rec.FailedAt = m.timestamp()
+				rec.Errors = &Errors{Object: "list", Data: []Error{{Code: "api_key_revoked", Message: "The API key that created this batch is no longer configured."}}}
+			case StatusCancelling:
+				rec.Status = StatusCancelled
+				rec.CancelledAt = m.timestamp()
+			}
+		})
+		return
+	}
+
+	if snapshot.Status == StatusValidating {
+		lines, validation := m.readInput(filepath.Join(dir, "files", snapshot.InputFileID+".jsonl"), snapshot.Endpoint)
+		m.update(id, func(rec *batchRecord) {
+			if rec.Status == StatusCancelling {
+				return
+			}
+			if len(validation) > 0 {
+				rec.Status = StatusFailed
+				rec.FailedAt = m.timestamp()
+				rec.Errors = &Errors{Object: "list", Data: validation}
+				return
+			}
+			rec.Status = StatusInProgress
+			rec.InProgressAt = m.timestamp()
+			rec.RequestCounts.Total = len(lines)
+		})
+	}
+
+	m.mu.Lock()
+	snapshot = *rec
+	m.mu.Unlock()
+	switch snapshot.Status {
+	case StatusInProgress, StatusCancelling, StatusFinalizing:
+	default:
+		return
+	}
+	lines, validation := m.readInput(filepath.Join(dir, "files", snapshot.InputFileID+".jsonl"), snapshot.Endpoint)
+	if len(validation) > 0 {
+		// The input file was removed or altered after validation.
+		lines = nil
+	}
+
+	results, err := openResults(dir, id)
+	if err != nil {
+		log.Errorf("batch %s: %v", id, err)
+		return
+	}
+	m.update(id, func(rec *batchRecord) {
+		rec.RequestCounts.Completed = results.completed
+		rec.RequestCounts.Failed = results.failed
+	})
+
+	expired := false
+	if snapshot.Status == StatusInProgress {
+		expired = m.dispatch(ctx, id, apiKey, snapshot, lines, results)
+	}
+	results.close()
+	if ctx.Err() != nil {
+		m.mu.Lock()
+		cancelling := rec.Status == StatusCancelling
+		m.mu.Unlock()
+		if !cancelling {
+			return
+		}
+	}
+	m.finish(id, results, expired)
+}
+
+// dispatch executes the requests of a batch that have no result yet. It reports whether
+// the batch expired, in which case the remaining requests were recorded as expired.
+func (m *Manager) dispatch(ctx context.Context, id, apiKey string, snapshot batchRecord, lines []line, results *results) bool {
+	expiresAt := time.Unix(snapshot.ExpiresAt, 0)
+	var inflight sync.WaitGroup
+	defer inflight.Wait()
+	for i, ln := range lines {
+		if results.has(ln.customID) {
+			continue
+		}
+		if !m.now().Before(expiresAt) {
+			inflight.Wait()
+			for _, rest := range lines[i:] {
+				if !results.has(rest.customID) {
+					m.record(id, results, rest, Result{}, &lineResultErr{Code: "batch_expired", Message: "This request could not be executed before the completion window expired."})
+				}
+			}
+			return true
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case m.slots <- struct{}{}:
+		}
+		inflight.Add(1)
+		go func(ln line) {
+			defer inflight.Done()
+			defer func() { <-m.slots }()
+			res := m.executeLine(ctx, apiKey, snapshot.Endpoint, ln.body)
+			if ctx.Err() != nil {
+				// Interrupted requests run again when the batch resumes.
+				return
+			}
+			m.record(id, results, ln, res, nil)
+		}(ln)
+	}
+	return false
+}
+
+// executeLine runs one request, waiting and retrying while every credential is cooling down.
+func (m *Manager) executeLine(ctx context.Context, apiKey, endpoint string, body []byte) Result {
+	wait := 2 * time.Second
+	for attempt := 1; ; attempt++ {
+		res := m.execute(ctx, apiKey, endpoint, body)
+		if attempt >= maxLineAttempts || (res.StatusCode != http.StatusTooManyRequests && res.StatusCode != http.StatusServiceUnavailable) {
+			return res
+		}
+		delay := wait
+		if res.RetryAfter > 0 {
+			delay = res.RetryAfter
+		}
+		if delay > maxRetryWait {
+			delay = maxRetryWait
+		}
+		wait *= 2
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return res
+		case <-timer.C:
+		}
+	}
+}
+
+// record appends the outcome of a request to the output or error file and updates counts.
+func (m *Manager) record(id string, results *results, ln line, res Result, lineErr *lineResultErr) {
+	entry := resultLine{ID: newID("batch_req_"), CustomID: ln.customID, Error: lineErr}
+	if lineErr == nil {
+		body := json.RawMessage(res.Body)
+		if !json.Valid(body) {
+			body, _ = json.Marshal(string(res.Body))
+		}
+		entry.Response = &lineResponse{StatusCode: res.StatusCode, RequestID: newID("req_"), Body: body}
+	}
+	succeeded := lineErr == nil && res.StatusCode >= 200 && res.StatusCode < 300
+	if err := results.write(entry, succeeded); err != nil {
+		log.Errorf("batch %s: record %s: %v", id, ln.customID, err)
+		return
+	}
+	m.update(id, func(rec *batchRecord) {
+		if succeeded {
+			rec.RequestCounts.Completed++
+		} else {
+			rec.RequestCounts.Failed++
+		}
+	})
+}
+
+// finish publishes the result files and moves the batch to its final status.
+func (m *Manager) finish(id string, results *results, expired bool) {
+	m.update(id, func(rec *batchRecord) {
+		if rec.Status == StatusInProgress {
+			rec.Status = StatusFinalizing
+			rec.FinalizingAt = m.timestamp()
+		}
+	})
+	m.mu.Lock()
+	owner := m.batches[id].Owner
+	m.mu.Unlock()
+
+	outputID, errOutput := m.publish(owner, id, results.outputPath, "output")
+	errorID, errErrors := m.publish(owner, id, results.errorPath, "errors")
+	if err := errors.Join(errOutput, errErrors); err != nil {
+		log.Errorf("batch %s: publish results: %v", id, err)
+	}
+	m.update(id, func(rec *batchRecord) {
+		rec.OutputFileID = outputID
+		rec.ErrorFileID = errorID
+		switch {
+		case rec.Status == StatusCancelling:
+			rec.Status = StatusCancelled
+			rec.CancelledAt = m.timestamp()
+		case expired:
+			rec.Status = StatusExpired
+			rec.ExpiredAt = m.timestamp()
+		default:
+			rec.Status = StatusCompleted
+			rec.CompletedAt = m.timestamp()
+		}
+	})
+}
+
+// publish turns a non-empty result file into a batch_output file owned by owner.
+func (m *Manager) publish(owner, batchID, path, kind string) (*string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil
+	}
+	if info.Size() == 0 {
+		_ = os.Remove(path)
+		return nil, nil
+	}
+	m.mu.Lock()
+	dir := m.dir
+	m.mu.Unlock()
+	fileID := newID("file-")
+	if err = os.Rename(path, filepath.Join(dir, "files", fileID+".jsonl")); err != nil {
+		return nil, err
+	}
+	rec := &fileRecord{
+		File: File{
+			ID:        fileID,
+			Object:    "file",
+			Bytes:     info.Size(),
+			CreatedAt: m.now().Unix(),
+			Filename:  fmt.Sprintf("%s_%s.jsonl", batchID, kind),
+			Purpose:   PurposeBatchOutput,
+		},
+		Owner: owner,
+	}
+	if err = m.saveFile(rec); err != nil {
+		return nil, err
+	}
+	return &fileID, nil
+}
+
+// update applies fn to a batch and persists it.
+func (m *Manager) update(id string, fn func(rec *batchRecord)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rec := m.batches[id]
+	fn(rec)
+	if err := m.saveBatchLocked(rec); err != nil {
+		log.Errorf("batch %s: %v", id, err)
+	}
+}
+
+// readInput parses a batch input file, returning its requests or the problems found.
+func (m *Manager) readInput(path, endpoint string) ([]line, []Error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, []Error{{Code: "file_not_found", Message: "The input file could not be read."}}
+	}
+	defer func() { _ = file.Close() }()
+
+	var lines []line
+	var problems []Error
+	report := func(number int, code, format string, args ...any) {
+		if len(problems) < maxReportedErrors {
+			problems = append(problems, Error{Code: code, Message: fmt.Sprintf(format, args...), Line: number})
+		}
+	}
+	seen := make(map[string]struct{})
+	reader := bufio.NewReader(file)
+	for number := 1; ; number++ {
+		raw, errRead := reader.ReadBytes('\n')
+		if len(raw) > 0 {
+			m.parseLine(raw, number, endpoint, seen, &lines, report)
+		}
+		if errRead == io.EOF {
+			break
+		}
+		if errRead != nil {
+			return nil, []Error{{Code: "file_not_found", Message: "The input file could not be read."}}
+		}
+	}
+	if len(lines) == 0 && len(problems) == 0 {
+		report(0, "empty_file", "The input file contains no requests.")
+	}
+	return lines, problems
+}
+
+func (m *Manager) parseLine(raw []byte, number int, endpoint string, seen map[string]struct{}, lines *[]line, report func(int, string, string, ...any)) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return
+	}
+	entry := gjson.ParseBytes(raw)
+	if !gjson.ValidBytes(raw) || !entry.IsObject() {
+		report(number, "invalid_json_line", "Line %d is not a JSON object.", number)
+		return
+	}
+	customID := entry.Get("custom_id").String()
+	switch {
+	case customID == "":
+		report(number, "missing_required_parameter", "Line %d is missing custom_id.", number)
+		return
+	case entry.Get("method").String() != http.MethodPost:
+		report(number, "invalid_method", "Line %d must use method POST.", number)
+		return
+	case entry.Get("url").String() != endpoint:
+		report(number, "mismatched_endpoint", "Line %d targets %q but the batch endpoint is %q.", number, entry.Get("url").String(), endpoint)
+		return
+	case !entry.Get("body").IsObject() || entry.Get("body.model").String() == "":
+		report(number, "missing_required_parameter", "Line %d must have a body with a model.", number)
+		return
+	}
+	if _, dup := seen[customID]; dup {
+		report(number, "duplicate_custom_id", "Line %d repeats custom_id %q.", number, customID)
+		return
+	}
+	seen[customID] = struct{}{}
+	*lines = append(*lines, line{customID: customID, body: []byte(entry.Get("body").Raw)})
+}
+
+// results appends to the output and error files of a running batch.
+type results struct {
+	mu         sync.Mutex
+	outputPath string
+	errorPath  string
+	output     *os.File
+	errors     *os.File
+	done       map[string]struct{}
+	completed  int
+	failed     int
+}
+
+// openResults opens the result files of a batch, keeping the intact entries written before
+// a restart so their requests are not executed again.
+func openResults(dir, id string) (*results, error) {
+	r := &results{
+		outputPath: filepath.Join(dir, "batches", id+".output.jsonl"),
+		errorPath:  filepath.Join(dir, "batches", id+".errors.jsonl"),
+		done:       make(map[string]struct{}),
+	}
+	var err error
+	if r.output, r.completed, err = r.recover(r.outputPath); err != nil {
+		return nil, err
+	}
+	if r.errors, r.failed, err = r.recover(r.errorPath); err != nil {
+		_ = r.output.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *results) recover(path string) (*os.File, int, error) {
+	var kept []byte
+	count := 0
+	if data, err := os.ReadFile(path); err == nil {
+		for _, raw := range bytes.Split(data, []byte("\n")) {
+			customID := gjson.GetBytes(raw, "custom_id").String()
+			if !gjson.ValidBytes(raw) || customID == "" {
+				continue
+			}
+			r.done[customID] = struct{}{}
+			kept = append(append(kept, raw...), '\n')
+			count++
+		}
+	}
+	if err := os.WriteFile(path, kept, 0o600); err != nil {
+		return nil, 0, fmt.Errorf("reset results: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, 0, fmt.Errorf("open results: %w", err)
+	}
+	return file, count, nil
+}
+
+func (r *results) has(customID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.done[customID]
+	return ok
+}
+
+func (r *results) write(entry resultLine, succeeded bool) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	target := r.errors
+	if succeeded {
+		target = r.output
+	}
+	if _, err = target.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	r.done[entry.CustomID] = struct{}{}
+	return nil
+}
+
+func (r *results) close() {
+	_ = r.output.Close()
+	_ = r.errors.Close()
+}
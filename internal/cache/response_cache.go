@@ -0,0 +1,264 @@
+package cache
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// DefaultResponseCacheTTL is how long responses are served when no TTL is configured.
+	DefaultResponseCacheTTL = time.Hour
+	// DefaultResponseCacheMaxEntries bounds the cache when no entry limit is configured.
+	DefaultResponseCacheMaxEntries = 1000
+	// DefaultResponseCacheMaxBytes bounds the cache when no size limit is configured.
+	DefaultResponseCacheMaxBytes = 100 << 20
+)
+
+// ResponseStore stores cached response bodies by key. Implementations enforce their own
+// expiry and size limits and must be safe for concurrent use.
+type ResponseStore interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte)
+	Delete(key string)
+}
+
+// ResponseCache serves cached responses for repeated non-streaming requests.
+type ResponseCache struct {
+	mu      sync.RWMutex
+	cfg     config.ResponseCacheConfig
+	baseDir string
+	store   ResponseStore
+}
+
+var defaultResponseCache = &ResponseCache{}
+
+// DefaultResponseCache returns the shared response cache configured from the server config.
+func DefaultResponseCache() *ResponseCache { return defaultResponseCache }
+
+// Configure applies cfg, replacing the store when the backend or its limits change.
+// baseDir anchors the default disk cache directory.
+func (c *ResponseCache) Configure(cfg config.ResponseCacheConfig, baseDir string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !cfg.Enabled {
+		c.cfg = cfg
+		c.store = nil
+		return nil
+	}
+	if c.store != nil && c.cfg == cfg && c.baseDir == baseDir {
+		return nil
+	}
+
+	ttl := time.Duration(cfg.TTLSeconds) * time.Second
+	if ttl <= 0 {
+		ttl = DefaultResponseCacheTTL
+	}
+	maxEntries := cfg.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = DefaultResponseCacheMaxEntries
+	}
+	maxBytes := int64(cfg.MaxSizeMB) << 20
+	if maxBytes <= 0 {
+		maxBytes = DefaultResponseCacheMaxBytes
+	}
+
+	var store ResponseStore
+	switch strings.ToLower(strings.TrimSpace(cfg.Backend)) {
+	case "", "memory":
+		store = NewMemoryResponseStore(ttl, maxEntries, maxBytes)
+	case "disk":
+		dir := strings.TrimSpace(cfg.Path)
+		if dir == "" {
+			dir = filepath.Join(baseDir, "cache", "responses")
+		}
+		diskStore, err := NewDiskResponseStore(dir, ttl, maxEntries, maxBytes)
+		if err != nil {
+			c.store = nil
+			return err
+		}
+		store = diskStore
+	default:
+		c.store = nil
+		return fmt.Errorf("response cache: unsupported backend %q", cfg.Backend)
+	}
+	c.cfg = cfg
+	c.baseDir = baseDir
+	c.store = store
+	return nil
+}
+
+// Cacheable reports whether a request with payload may be served from the cache.
+func (c *ResponseCache) Cacheable(payload []byte) bool {
+	if c == nil {
+		return false
+	}
+	c.mu.RLock()
+	enabled := c.store != nil
+	includeAll := c.cfg.IncludeNondeterministic
+	c.mu.RUnlock()
+	if !enabled {
+		return false
+	}
+	return includeAll || IsDeterministicRequest(payload)
+}
+
+// Get returns the cached response for key.
+func (c *ResponseCache) Get(key string) ([]byte, bool) {
+	store := c.currentStore()
+	if store == nil {
+		return nil, false
+	}
+	return store.Get(key)
+}
+
+// Set stores value under key.
+func (c *ResponseCache) Set(key string, value []byte) {
+	if store := c.currentStore(); store != nil && len(value) > 0 {
+		store.Set(key, value)
+	}
+}
+
+func (c *ResponseCache) currentStore() ResponseStore {
+	if c == nil {
+		return nil
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.store
+}
+
+// temperaturePaths lists where OpenAI, Claude, Gemini and Gemini CLI requests carry temperature.
+var temperaturePaths = []string{"temperature", "generationConfig.temperature", "request.generationConfig.temperature"}
+
+// IsDeterministicRequest reports whether the payload explicitly requests temperature 0.
+func IsDeterministicRequest(payload []byte) bool {
+	for _, path := range temperaturePaths {
+		if value := gjson.GetBytes(payload, path); value.Exists() {
+			return value.Type == gjson.Number && value.Float() == 0
+		}
+	}
+	return false
+}
+
+// ResponseCacheKey derives the cache key from the request identity and a canonical hash of
+// the payload, so that key order and whitespace differences map to the same entry.
+// Handlers pass the client's payload rather than the translated one: translation depends on
+// the credential picked later, and hashing before routing lets a hit skip selection entirely.
+// Cached bodies are already in the client's format, so handlerType keeps formats apart.
+func ResponseCacheKey(handlerType, model, alt, apiKey string, payload []byte) string {
+	hasher := sha256.New()
+	for _, part := range []string{handlerType, model, alt, apiKey} {
+		hasher.Write([]byte(part))
+		hasher.Write([]byte{0})
+	}
+	hasher.Write(canonicalJSON(payload))
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+// canonicalJSON re-encodes payload with sorted object keys. Invalid JSON is returned as-is.
+func canonicalJSON(payload []byte) []byte {
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return payload
+	}
+	canonical, err := json.Marshal(value)
+	if err != nil {
+		return payload
+	}
+	return canonical
+}
+
+// memoryEntry is a cached response held by MemoryResponseStore.
+type memoryEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// MemoryResponseStore is an in-process LRU store bounded by entry count and total size.
+type MemoryResponseStore struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	maxBytes   int64
+	size       int64
+	order      *list.List
+	entries    map[string]*list.Element
+	now        func() time.Time
+}
+
+// NewMemoryResponseStore constructs an in-memory store.
+func NewMemoryResponseStore(ttl time.Duration, maxEntries int, maxBytes int64) *MemoryResponseStore {
+	return &MemoryResponseStore{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+		now:        time.Now,
+	}
+}
+
+// Get implements ResponseStore.
+func (s *MemoryResponseStore) Get(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	elem, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*memoryEntry)
+	if !s.now().Before(entry.expiresAt) {
+		s.removeLocked(elem)
+		return nil, false
+	}
+	s.order.MoveToFront(elem)
+	return append([]byte(nil), entry.value...), true
+}
+
+// Set implements ResponseStore.
+func (s *MemoryResponseStore) Set(key string, value []byte) {
+	if int64(len(value)) > s.maxBytes {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if elem, ok := s.entries[key]; ok {
+		s.removeLocked(elem)
+	}
+	entry := &memoryEntry{key: key, value: append([]byte(nil), value...), expiresAt: s.now().Add(s.ttl)}
+	s.entries[key] = s.order.PushFront(entry)
+	s.size += int64(len(entry.value))
+	for s.order.Len() > s.maxEntries || s.size > s.maxBytes {
+		s.removeLocked(s.order.Back())
+	}
+}
+
+// Delete implements ResponseStore.
+func (s *MemoryResponseStore) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if elem, ok := s.entries[key]; ok {
+		s.removeLocked(elem)
+	}
+}
+
+func (s *MemoryResponseStore) removeLocked(elem *list.Element) {
+	entry := s.order.Remove(elem).(*memoryEntry)
+	delete(s.entries, entry.key)
+	s.size -= int64(len(entry.value))
+}
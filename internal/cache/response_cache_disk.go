@@ -0,0 +1,168 @@
+package cache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const diskEntrySuffix = ".resp"
+
+// diskEntry tracks a cached file so limits can be enforced without rescanning the directory.
+type diskEntry struct {
+	size      int64
+	writtenAt time.Time
+}
+
+// DiskResponseStore keeps one file per cached response. Entries expire TTL after they were
+// written and the oldest entries are evicted first when a limit is exceeded.
+type DiskResponseStore struct {
+	mu         sync.Mutex
+	dir        string
+	ttl        time.Duration
+	maxEntries int
+	maxBytes   int64
+	size       int64
+	entries    map[string]diskEntry
+	now        func() time.Time
+}
+
+// NewDiskResponseStore opens or creates a disk store rooted at dir, indexing existing entries.
+func NewDiskResponseStore(dir string, ttl time.Duration, maxEntries int, maxBytes int64) (*DiskResponseStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("response cache: create directory: %w", err)
+	}
+	s := &DiskResponseStore{
+		dir:        dir,
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		entries:    make(map[string]diskEntry),
+		now:        time.Now,
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("response cache: read directory: %w", err)
+	}
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !strings.HasSuffix(name, diskEntrySuffix) {
+			continue
+		}
+		info, errInfo := file.Info()
+		if errInfo != nil {
+			continue
+		}
+		key := strings.TrimSuffix(name, diskEntrySuffix)
+		s.entries[key] = diskEntry{size: info.Size(), writtenAt: info.ModTime()}
+		s.size += info.Size()
+	}
+	s.mu.Lock()
+	s.evictLocked()
+	s.mu.Unlock()
+	return s, nil
+}
+
+// Get implements ResponseStore.
+func (s *DiskResponseStore) Get(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !s.now().Before(entry.writtenAt.Add(s.ttl)) {
+		s.removeLocked(key)
+		return nil, false
+	}
+	data, err := os.ReadFile(s.path(key))
+	if err != nil {
+		log.Debugf("response cache: read entry: %v", err)
+		s.removeLocked(key)
+		return nil, false
+	}
+	return data, true
+}
+
+// Set implements ResponseStore.
+func (s *DiskResponseStore) Set(key string, value []byte) {
+	size := int64(len(value))
+	if size > s.maxBytes {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tmp := s.path(key) + ".tmp"
+	if err := os.WriteFile(tmp, value, 0o600); err != nil {
+		log.Debugf("response cache: write entry: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, s.path(key)); err != nil {
+		_ = os.Remove(tmp)
+		log.Debugf("response cache: commit entry: %v", err)
+		return
+	}
+	if previous, ok := s.entries[key]; ok {
+		s.size -= previous.size
+	}
+	s.entries[key] = diskEntry{size: size, writtenAt: s.now()}
+	s.size += size
+	s.evictLocked()
+}
+
+// Delete implements ResponseStore.
+func (s *DiskResponseStore) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.removeLocked(key)
+}
+
+// evictLocked drops expired entries, then the oldest ones until both limits hold.
+func (s *DiskResponseStore) evictLocked() {
+	now := s.now()
+	for key, entry := range s.entries {
+		if !now.Before(entry.writtenAt.Add(s.ttl)) {
+			s.removeLocked(key)
+		}
+	}
+	if len(s.entries) <= s.maxEntries && s.size <= s.maxBytes {
+		return
+	}
+	keys := make([]string, 0, len(s.entries))
+	for key := range s.entries {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return s.entries[keys[i]].writtenAt.Before(s.entries[keys[j]].writtenAt)
+	})
+	for _, key := range keys {
+		if len(s.entries) <= s.maxEntries && s.size <= s.maxBytes {
+			return
+		}
+		s.removeLocked(key)
+	}
+}
+
+func (s *DiskResponseStore) removeLocked(key string) {
+	entry, ok := s.entries[key]
+	if !ok {
+		return
+	}
+	delete(s.entries, key)
+	s.size -= entry.size
+	if err := os.Remove(s.path(key)); err != nil && !os.IsNotExist(err) {
+		log.Debugf("response cache: remove entry: %v", err)
+	}
+}
+
+// path returns the file for key. Keys are hex digests, so they are safe file names.
+func (s *DiskResponseStore) path(key string) string {
+	return filepath.Join(s.dir, key+diskEntrySuffix)
+}
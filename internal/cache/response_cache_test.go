@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResponseCacheKey_IgnoresKeyOrderAndWhitespace(t *testing.T) {
+	a := ResponseCacheKey("openai", "gpt-4o", "", "k", []byte(`{"model":"gpt-4o","temperature":0,"messages":[{"role":"user","content":"hi"}]}`))
+	b := ResponseCacheKey("openai", "gpt-4o", "", "k", []byte(`{ "messages": [{"content":"hi","role":"user"}], "temperature": 0, "model": "gpt-4o" }`))
+	if a != b {
+		t.Fatalf("keys differ for equivalent payloads: %s != %s", a, b)
+	}
+	if c := ResponseCacheKey("claude", "gpt-4o", "", "k", []byte(`{"model":"gpt-4o","temperature":0}`)); c == a {
+		t.Fatal("keys match across handler types")
+	}
+}
+
+func TestIsDeterministicRequest(t *testing.T) {
+	cases := map[string]bool{
+		`{"temperature":0}`:                                  true,
+		`{"temperature":0.0}`:                                true,
+		`{"temperature":0.7}`:                                false,
+		`{"messages":[]}`:                                    false,
+		`{"generationConfig":{"temperature":0}}`:             true,
+		`{"request":{"generationConfig":{"temperature":0}}}`: true,
+		`{"request":{"generationConfig":{"temperature":1}}}`: false,
+		`{"temperature":"0"}`:                                false,
+	}
+	for payload, want := range cases {
+		if got := IsDeterministicRequest([]byte(payload)); got != want {
+			t.Errorf("IsDeterministicRequest(%s) = %t, want %t", payload, got, want)
+		}
+	}
+}
+
+func TestMemoryResponseStore_EvictsAndExpires(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryResponseStore(time.Minute, 2, 1<<20)
+	store.now = func() time.Time { return now }
+
+	store.Set("a", []byte("1"))
+	store.Set("b", []byte("2"))
+	if _, ok := store.Get("a"); !ok {
+		t.Fatal("Get(a) miss, want hit")
+	}
+	store.Set("c", []byte("3"))
+	if _, ok := store.Get("b"); ok {
+		t.Fatal("Get(b) hit, want eviction of least recently used entry")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := store.Get("a"); ok {
+		t.Fatal("Get(a) hit after TTL, want miss")
+	}
+}
+
+func TestDiskResponseStore_PersistsAcrossReopen(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewDiskResponseStore(dir, time.Hour, 10, 1<<20)
+	if err != nil {
+		t.Fatalf("NewDiskResponseStore() error = %v", err)
+	}
+	store.Set("key", []byte(`{"ok":true}`))
+
+	reopened, err := NewDiskResponseStore(dir, time.Hour, 10, 1<<20)
+	if err != nil {
+		t.Fatalf("reopen error = %v", err)
+	}
+	got, ok := reopened.Get("key")
+	if !ok || string(got) != `{"ok":true}` {
+		t.Fatalf("Get() = %q, %t; want cached payload", got, ok)
+	}
+
+	bounded, err := NewDiskResponseStore(dir, time.Hour, 10, 4)
+	if err != nil {
+		t.Fatalf("reopen with size limit error = %v", err)
+	}
+	if _, ok = bounded.Get("key"); ok {
+		t.Fatal("Get() hit after size limit shrank, want eviction")
+	}
+}
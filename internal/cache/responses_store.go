@@ -0,0 +1,175 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+const (
+	// DefaultResponsesStoreTTL is how long responses are kept when no TTL is configured.
+	DefaultResponsesStoreTTL = 30 * 24 * time.Hour
+	// DefaultResponsesStoreMaxEntries bounds the store when no entry limit is configured.
+	DefaultResponsesStoreMaxEntries = 10000
+	// DefaultResponsesStoreMaxBytes bounds the store when no size limit is configured.
+	DefaultResponsesStoreMaxBytes = 500 << 20
+)
+
+// StoredResponse is a Responses API result kept for previous_response_id chaining.
+type StoredResponse struct {
+	// ID is the response ID returned to the client.
+	ID string `json:"id"`
+	// Owner identifies the client key that created the response.
+	Owner string `json:"owner,omitempty"`
+	// Input holds every input item of the request, including those inherited through
+	// previous_response_id.
+	Input []json.RawMessage `json:"input"`
+	// Output holds the output items of the response.
+	Output []json.RawMessage `json:"output"`
+	// Response is the complete response object.
+	Response json.RawMessage `json:"response"`
+}
+
+// ResponsesStore keeps Responses API results by response ID. Entries are scoped to the
+// client key that created them.
+type ResponsesStore struct {
+	mu      sync.RWMutex
+	cfg     config.ResponsesStoreConfig
+	baseDir string
+	store   ResponseStore
+}
+
+var defaultResponsesStore = &ResponsesStore{}
+
+// DefaultResponsesStore returns the shared responses store configured from the server config.
+func DefaultResponsesStore() *ResponsesStore { return defaultResponsesStore }
+
+// Configure applies cfg, replacing the backing store when the backend or its limits change.
+// baseDir anchors the default disk store directory.
+func (s *ResponsesStore) Configure(cfg config.ResponsesStoreConfig, baseDir string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !cfg.Enabled {
+		s.cfg = cfg
+		s.store = nil
+		return nil
+	}
+	if s.store != nil && s.cfg == cfg && s.baseDir == baseDir {
+		return nil
+	}
+
+	ttl := time.Duration(cfg.TTLSeconds) * time.Second
+	if ttl <= 0 {
+		ttl = DefaultResponsesStoreTTL
+	}
+	maxEntries := cfg.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = DefaultResponsesStoreMaxEntries
+	}
+	maxBytes := int64(cfg.MaxSizeMB) << 20
+	if maxBytes <= 0 {
+		maxBytes = DefaultResponsesStoreMaxBytes
+	}
+
+	var store ResponseStore
+	switch strings.ToLower(strings.TrimSpace(cfg.Backend)) {
+	case "", "memory":
+		store = NewMemoryResponseStore(ttl, maxEntries, maxBytes)
+	case "disk":
+		dir := strings.TrimSpace(cfg.Path)
+		if dir == "" {
+			dir = filepath.Join(baseDir, "responses")
+		}
+		diskStore, err := NewDiskResponseStore(dir, ttl, maxEntries, maxBytes)
+		if err != nil {
+			s.store = nil
+			return err
+		}
+		store = diskStore
+	default:
+		s.store = nil
+		return fmt.Errorf("responses store: unsupported backend %q", cfg.Backend)
+	}
+	s.cfg = cfg
+	s.baseDir = baseDir
+	s.store = store
+	return nil
+}
+
+// Enabled reports whether responses are being stored.
+func (s *ResponsesStore) Enabled() bool { return s.currentStore() != nil }
+
+// Save stores resp under its ID.
+func (s *ResponsesStore) Save(resp StoredResponse) error {
+	store := s.currentStore()
+	if store == nil || resp.ID == "" {
+		return nil
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("responses store: encode response: %w", err)
+	}
+	store.Set(responsesStoreKey(resp.ID), data)
+	return nil
+}
+
+// Load returns the response stored under id when it belongs to owner.
+func (s *ResponsesStore) Load(id, owner string) (*StoredResponse, bool) {
+	store := s.currentStore()
+	if store == nil || id == "" {
+		return nil, false
+	}
+	data, ok := store.Get(responsesStoreKey(id))
+	if !ok {
+		return nil, false
+	}
+	var resp StoredResponse
+	if err := json.Unmarshal(data, &resp); err != nil || resp.ID != id || resp.Owner != owner {
+		return nil, false
+	}
+	return &resp, true
+}
+
+// Delete removes the response stored under id when it belongs to owner.
+func (s *ResponsesStore) Delete(id, owner string) bool {
+	if _, ok := s.Load(id, owner); !ok {
+		return false
+	}
+	if store := s.currentStore(); store != nil {
+		store.Delete(responsesStoreKey(id))
+	}
+	return true
+}
+
+func (s *ResponsesStore) currentStore() ResponseStore {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.store
+}
+
+// ResponsesStoreOwner derives the owner recorded for a client key, so that keys are never
+// written to the store.
+func ResponsesStoreOwner(apiKey string) string {
+	if apiKey == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte("responses-store\x00" + apiKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// responsesStoreKey hashes a response ID into a key that is safe as a file name.
+func responsesStoreKey(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,41 @@
+// Package cmd contains CLI helpers. This file implements recording translator
+// conformance fixtures from the logs written by the request logger.
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/conformance"
+	log "github.com/sirupsen/logrus"
+)
+
+// DoRecordFixtures converts a request log, or every log in a directory, into
+// conformance fixtures under outDir. Logs that do not describe a translated
+// exchange are skipped with a warning.
+func DoRecordFixtures(logPath, outDir string) {
+	logPath = strings.TrimSpace(logPath)
+	if logPath == "" {
+		log.Errorf("record-fixtures: missing request log path")
+		return
+	}
+	if strings.TrimSpace(outDir) == "" {
+		outDir = "fixtures"
+	}
+	written, errRecord := conformance.Record(logPath, outDir)
+	for _, path := range written {
+		fmt.Printf("Fixture recorded: %s\n", path)
+	}
+	if errRecord == nil {
+		return
+	}
+	var joined interface{ Unwrap() []error }
+	if !errors.As(errRecord, &joined) {
+		log.Errorf("record-fixtures: %v", errRecord)
+		return
+	}
+	for _, errSkip := range joined.Unwrap() {
+		log.Warnf("record-fixtures: skipped %v", errSkip)
+	}
+}
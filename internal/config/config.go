@@ -55,6 +55,27 @@ type Config struct {
 	// UsageStatisticsEnabled toggles in-memory usage aggregation; when false, usage data is discarded.
 	UsageStatisticsEnabled bool `yaml:"usage-statistics-enabled" json:"usage-statistics-enabled"`
 
+	// UsagePersistence configures durable storage for usage statistics.
+	UsagePersistence UsagePersistenceConfig `yaml:"usage-persistence" json:"usage-persistence"`
+
+	// Metrics configures the Prometheus metrics endpoint.
+	Metrics MetricsConfig `yaml:"metrics" json:"metrics"`
+
+	// ResponseCache configures caching of deterministic non-streaming responses.
+	ResponseCache ResponseCacheConfig `yaml:"response-cache" json:"response-cache"`
+
+	// ResponsesStore configures persistence of Responses API results for previous_response_id.
+	ResponsesStore ResponsesStoreConfig `yaml:"responses-store" json:"responses-store"`
+
+	// Batch configures the OpenAI-compatible /v1/files and /v1/batches endpoints.
+	Batch BatchConfig `yaml:"batch" json:"batch"`
+
+	// Tracing configures OpenTelemetry trace export.
+	Tracing TracingConfig `yaml:"tracing" json:"tracing"`
+
+	// Guardrails configures content filters for secrets and PII in requests and responses.
+	Guardrails GuardrailConfig `yaml:"guardrails" json:"guardrails"`
+
 	// DisableCooling disables quota cooldown scheduling when true.
 	DisableCooling bool `yaml:"disable-cooling" json:"disable-cooling"`
 
@@ -69,6 +90,16 @@ type Config struct {
 	// Routing controls credential selection behavior.
 	Routing RoutingConfig `yaml:"routing" json:"routing"`
 
+	// HealthCheck configures scheduled synthetic probes of every active credential.
+	HealthCheck HealthCheckConfig `yaml:"health-check" json:"health-check"`
+
+	// ModelFallbacks declares cross-provider fallback chains tried after every credential
+	// for the requested model has failed.
+	ModelFallbacks []ModelFallback `yaml:"model-fallbacks,omitempty" json:"model-fallbacks,omitempty"`
+
+	// APIKeyLimits declares per-client-key request rate, token quota and stream concurrency limits.
+	APIKeyLimits []APIKeyLimit `yaml:"api-key-limits,omitempty" json:"api-key-limits,omitempty"`
+
 	// WebsocketAuth enables or disables authentication for the WebSocket API.
 	WebsocketAuth bool `yaml:"ws-auth" json:"ws-auth"`
 
@@ -127,6 +158,21 @@ type RemoteManagement struct {
 	// PanelGitHubRepository overrides the GitHub repository used to fetch the management panel asset.
 	// Accepts either a repository URL (https://github.com/org/repo) or an API releases endpoint.
 	PanelGitHubRepository string `yaml:"panel-github-repository"`
+	// Keys lists additional named management keys limited to a role or explicit scopes.
+	Keys []ManagementKey `yaml:"keys,omitempty"`
+}
+
+// ManagementKey is a named management key whose access is limited by role or scopes.
+type ManagementKey struct {
+	// Name identifies the key in management listings and the audit log.
+	Name string `yaml:"name" json:"name"`
+	// Key is the secret (plaintext or bcrypt hashed); plaintext values are hashed on load.
+	Key string `yaml:"key" json:"key,omitempty"`
+	// Role selects a predefined scope set (e.g., "usage-viewer", "config-admin").
+	Role string `yaml:"role,omitempty" json:"role,omitempty"`
+	// Scopes grants access to route groups in addition to the role. A scope such as "usage"
+	// allows all methods, while "usage:read" allows only GET requests.
+	Scopes []string `yaml:"scopes,omitempty" json:"scopes,omitempty"`
 }
 
 // OAuthConfig defines OAuth client settings for providers using OAuth.
@@ -156,8 +202,204 @@ type QuotaExceeded struct {
 // RoutingConfig configures how credentials are selected for requests.
 type RoutingConfig struct {
 	// Strategy selects the credential selection strategy.
-	// Supported values: "round-robin" (default), "fill-first".
+	// Supported values: "round-robin" (default), "fill-first", "least-latency", "weighted".
 	Strategy string `yaml:"strategy,omitempty" json:"strategy,omitempty"`
+
+	// Hedging races a second credential against a slow first attempt.
+	Hedging HedgingConfig `yaml:"hedging,omitempty" json:"hedging,omitempty"`
+
+	// Queue holds requests while every credential for their model is cooling down.
+	Queue QueueConfig `yaml:"queue,omitempty" json:"queue,omitempty"`
+}
+
+// QueueConfig configures the bounded wait queue used when every credential for a model is
+// cooling down. Queued requests are released as credentials recover, higher priority
+// classes first and round-robin across client keys within a class.
+type QueueConfig struct {
+	// Enabled turns the queue on. When off, such requests fail with 429 immediately.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// MaxDepth caps the number of waiting requests. Defaults to 100.
+	MaxDepth int `yaml:"max-depth,omitempty" json:"max-depth,omitempty"`
+	// MaxPerKey caps the waiting requests of a single client key. 0 means no cap.
+	MaxPerKey int `yaml:"max-per-key,omitempty" json:"max-per-key,omitempty"`
+	// MaxWaitSeconds is how long a request may wait before failing. Defaults to 60.
+	MaxWaitSeconds int `yaml:"max-wait-seconds,omitempty" json:"max-wait-seconds,omitempty"`
+	// Priorities maps client API keys to a priority class: "high", "normal" (default) or "low".
+	Priorities map[string]string `yaml:"priorities,omitempty" json:"priorities,omitempty"`
+}
+
+// Queue defaults applied when the queue is enabled without explicit limits.
+const (
+	DefaultQueueMaxDepth       = 100
+	DefaultQueueMaxWaitSeconds = 60
+)
+
+// HedgingConfig configures hedged requests. When the first attempt has produced no output
+// after Delay, a second attempt is started on another credential of the same provider and
+// the first to respond wins; the other attempt is cancelled and not recorded.
+type HedgingConfig struct {
+	// Enabled turns hedging on.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// DelayMS is how long to wait for the first byte before hedging. Defaults to 500.
+	DelayMS int `yaml:"delay-ms,omitempty" json:"delay-ms,omitempty"`
+	// Models limits hedging to matching model names ("*" wildcards). Empty hedges every model.
+	Models []string `yaml:"models,omitempty" json:"models,omitempty"`
+}
+
+// DefaultHedgingDelayMS is used when hedging is enabled without an explicit delay.
+const DefaultHedgingDelayMS = 500
+
+// HealthCheckConfig configures the credential health prober, which periodically sends a
+// minimal request (or a token count where the provider supports it) through every active
+// credential so that revoked or expired credentials are detected before users hit them.
+type HealthCheckConfig struct {
+	// Enabled turns scheduled probes on. Probes can always be run on demand.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// IntervalSeconds is the time between probe rounds. Defaults to 300.
+	IntervalSeconds int `yaml:"interval-seconds,omitempty" json:"interval-seconds,omitempty"`
+	// TimeoutSeconds bounds a single probe. Defaults to 30.
+	TimeoutSeconds int `yaml:"timeout-seconds,omitempty" json:"timeout-seconds,omitempty"`
+	// Models maps a provider to the model used for its probes. Providers without an entry
+	// use the first model registered for each credential.
+	Models map[string]string `yaml:"models,omitempty" json:"models,omitempty"`
+}
+
+// Health check defaults applied when probes are enabled without explicit timings.
+const (
+	DefaultHealthCheckIntervalSeconds = 300
+	DefaultHealthCheckTimeoutSeconds  = 30
+)
+
+// ModelFallback maps a requested model to the ordered list of models served instead
+// when the requested model is unavailable (cooling down, unauthorized or failing upstream).
+type ModelFallback struct {
+	// Model is the requested model name.
+	Model string `yaml:"model" json:"model"`
+	// Fallbacks lists the replacement models in the order they are tried.
+	Fallbacks []string `yaml:"fallbacks" json:"fallbacks"`
+}
+
+// APIKeyLimit defines the limits enforced for a single inbound client API key.
+// A zero value for any limit disables that limit.
+type APIKeyLimit struct {
+	// APIKey is the client key the limits apply to. Use "*" to set defaults for keys without an explicit entry.
+	APIKey string `yaml:"api-key" json:"api-key"`
+	// RequestsPerMinute caps the number of requests accepted in any rolling one-minute window.
+	RequestsPerMinute int `yaml:"requests-per-minute,omitempty" json:"requests-per-minute,omitempty"`
+	// TokensPerDay caps the total tokens consumed per UTC day.
+	TokensPerDay int64 `yaml:"tokens-per-day,omitempty" json:"tokens-per-day,omitempty"`
+	// MaxConcurrentStreams caps the number of streaming requests in flight at once.
+	MaxConcurrentStreams int `yaml:"max-concurrent-streams,omitempty" json:"max-concurrent-streams,omitempty"`
+}
+
+// UsagePersistenceConfig configures the durable usage statistics sink.
+// Changes take effect on restart.
+type UsagePersistenceConfig struct {
+	// Enabled toggles persisting usage records. Requires usage-statistics-enabled.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Backend selects the storage backend.
+	// Supported values: "jsonl" (default), "postgres" (default when PGSTORE_DSN is set).
+	Backend string `yaml:"backend,omitempty" json:"backend,omitempty"`
+	// Path is the JSONL file location. Defaults to usage/usage.jsonl next to the config file.
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+	// RetentionDays drops records older than this many days. Set to 0 to keep records forever.
+	RetentionDays int `yaml:"retention-days,omitempty" json:"retention-days,omitempty"`
+}
+
+// MetricsConfig controls the Prometheus text-format /metrics endpoint.
+type MetricsConfig struct {
+	// Enabled exposes GET /metrics. The endpoint returns 404 when disabled.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// RequireAuth protects the endpoint with the client API keys used by /v1. Defaults to
+	// true because the metrics are labelled with credential IDs, providers, models and handlers.
+	RequireAuth bool `yaml:"require-auth" json:"require-auth"`
+}
+
+// ResponseCacheConfig controls the non-streaming response cache.
+type ResponseCacheConfig struct {
+	// Enabled turns on response caching.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Backend selects the store: "memory" (default) or "disk".
+	Backend string `yaml:"backend,omitempty" json:"backend,omitempty"`
+	// Path is the disk cache directory. Defaults to cache/responses next to the config file.
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+	// TTLSeconds is how long a cached response is served. Defaults to 3600.
+	TTLSeconds int `yaml:"ttl-seconds,omitempty" json:"ttl-seconds,omitempty"`
+	// MaxEntries bounds the number of cached responses. Defaults to 1000.
+	MaxEntries int `yaml:"max-entries,omitempty" json:"max-entries,omitempty"`
+	// MaxSizeMB bounds the total size of cached responses. Defaults to 100.
+	MaxSizeMB int `yaml:"max-size-mb,omitempty" json:"max-size-mb,omitempty"`
+	// IncludeNondeterministic also caches requests without temperature 0.
+	IncludeNondeterministic bool `yaml:"include-nondeterministic,omitempty" json:"include-nondeterministic,omitempty"`
+}
+
+// GuardrailConfig controls the content-filter stage applied before requests leave the proxy.
+type GuardrailConfig struct {
+	// Enabled turns on content filtering.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// ApplyToResponses also filters non-streaming responses and stream chunks.
+	ApplyToResponses bool `yaml:"apply-to-responses,omitempty" json:"apply-to-responses,omitempty"`
+	// Rules are evaluated in order against every string value of the payload.
+	Rules []GuardrailRule `yaml:"rules,omitempty" json:"rules,omitempty"`
+}
+
+// GuardrailRule detects sensitive content with a built-in detector or a regular expression.
+type GuardrailRule struct {
+	// Name identifies the rule in counters and logs. Defaults to the detector name.
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+	// Detector selects a built-in detector: "api-key", "email" or "credit-card".
+	Detector string `yaml:"detector,omitempty" json:"detector,omitempty"`
+	// Pattern is a regular expression used when no detector is set.
+	Pattern string `yaml:"pattern,omitempty" json:"pattern,omitempty"`
+	// Action is "redact" (default), "block" or "log".
+	Action string `yaml:"action,omitempty" json:"action,omitempty"`
+	// Replacement substitutes redacted matches. Defaults to "[REDACTED:<name>]".
+	Replacement string `yaml:"replacement,omitempty" json:"replacement,omitempty"`
+}
+
+// ResponsesStoreConfig controls the store that keeps Responses API results so that
+// previous_response_id can be expanded and responses retrieved by ID.
+type ResponsesStoreConfig struct {
+	// Enabled turns on storing responses whose request does not set "store": false.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Backend selects the store: "memory" (default) or "disk".
+	Backend string `yaml:"backend,omitempty" json:"backend,omitempty"`
+	// Path is the disk store directory. Defaults to responses next to the config file.
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+	// TTLSeconds is how long a stored response is kept. Defaults to 30 days.
+	TTLSeconds int `yaml:"ttl-seconds,omitempty" json:"ttl-seconds,omitempty"`
+	// MaxEntries bounds the number of stored responses. Defaults to 10000.
+	MaxEntries int `yaml:"max-entries,omitempty" json:"max-entries,omitempty"`
+	// MaxSizeMB bounds the total size of stored responses. Defaults to 500.
+	MaxSizeMB int `yaml:"max-size-mb,omitempty" json:"max-size-mb,omitempty"`
+}
+
+// BatchConfig controls OpenAI Batch API emulation. Batches run in the background through
+// the regular request path and their files are kept on disk.
+type BatchConfig struct {
+	// Enabled turns on the /v1/files and /v1/batches endpoints.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Path is the directory holding batch files and state. Defaults to batches next to the config file.
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+	// Concurrency bounds the batch requests executed at once across all batches. Defaults to 4.
+	Concurrency int `yaml:"concurrency,omitempty" json:"concurrency,omitempty"`
+	// MaxFileSizeMB bounds uploaded files. Defaults to 200.
+	MaxFileSizeMB int `yaml:"max-file-size-mb,omitempty" json:"max-file-size-mb,omitempty"`
+}
+
+// TracingConfig controls OTLP/HTTP span export.
+type TracingConfig struct {
+	// Enabled turns on span recording and export.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Endpoint is the OTLP/HTTP traces URL. Defaults to http://127.0.0.1:4318/v1/traces.
+	Endpoint string `yaml:"endpoint,omitempty" json:"endpoint,omitempty"`
+	// ServiceName is reported as the service.name resource attribute. Defaults to cli-proxy-api.
+	ServiceName string `yaml:"service-name,omitempty" json:"service-name,omitempty"`
+	// SampleRatio is the fraction of new traces recorded, between 0 and 1. Defaults to 1.
+	// Traces continued from a client traceparent follow the client's sampling decision.
+	SampleRatio float64 `yaml:"sample-ratio,omitempty" json:"sample-ratio,omitempty"`
+	// Headers are added to every export request, e.g. collector authentication.
+	Headers map[string]string `yaml:"headers,omitempty" json:"-"`
 }
 
 // AmpModelMapping defines a model name mapping for Amp CLI requests.
@@ -407,6 +649,7 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	cfg.CodexSettings.ReasoningEffort = DefaultCodexReasoningEffort
 	cfg.CodexSettings.ReasoningSummary = DefaultCodexReasoningSummary
 	cfg.CodexSettings.ReasoningCompat = DefaultCodexReasoningCompat
+	cfg.Metrics.RequireAuth = true
 	if err = yaml.Unmarshal(data, &cfg); err != nil {
 		if optional {
 			// In cloud deploy mode, if YAML parsing fails, return empty config instead of error.
@@ -442,6 +685,17 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 		_ = SaveConfigPreserveCommentsUpdateNestedScalar(configFile, []string{"remote-management", "secret-key"}, hashed)
 	}
 
+	// Hash plaintext scoped management keys and persist only the hashed values.
+	hashedManagementKeys, errHashKeys := cfg.hashManagementKeys()
+	if errHashKeys != nil {
+		return nil, errHashKeys
+	}
+	if len(hashedManagementKeys) > 0 && !optional && configFile != "" {
+		if errSave := SaveConfigPreserveCommentsUpdateManagementKeys(configFile, hashedManagementKeys); errSave != nil {
+			return nil, fmt.Errorf("failed to persist hashed management keys: %w", errSave)
+		}
+	}
+
 	cfg.RemoteManagement.PanelGitHubRepository = strings.TrimSpace(cfg.RemoteManagement.PanelGitHubRepository)
 	if cfg.RemoteManagement.PanelGitHubRepository == "" {
 		cfg.RemoteManagement.PanelGitHubRepository = DefaultPanelGitHubRepository
@@ -451,6 +705,12 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 		cfg.LogsMaxTotalSizeMB = 0
 	}
 
+	cfg.UsagePersistence.Backend = strings.ToLower(strings.TrimSpace(cfg.UsagePersistence.Backend))
+	cfg.UsagePersistence.Path = strings.TrimSpace(cfg.UsagePersistence.Path)
+	if cfg.UsagePersistence.RetentionDays < 0 {
+		cfg.UsagePersistence.RetentionDays = 0
+	}
+
 	// Sync request authentication providers with inline API keys for backwards compatibility.
 	syncInlineAccessProvider(&cfg)
 
@@ -475,6 +735,46 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	// Normalize OAuth provider model exclusion map.
 	cfg.OAuthExcludedModels = NormalizeOAuthExcludedModels(cfg.OAuthExcludedModels)
 
+	// Sanitize model fallback chains: drop empty entries and self references.
+	cfg.SanitizeModelFallbacks()
+
+	// Sanitize model routing: drop incomplete routes and normalize provider names.
+	cfg.SanitizeModelRouting()
+
+	// Default the hedging delay when hedging is enabled without one.
+	if cfg.Routing.Hedging.Enabled && cfg.Routing.Hedging.DelayMS <= 0 {
+		cfg.Routing.Hedging.DelayMS = DefaultHedgingDelayMS
+	}
+
+	// Default the cooldown queue limits when the queue is enabled without them.
+	if cfg.Routing.Queue.Enabled {
+		if cfg.Routing.Queue.MaxDepth <= 0 {
+			cfg.Routing.Queue.MaxDepth = DefaultQueueMaxDepth
+		}
+		if cfg.Routing.Queue.MaxWaitSeconds <= 0 {
+			cfg.Routing.Queue.MaxWaitSeconds = DefaultQueueMaxWaitSeconds
+		}
+	}
+
+	// Default the health check timings when probes are enabled without them.
+	if cfg.HealthCheck.Enabled {
+		if cfg.HealthCheck.IntervalSeconds <= 0 {
+			cfg.HealthCheck.IntervalSeconds = DefaultHealthCheckIntervalSeconds
+		}
+		if cfg.HealthCheck.TimeoutSeconds <= 0 {
+			cfg.HealthCheck.TimeoutSeconds = DefaultHealthCheckTimeoutSeconds
+		}
+	}
+
+	// Sanitize per-client-key limits: drop entries without a key and clamp negative limits.
+	cfg.SanitizeAPIKeyLimits()
+
+	// Sanitize virtual key policies: drop entries without a key and normalize restrictions.
+	cfg.SanitizeAPIKeyPolicies()
+
+	// Sanitize scoped management keys: drop entries without a name or key.
+	cfg.SanitizeManagementKeys()
+
 	if cfg.legacyMigrationPending {
 		fmt.Println("Detected legacy configuration keys, attempting to persist the normalized config...")
 		if !optional && configFile != "" {
@@ -514,6 +814,203 @@ func (cfg *Config) SanitizeOpenAICompatibility() {
 	cfg.OpenAICompatibility = out
 }
 
+// SanitizeModelFallbacks trims model names, removes empty and self-referencing fallbacks,
+// deduplicates each chain and drops entries without any fallback.
+func (cfg *Config) SanitizeModelFallbacks() {
+	if cfg == nil || len(cfg.ModelFallbacks) == 0 {
+		return
+	}
+	out := make([]ModelFallback, 0, len(cfg.ModelFallbacks))
+	for _, entry := range cfg.ModelFallbacks {
+		entry.Model = strings.TrimSpace(entry.Model)
+		if entry.Model == "" {
+			continue
+		}
+		seen := map[string]struct{}{strings.ToLower(entry.Model): {}}
+		fallbacks := make([]string, 0, len(entry.Fallbacks))
+		for _, fallback := range entry.Fallbacks {
+			fallback = strings.TrimSpace(fallback)
+			key := strings.ToLower(fallback)
+			if fallback == "" {
+				continue
+			}
+			if _, exists := seen[key]; exists {
+				continue
+			}
+			seen[key] = struct{}{}
+			fallbacks = append(fallbacks, fallback)
+		}
+		if len(fallbacks) == 0 {
+			continue
+		}
+		entry.Fallbacks = fallbacks
+		out = append(out, entry)
+	}
+	cfg.ModelFallbacks = out
+}
+
+// SanitizeModelRouting trims routes, lower-cases provider names, normalizes prefixes
+// and drops routes without a match or target.
+func (cfg *Config) SanitizeModelRouting() {
+	if cfg == nil || len(cfg.ModelRouting) == 0 {
+		return
+	}
+	out := make([]ModelRoute, 0, len(cfg.ModelRouting))
+	for _, route := range cfg.ModelRouting {
+		route.Match = strings.TrimSpace(route.Match)
+		route.Target = strings.TrimSpace(route.Target)
+		if route.Match == "" || route.Target == "" {
+			continue
+		}
+		route.Prefix = normalizeModelPrefix(route.Prefix)
+		var providers []string
+		for _, provider := range route.Providers {
+			if provider = strings.ToLower(strings.TrimSpace(provider)); provider != "" {
+				providers = append(providers, provider)
+			}
+		}
+		route.Providers = providers
+		out = append(out, route)
+	}
+	cfg.ModelRouting = out
+}
+
+// SanitizeManagementKeys trims names, lower-cases roles and scopes, and drops keys without
+// a name or secret. Later entries with a duplicate name are ignored.
+func (cfg *Config) SanitizeManagementKeys() {
+	if cfg == nil || len(cfg.RemoteManagement.Keys) == 0 {
+		return
+	}
+	seen := make(map[string]struct{}, len(cfg.RemoteManagement.Keys))
+	out := make([]ManagementKey, 0, len(cfg.RemoteManagement.Keys))
+	for _, key := range cfg.RemoteManagement.Keys {
+		key.Name = strings.TrimSpace(key.Name)
+		key.Key = strings.TrimSpace(key.Key)
+		key.Role = strings.ToLower(strings.TrimSpace(key.Role))
+		if key.Name == "" || key.Key == "" {
+			continue
+		}
+		if _, exists := seen[key.Name]; exists {
+			continue
+		}
+		seen[key.Name] = struct{}{}
+		var scopes []string
+		for _, scope := range key.Scopes {
+			if scope = strings.ToLower(strings.TrimSpace(scope)); scope != "" {
+				scopes = append(scopes, scope)
+			}
+		}
+		key.Scopes = scopes
+		out = append(out, key)
+	}
+	cfg.RemoteManagement.Keys = out
+}
+
+// hashManagementKeys replaces plaintext scoped management keys with bcrypt hashes and
+// returns the new hashes keyed by their position in remote-management.keys.
+func (cfg *Config) hashManagementKeys() (map[int]string, error) {
+	var changed map[int]string
+	for i := range cfg.RemoteManagement.Keys {
+		key := &cfg.RemoteManagement.Keys[i]
+		secret := strings.TrimSpace(key.Key)
+		if secret == "" || looksLikeBcrypt(secret) {
+			continue
+		}
+		hashed, err := hashSecret(secret)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash management key %q: %w", key.Name, err)
+		}
+		key.Key = hashed
+		if changed == nil {
+			changed = make(map[int]string)
+		}
+		changed[i] = hashed
+	}
+	return changed, nil
+}
+
+// HashManagementKey returns the bcrypt hash stored for a management key secret.
+// Values that are already hashed are returned unchanged.
+func HashManagementKey(secret string) (string, error) {
+	secret = strings.TrimSpace(secret)
+	if secret == "" || looksLikeBcrypt(secret) {
+		return secret, nil
+	}
+	return hashSecret(secret)
+}
+
+// SanitizeAPIKeyLimits trims key names, drops entries without a key, clamps negative
+// limits to zero and keeps only the last entry for duplicate keys.
+func (cfg *Config) SanitizeAPIKeyLimits() {
+	if cfg == nil || len(cfg.APIKeyLimits) == 0 {
+		return
+	}
+	index := make(map[string]int, len(cfg.APIKeyLimits))
+	out := make([]APIKeyLimit, 0, len(cfg.APIKeyLimits))
+	for _, entry := range cfg.APIKeyLimits {
+		entry.APIKey = strings.TrimSpace(entry.APIKey)
+		if entry.APIKey == "" {
+			continue
+		}
+		if entry.RequestsPerMinute < 0 {
+			entry.RequestsPerMinute = 0
+		}
+		if entry.TokensPerDay < 0 {
+			entry.TokensPerDay = 0
+		}
+		if entry.MaxConcurrentStreams < 0 {
+			entry.MaxConcurrentStreams = 0
+		}
+		if i, ok := index[entry.APIKey]; ok {
+			out[i] = entry
+			continue
+		}
+		index[entry.APIKey] = len(out)
+		out = append(out, entry)
+	}
+	cfg.APIKeyLimits = out
+}
+
+// SanitizeAPIKeyPolicies trims keys and restrictions, drops entries without a key,
+// lower-cases provider names and keeps only the last entry for duplicate keys.
+func (cfg *Config) SanitizeAPIKeyPolicies() {
+	if cfg == nil || len(cfg.APIKeyPolicies) == 0 {
+		return
+	}
+	index := make(map[string]int, len(cfg.APIKeyPolicies))
+	out := make([]APIKeyPolicy, 0, len(cfg.APIKeyPolicies))
+	for _, policy := range cfg.APIKeyPolicies {
+		policy.APIKey = strings.TrimSpace(policy.APIKey)
+		if policy.APIKey == "" {
+			continue
+		}
+		policy.Label = strings.TrimSpace(policy.Label)
+		policy.ExpiresAt = strings.TrimSpace(policy.ExpiresAt)
+		policy.AllowedModels = trimNonEmpty(policy.AllowedModels, strings.TrimSpace)
+		policy.AllowedProviders = trimNonEmpty(policy.AllowedProviders, func(s string) string {
+			return strings.ToLower(strings.TrimSpace(s))
+		})
+		policy.AllowedPrefixes = trimNonEmpty(policy.AllowedPrefixes, normalizeModelPrefix)
+		if i, ok := index[policy.APIKey]; ok {
+			out[i] = policy
+			continue
+		}
+		index[policy.APIKey] = len(out)
+		out = append(out, policy)
+	}
+	cfg.APIKeyPolicies = out
+}
+
+func trimNonEmpty(values []string, normalize func(string) string) []string {
+	var out []string
+	for _, value := range values {
+		if value = normalize(value); value != "" {
+			out = append(out, value)
+		}
+	}
+	return out
+}
+
 // SanitizeCodexKeys removes Codex API key entries missing a BaseURL.
 // It trims whitespace and preserves order for remaining entries.
 func (cfg *Config) SanitizeCodexKeys() {
@@ -839,6 +1336,53 @@ func SaveConfigPreserveCommentsUpdateNestedScalar(configFile string, path []stri
 			node = next
 		}
 	}
+	return writeYAMLDocument(configFile, &root)
+}
+
+// SaveConfigPreserveCommentsUpdateManagementKeys replaces the key of the scoped management
+// entries at the given positions of remote-management.keys while preserving comments and
+// positions. Other values in the file are left untouched.
+func SaveConfigPreserveCommentsUpdateManagementKeys(configFile string, keys map[int]string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		return err
+	}
+	var root yaml.Node
+	if err = yaml.Unmarshal(data, &root); err != nil {
+		return err
+	}
+	if root.Kind != yaml.DocumentNode || len(root.Content) == 0 {
+		return fmt.Errorf("invalid yaml document structure")
+	}
+	top := root.Content[0]
+	idx := findMapKeyIndex(top, "remote-management")
+	if idx < 0 {
+		return fmt.Errorf("remote-management section not found")
+	}
+	section := top.Content[idx+1]
+	idx = findMapKeyIndex(section, "keys")
+	if idx < 0 || section.Content[idx+1].Kind != yaml.SequenceNode {
+		return fmt.Errorf("remote-management.keys sequence not found")
+	}
+	entries := section.Content[idx+1].Content
+	for i, value := range keys {
+		if i < 0 || i >= len(entries) || entries[i].Kind != yaml.MappingNode {
+			return fmt.Errorf("remote-management.keys[%d] not found", i)
+		}
+		v := getOrCreateMapValue(entries[i], "key")
+		v.Kind = yaml.ScalarNode
+		v.Tag = "!!str"
+		v.Style = 0
+		v.Value = value
+	}
+	return writeYAMLDocument(configFile, &root)
+}
+
+// writeYAMLDocument encodes root with the repository's indentation and writes it to configFile.
+func writeYAMLDocument(configFile string, root *yaml.Node) error {
 	f, err := os.Create(configFile)
 	if err != nil {
 		return err
@@ -847,15 +1391,14 @@ func SaveConfigPreserveCommentsUpdateNestedScalar(configFile string, path []stri
 	var buf bytes.Buffer
 	enc := yaml.NewEncoder(&buf)
 	enc.SetIndent(2)
-	if err = enc.Encode(&root); err != nil {
+	if err = enc.Encode(root); err != nil {
 		_ = enc.Close()
 		return err
 	}
 	if err = enc.Close(); err != nil {
 		return err
 	}
-	data = NormalizeCommentIndentation(buf.Bytes())
-	_, err = f.Write(data)
+	_, err = f.Write(NormalizeCommentIndentation(buf.Bytes()))
 	return err
 }
 
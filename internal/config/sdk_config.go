@@ -4,6 +4,11 @@
 // debug settings, proxy configuration, and API keys.
 package config
 
+import (
+	"strings"
+	"time"
+)
+
 // SDKConfig represents the application's configuration, loaded from a YAML file.
 type SDKConfig struct {
 	// ProxyURL is the URL of an optional proxy server to use for outbound requests.
@@ -20,11 +25,124 @@ type SDKConfig struct {
 	// APIKeys is a list of keys for authenticating clients to this proxy server.
 	APIKeys []string `yaml:"api-keys" json:"api-keys"`
 
+	// APIKeyPolicies restricts individual client keys to a subset of models and providers.
+	// Keys without a policy remain unrestricted.
+	APIKeyPolicies []APIKeyPolicy `yaml:"api-key-policies,omitempty" json:"api-key-policies,omitempty"`
+
 	// Access holds request authentication provider configuration.
 	Access AccessConfig `yaml:"auth,omitempty" json:"auth,omitempty"`
 
 	// Streaming configures server-side streaming behavior (keep-alives and safe bootstrap retries).
 	Streaming StreamingConfig `yaml:"streaming" json:"streaming"`
+
+	// ModelRouting maps client-visible model names to target models for every provider.
+	// Exact matches take precedence; wildcard and regex routes are evaluated in order.
+	ModelRouting []ModelRoute `yaml:"model-routing,omitempty" json:"model-routing,omitempty"`
+
+	// ContextOverflow controls how requests exceeding a model's context window are handled.
+	ContextOverflow ContextOverflowConfig `yaml:"context-overflow,omitempty" json:"context-overflow,omitempty"`
+}
+
+// Context overflow modes.
+const (
+	// ContextOverflowError rejects oversized requests with a context_length_exceeded error.
+	ContextOverflowError = "error"
+	// ContextOverflowTruncate drops the oldest conversation turns until the request fits.
+	ContextOverflowTruncate = "truncate"
+	// ContextOverflowReroute sends oversized requests to a larger-context model.
+	ContextOverflowReroute = "reroute"
+)
+
+// ContextOverflowConfig selects what happens when a request's estimated prompt exceeds the
+// input limit the registry reports for its model.
+type ContextOverflowConfig struct {
+	// Mode is "error" (default), "truncate" or "reroute".
+	Mode string `yaml:"mode,omitempty" json:"mode,omitempty"`
+
+	// RerouteModels maps a model to the larger-context model used in reroute mode. Models
+	// without an entry are not rerouted and fail with a context length error.
+	RerouteModels map[string]string `yaml:"reroute-models,omitempty" json:"reroute-models,omitempty"`
+}
+
+// NormalizedMode returns the configured mode in lower case, defaulting to "error".
+func (c ContextOverflowConfig) NormalizedMode() string {
+	switch mode := strings.ToLower(strings.TrimSpace(c.Mode)); mode {
+	case ContextOverflowTruncate, ContextOverflowReroute:
+		return mode
+	default:
+		return ContextOverflowError
+	}
+}
+
+// ModelRoute maps a requested model name to a target model and optional provider restrictions.
+type ModelRoute struct {
+	// Match is the client-visible model name. It may contain "*" wildcards, or be a
+	// regular expression matched against the whole name when Regex is true.
+	Match string `yaml:"match" json:"match"`
+
+	// Target is the model served instead. Each "*" receives the text matched by the
+	// corresponding wildcard; regex routes may reference capture groups as $1.
+	Target string `yaml:"target" json:"target"`
+
+	// Regex interprets Match as a case-insensitive regular expression.
+	Regex bool `yaml:"regex,omitempty" json:"regex,omitempty"`
+
+	// Providers restricts execution to these providers (e.g., "gemini", "claude").
+	Providers []string `yaml:"providers,omitempty" json:"providers,omitempty"`
+
+	// Prefix restricts execution to credentials registered with this model prefix.
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+}
+
+// APIKeyPolicy turns a client API key into a virtual key with a label, an expiry and
+// restrictions on the models and credentials it may use.
+type APIKeyPolicy struct {
+	// APIKey is the client key the policy applies to. It must also be listed in api-keys.
+	APIKey string `yaml:"api-key" json:"api-key"`
+
+	// Label is a human readable name shown in management listings.
+	Label string `yaml:"label,omitempty" json:"label,omitempty"`
+
+	// AllowedModels lists the model names the key may request; "*" matches any text.
+	// An empty list allows every model.
+	AllowedModels []string `yaml:"allowed-models,omitempty" json:"allowed-models,omitempty"`
+
+	// AllowedProviders restricts execution to these providers (e.g., "gemini", "claude").
+	AllowedProviders []string `yaml:"allowed-providers,omitempty" json:"allowed-providers,omitempty"`
+
+	// AllowedPrefixes requires requests to target credentials registered with one of these
+	// model prefixes (e.g., "teamA" for "teamA/gemini-2.5-pro").
+	AllowedPrefixes []string `yaml:"allowed-prefixes,omitempty" json:"allowed-prefixes,omitempty"`
+
+	// ExpiresAt is the RFC 3339 time after which the key is rejected. Empty never expires.
+	ExpiresAt string `yaml:"expires-at,omitempty" json:"expires-at,omitempty"`
+}
+
+// Expired reports whether the policy has expired at now. An unparsable expiry counts as
+// expired so a typo never grants indefinite access.
+func (p APIKeyPolicy) Expired(now time.Time) bool {
+	raw := strings.TrimSpace(p.ExpiresAt)
+	if raw == "" {
+		return false
+	}
+	expiresAt, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return true
+	}
+	return !now.Before(expiresAt)
+}
+
+// APIKeyPolicyFor returns the policy configured for key, if any.
+func (c *SDKConfig) APIKeyPolicyFor(key string) (APIKeyPolicy, bool) {
+	if c == nil || key == "" {
+		return APIKeyPolicy{}, false
+	}
+	for _, policy := range c.APIKeyPolicies {
+		if policy.APIKey == key {
+			return policy, true
+		}
+	}
+	return APIKeyPolicy{}, false
 }
 
 // StreamingConfig holds server streaming behavior configuration.
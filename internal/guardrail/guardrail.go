@@ -0,0 +1,310 @@
+// Package guardrail filters secrets and personal data out of payloads before they
+// leave the proxy, using built-in detectors or configured regular expressions.
+package guardrail
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Actions a rule may take when it matches.
+const (
+	ActionRedact = "redact"
+	ActionBlock  = "block"
+	ActionLog    = "log"
+)
+
+// Built-in detector names.
+const (
+	DetectorAPIKey     = "api-key"
+	DetectorEmail      = "email"
+	DetectorCreditCard = "credit-card"
+)
+
+var detectorPatterns = map[string]string{
+	DetectorAPIKey: `\b(?:sk-(?:ant-)?[A-Za-z0-9_-]{20,}|AIza[0-9A-Za-z_-]{35}|AKIA[0-9A-Z]{16}|gh[pousr]_[A-Za-z0-9]{36,}|xox[baprs]-[A-Za-z0-9-]{10,})`,
+	DetectorEmail:  `[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`,
+	// Candidates are confirmed with a Luhn check before they count as a match.
+	DetectorCreditCard: `\b\d(?:[ -]?\d){12,18}\b`,
+}
+
+// Violation describes the block rule that rejected a payload.
+type Violation struct {
+	Rule string
+}
+
+// Error implements error.
+func (v *Violation) Error() string {
+	return fmt.Sprintf("request blocked by content filter %q", v.Rule)
+}
+
+type rule struct {
+	name        string
+	action      string
+	replacement string
+	re          *regexp.Regexp
+	luhn        bool
+}
+
+// Filter applies compiled guardrail rules to JSON payloads.
+type Filter struct {
+	responses bool
+	rules     []rule
+}
+
+var current atomic.Pointer[Filter]
+
+// Configure replaces the active filter. Invalid rules are logged and skipped.
+func Configure(cfg config.GuardrailConfig) {
+	if !cfg.Enabled || len(cfg.Rules) == 0 {
+		current.Store(nil)
+		return
+	}
+	filter, errs := NewFilter(cfg)
+	for _, err := range errs {
+		log.Warnf("guardrails: %v", err)
+	}
+	current.Store(filter)
+}
+
+// Active returns the configured filter, or nil when guardrails are disabled.
+func Active() *Filter { return current.Load() }
+
+// NewFilter compiles cfg into a filter, returning errors for rules it had to skip.
+func NewFilter(cfg config.GuardrailConfig) (*Filter, []error) {
+	f := &Filter{responses: cfg.ApplyToResponses}
+	var errs []error
+	for i, r := range cfg.Rules {
+		compiled, err := compileRule(r)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("rule %d: %w", i, err))
+			continue
+		}
+		f.rules = append(f.rules, compiled)
+	}
+	return f, errs
+}
+
+func compileRule(r config.GuardrailRule) (rule, error) {
+	detector := strings.ToLower(strings.TrimSpace(r.Detector))
+	pattern := r.Pattern
+	if detector != "" {
+		builtin, ok := detectorPatterns[detector]
+		if !ok {
+			return rule{}, fmt.Errorf("unknown detector %q", r.Detector)
+		}
+		pattern = builtin
+	}
+	if strings.TrimSpace(pattern) == "" {
+		return rule{}, fmt.Errorf("detector or pattern is required")
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return rule{}, fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	name := strings.TrimSpace(r.Name)
+	if name == "" {
+		name = detector
+	}
+	if name == "" {
+		name = "pattern"
+	}
+	action := strings.ToLower(strings.TrimSpace(r.Action))
+	switch action {
+	case "":
+		action = ActionRedact
+	case ActionRedact, ActionBlock, ActionLog:
+	default:
+		return rule{}, fmt.Errorf("unknown action %q", r.Action)
+	}
+	replacement := r.Replacement
+	if replacement == "" {
+		replacement = "[REDACTED:" + name + "]"
+	}
+	return rule{name: name, action: action, replacement: replacement, re: re, luhn: detector == DetectorCreditCard}, nil
+}
+
+// AppliesToResponses reports whether responses should be filtered as well.
+func (f *Filter) AppliesToResponses() bool { return f != nil && f.responses }
+
+// Check returns the first block rule matching any string value of payload.
+func (f *Filter) Check(payload []byte) *Violation {
+	if f == nil || !gjson.ValidBytes(payload) {
+		return nil
+	}
+	var violation *Violation
+	walkStrings(gjson.ParseBytes(payload), "", func(_ string, value string) bool {
+		for i := range f.rules {
+			r := &f.rules[i]
+			if r.action == ActionBlock && r.matches(value) {
+				usage.GetRequestStatistics().RecordGuardrail(r.name, ActionBlock)
+				violation = &Violation{Rule: r.name}
+				return false
+			}
+		}
+		return true
+	})
+	return violation
+}
+
+// Apply redacts matches of redact and block rules in every string value of payload and
+// counts matches of log rules. Payloads that are not JSON are returned unchanged.
+func (f *Filter) Apply(payload []byte) []byte {
+	if f == nil || !gjson.ValidBytes(payload) {
+		return payload
+	}
+	type edit struct {
+		path  string
+		value string
+	}
+	var edits []edit
+	walkStrings(gjson.ParseBytes(payload), "", func(path string, value string) bool {
+		if filtered, changed := f.filterString(value); changed && path != "" {
+			edits = append(edits, edit{path: path, value: filtered})
+		}
+		return true
+	})
+	out := payload
+	for _, e := range edits {
+		updated, err := sjson.SetBytes(out, e.path, e.value)
+		if err != nil {
+			log.Debugf("guardrails: failed to redact %s: %v", e.path, err)
+			continue
+		}
+		out = updated
+	}
+	return out
+}
+
+// ApplyChunk filters a streamed chunk made of JSON or SSE "data:" lines.
+func (f *Filter) ApplyChunk(chunk []byte) []byte {
+	if f == nil || len(chunk) == 0 {
+		return chunk
+	}
+	if gjson.ValidBytes(chunk) {
+		return f.Apply(chunk)
+	}
+	lines := strings.Split(string(chunk), "\n")
+	changed := false
+	for i, line := range lines {
+		prefix, body, found := strings.Cut(line, "data:")
+		if !found || strings.TrimSpace(prefix) != "" {
+			continue
+		}
+		body = strings.TrimSpace(body)
+		filtered := f.Apply([]byte(body))
+		if string(filtered) != body {
+			lines[i] = "data: " + string(filtered)
+			changed = true
+		}
+	}
+	if !changed {
+		return chunk
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
+
+func (f *Filter) filterString(value string) (string, bool) {
+	changed := false
+	for i := range f.rules {
+		r := &f.rules[i]
+		if !r.matches(value) {
+			continue
+		}
+		if r.action == ActionLog {
+			usage.GetRequestStatistics().RecordGuardrail(r.name, ActionLog)
+			log.Warnf("guardrails: content matched rule %q", r.name)
+			continue
+		}
+		value = r.re.ReplaceAllStringFunc(value, func(match string) string {
+			if r.luhn && !luhnValid(match) {
+				return match
+			}
+			return r.replacement
+		})
+		usage.GetRequestStatistics().RecordGuardrail(r.name, ActionRedact)
+		changed = true
+	}
+	return value, changed
+}
+
+func (r *rule) matches(value string) bool {
+	if !r.luhn {
+		return r.re.MatchString(value)
+	}
+	for _, candidate := range r.re.FindAllString(value, -1) {
+		if luhnValid(candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+// luhnValid reports whether the digits in s pass the Luhn checksum.
+func luhnValid(s string) bool {
+	sum, count := 0, 0
+	double := false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		count++
+		double = !double
+	}
+	return count >= 13 && sum%10 == 0
+}
+
+// walkStrings visits every string value under value with its sjson path. The walk stops
+// when visit returns false.
+func walkStrings(value gjson.Result, path string, visit func(path, value string) bool) bool {
+	switch {
+	case value.IsObject():
+		keepGoing := true
+		value.ForEach(func(key, child gjson.Result) bool {
+			keepGoing = walkStrings(child, joinPath(path, escapePathKey(key.String())), visit)
+			return keepGoing
+		})
+		return keepGoing
+	case value.IsArray():
+		keepGoing := true
+		index := 0
+		value.ForEach(func(_, child gjson.Result) bool {
+			keepGoing = walkStrings(child, joinPath(path, strconv.Itoa(index)), visit)
+			index++
+			return keepGoing
+		})
+		return keepGoing
+	case value.Type == gjson.String:
+		return visit(path, value.String())
+	}
+	return true
+}
+
+func joinPath(base, key string) string {
+	if base == "" {
+		return key
+	}
+	return base + "." + key
+}
+
+var pathEscaper = strings.NewReplacer(`\`, `\\`, ".", `\.`, "*", `\*`, "?", `\?`, "|", `\|`, "#", `\#`, "@", `\@`, ":", `\:`)
+
+func escapePathKey(key string) string { return pathEscaper.Replace(key) }
@@ -0,0 +1,54 @@
+package guardrail
+
+import (
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestFilter_ApplyRedactsStringValues(t *testing.T) {
+	filter, errs := NewFilter(config.GuardrailConfig{Enabled: true, Rules: []config.GuardrailRule{
+		{Detector: DetectorAPIKey},
+		{Detector: DetectorEmail, Replacement: "<email>"},
+		{Detector: DetectorCreditCard},
+	}})
+	if len(errs) != 0 {
+		t.Fatalf("NewFilter() errors = %v", errs)
+	}
+
+	payload := []byte(`{"messages":[{"role":"user","content":"key sk-abcdefghijklmnopqrstuvwx, mail\nbob@example.com, card 4111 1111 1111 1111, order 1234567890123"}],"a.b":"x@y.io"}`)
+	out := filter.Apply(payload)
+
+	content := gjson.GetBytes(out, "messages.0.content").String()
+	want := "key [REDACTED:api-key], mail\n<email>, card [REDACTED:credit-card], order 1234567890123"
+	if content != want {
+		t.Fatalf("content = %q, want %q", content, want)
+	}
+	if got := gjson.GetBytes(out, `a\.b`).String(); got != "<email>" {
+		t.Fatalf("escaped key value = %q, want <email>", got)
+	}
+	if role := gjson.GetBytes(out, "messages.0.role").String(); role != "user" {
+		t.Fatalf("role = %q, want unchanged", role)
+	}
+}
+
+func TestFilter_CheckBlocksAndChunks(t *testing.T) {
+	filter, _ := NewFilter(config.GuardrailConfig{Enabled: true, Rules: []config.GuardrailRule{
+		{Name: "internal-host", Pattern: `corp\.internal`, Action: ActionBlock},
+		{Detector: DetectorEmail},
+	}})
+	if v := filter.Check([]byte(`{"input":"call db.corp.internal"}`)); v == nil || v.Rule != "internal-host" {
+		t.Fatalf("Check() = %v, want internal-host violation", v)
+	}
+	if v := filter.Check([]byte(`{"input":"hello"}`)); v != nil {
+		t.Fatalf("Check() = %v, want nil", v)
+	}
+
+	chunk := []byte("event: delta\ndata: {\"delta\":{\"text\":\"write to a@b.co\"}}\n\n")
+	got := string(filter.ApplyChunk(chunk))
+	want := "event: delta\ndata: {\"delta\":{\"text\":\"write to [REDACTED:email]\"}}\n\n"
+	if got != want {
+		t.Fatalf("ApplyChunk() = %q, want %q", got, want)
+	}
+}
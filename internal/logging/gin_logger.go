@@ -21,6 +21,7 @@ var aiAPIPrefixes = []string{
 	"/v1/completions",
 	"/v1/messages",
 	"/v1/responses",
+	"/v1/embeddings",
 	"/v1beta/models/",
 	"/api/provider/",
 }
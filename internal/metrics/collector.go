@@ -0,0 +1,239 @@
+// Package metrics exposes proxy and credential health in the Prometheus text format.
+// Request counters and latencies are fed by the auth manager hook, token counters by
+// usage records, and credential and registry gauges are computed at scrape time.
+package metrics
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+// seriesKey identifies a request series.
+type seriesKey struct {
+	provider string
+	model    string
+	handler  string
+}
+
+func (k seriesKey) labels() []label {
+	return []label{{"provider", k.provider}, {"model", k.model}, {"handler", k.handler}}
+}
+
+type requestKey struct {
+	seriesKey
+	outcome string
+}
+
+type tokenKey struct {
+	provider  string
+	model     string
+	tokenType string
+}
+
+type refreshKey struct {
+	provider string
+	outcome  string
+}
+
+// Collector aggregates proxy metrics. It implements coreauth.Hook, coreauth.RefreshHook
+// and coreusage.Plugin.
+type Collector struct {
+	coreauth.NoopHook
+
+	mu        sync.Mutex
+	requests  map[requestKey]uint64
+	durations map[seriesKey]*histogram
+	ttft      map[seriesKey]*histogram
+	tokens    map[tokenKey]uint64
+	refreshes map[refreshKey]uint64
+}
+
+var defaultCollector = NewCollector()
+
+func init() {
+	coreusage.RegisterPlugin(defaultCollector)
+}
+
+// Default returns the shared collector registered with the usage manager.
+func Default() *Collector { return defaultCollector }
+
+// NewCollector constructs an empty collector.
+func NewCollector() *Collector {
+	return &Collector{
+		requests:  make(map[requestKey]uint64),
+		durations: make(map[seriesKey]*histogram),
+		ttft:      make(map[seriesKey]*histogram),
+		tokens:    make(map[tokenKey]uint64),
+		refreshes: make(map[refreshKey]uint64),
+	}
+}
+
+// OnResult implements coreauth.Hook by counting the upstream attempt and its latency.
+// Health probe results are not client requests and are skipped.
+func (c *Collector) OnResult(ctx context.Context, result coreauth.Result) {
+	if c == nil || result.Probe {
+		return
+	}
+	key := seriesKey{provider: result.Provider, model: result.Model, handler: handlerFromContext(ctx)}
+	outcome := "success"
+	if !result.Success {
+		outcome = "error"
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.requests[requestKey{seriesKey: key, outcome: outcome}]++
+	if result.Latency > 0 {
+		observe(c.durations, key, result.Latency)
+	}
+	if result.TimeToFirstToken > 0 {
+		observe(c.ttft, key, result.TimeToFirstToken)
+	}
+}
+
+// OnAuthRefreshed implements coreauth.RefreshHook.
+func (c *Collector) OnAuthRefreshed(_ context.Context, auth *coreauth.Auth, err error) {
+	if c == nil || auth == nil {
+		return
+	}
+	outcome := "success"
+	if err != nil {
+		outcome = "failure"
+	}
+	c.mu.Lock()
+	c.refreshes[refreshKey{provider: auth.Provider, outcome: outcome}]++
+	c.mu.Unlock()
+}
+
+// HandleUsage implements coreusage.Plugin by counting billed tokens.
+func (c *Collector) HandleUsage(_ context.Context, record coreusage.Record) {
+	if c == nil {
+		return
+	}
+	detail := record.Detail
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for tokenType, value := range map[string]int64{
+		"input":     detail.InputTokens,
+		"output":    detail.OutputTokens,
+		"reasoning": detail.ReasoningTokens,
+		"cached":    detail.CachedTokens,
+	} {
+		if value > 0 {
+			c.tokens[tokenKey{provider: record.Provider, model: record.Model, tokenType: tokenType}] += uint64(value)
+		}
+	}
+}
+
+// Write renders every metric family to w. auths supplies the credentials reported by the
+// per-auth gauges and may be nil.
+func (c *Collector) Write(w io.Writer, auths []*coreauth.Auth) error {
+	out := &writer{w: w}
+	c.writeCounters(out)
+	writeAuthGauges(out, auths, time.Now())
+	writeRegistryGauges(out, registry.GetGlobalRegistry().GetProviderClientCounts())
+	return out.err
+}
+
+func (c *Collector) writeCounters(out *writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	requests := make([]sample, 0, len(c.requests))
+	for key, value := range c.requests {
+		requests = append(requests, sample{labels: append(key.labels(), label{"outcome", key.outcome}), value: float64(value)})
+	}
+	out.family("cliproxy_requests_total", "counter", "Upstream request attempts by provider, model, handler and outcome.", requests)
+	out.histogramFamily("cliproxy_request_duration_seconds", "Upstream request latency in seconds.", c.durations)
+	out.histogramFamily("cliproxy_time_to_first_token_seconds", "Time to the first streamed chunk in seconds.", c.ttft)
+
+	tokens := make([]sample, 0, len(c.tokens))
+	for key, value := range c.tokens {
+		tokens = append(tokens, sample{labels: []label{{"provider", key.provider}, {"model", key.model}, {"type", key.tokenType}}, value: float64(value)})
+	}
+	out.family("cliproxy_tokens_total", "counter", "Tokens reported by upstream providers.", tokens)
+
+	refreshes := make([]sample, 0, len(c.refreshes))
+	for key, value := range c.refreshes {
+		refreshes = append(refreshes, sample{labels: []label{{"provider", key.provider}, {"outcome", key.outcome}}, value: float64(value)})
+	}
+	out.family("cliproxy_auth_refresh_total", "counter", "Credential refresh attempts by provider and outcome.", refreshes)
+}
+
+func writeAuthGauges(out *writer, auths []*coreauth.Auth, now time.Time) {
+	status := make([]sample, 0, len(auths))
+	available := make([]sample, 0, len(auths))
+	cooldown := make([]sample, 0)
+	for _, auth := range auths {
+		if auth == nil {
+			continue
+		}
+		labels := []label{{"auth_id", auth.ID}, {"provider", auth.Provider}}
+		status = append(status, sample{labels: append(labels, label{"status", string(auth.Status)}), value: 1})
+		up := 1.0
+		if auth.Disabled || auth.Unavailable || auth.Status == coreauth.StatusDisabled || auth.Status == coreauth.StatusError {
+			up = 0
+		}
+		available = append(available, sample{labels: labels, value: up})
+		if remaining := auth.NextRetryAfter.Sub(now); remaining > 0 {
+			cooldown = append(cooldown, sample{labels: append(labels, label{"model", ""}), value: remaining.Seconds()})
+		}
+		for model, state := range auth.ModelStates {
+			if state == nil {
+				continue
+			}
+			if remaining := state.NextRetryAfter.Sub(now); remaining > 0 {
+				cooldown = append(cooldown, sample{labels: append(labels, label{"model", model}), value: remaining.Seconds()})
+			}
+		}
+	}
+	out.family("cliproxy_auth_status", "gauge", "Credential lifecycle status; the sample with the current status is 1.", status)
+	out.family("cliproxy_auth_available", "gauge", "Whether the credential is enabled and not marked unavailable.", available)
+	out.family("cliproxy_auth_cooldown_seconds", "gauge", "Remaining cooldown per credential and model; an empty model covers the whole credential.", cooldown)
+}
+
+func writeRegistryGauges(out *writer, counts map[string]map[string]int) {
+	modelsByProvider := make(map[string]int)
+	clients := make([]sample, 0, len(counts))
+	for model, providers := range counts {
+		for provider, count := range providers {
+			modelsByProvider[provider]++
+			clients = append(clients, sample{labels: []label{{"model", model}, {"provider", provider}}, value: float64(count)})
+		}
+	}
+	models := make([]sample, 0, len(modelsByProvider))
+	for provider, count := range modelsByProvider {
+		models = append(models, sample{labels: []label{{"provider", provider}}, value: float64(count)})
+	}
+	out.family("cliproxy_registry_models", "gauge", "Models registered per provider.", models)
+	out.family("cliproxy_registry_model_clients", "gauge", "Credentials registered per model and provider.", clients)
+}
+
+func observe(series map[seriesKey]*histogram, key seriesKey, d time.Duration) {
+	h, ok := series[key]
+	if !ok {
+		h = newHistogram()
+		series[key] = h
+	}
+	h.observe(d.Seconds())
+}
+
+// handlerFromContext returns the route pattern of the inbound request that triggered the execution.
+func handlerFromContext(ctx context.Context) string {
+	if ctx != nil {
+		if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil {
+			if path := ginCtx.FullPath(); path != "" {
+				return path
+			}
+		}
+	}
+	return "unknown"
+}
@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+func TestCollectorWrite(t *testing.T) {
+	c := NewCollector()
+	ctx := context.Background()
+	c.OnResult(ctx, coreauth.Result{Provider: "gemini", Model: "m", Success: true, Latency: 300 * time.Millisecond})
+	c.OnResult(ctx, coreauth.Result{Provider: "gemini", Model: "m", Success: false, Latency: 2 * time.Second})
+	c.OnResult(ctx, coreauth.Result{Provider: "gemini", Model: "m", Success: true, Probe: true, Latency: time.Second})
+	c.HandleUsage(ctx, coreusage.Record{Provider: "gemini", Model: "m", Detail: coreusage.Detail{InputTokens: 10, OutputTokens: 5}})
+	c.OnAuthRefreshed(ctx, &coreauth.Auth{Provider: "claude"}, errors.New("boom"))
+
+	auths := []*coreauth.Auth{{
+		ID:          "a1",
+		Provider:    "gemini",
+		Status:      coreauth.StatusActive,
+		ModelStates: map[string]*coreauth.ModelState{"m": {NextRetryAfter: time.Now().Add(time.Minute)}},
+	}}
+
+	var b strings.Builder
+	if err := c.Write(&b, auths); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		`cliproxy_requests_total{provider="gemini",model="m",handler="unknown",outcome="error"} 1`,
+		`cliproxy_requests_total{provider="gemini",model="m",handler="unknown",outcome="success"} 1`,
+		`cliproxy_request_duration_seconds_bucket{provider="gemini",model="m",handler="unknown",le="0.5"} 1`,
+		`cliproxy_request_duration_seconds_bucket{provider="gemini",model="m",handler="unknown",le="+Inf"} 2`,
+		`cliproxy_request_duration_seconds_count{provider="gemini",model="m",handler="unknown"} 2`,
+		`cliproxy_tokens_total{provider="gemini",model="m",type="input"} 10`,
+		`cliproxy_tokens_total{provider="gemini",model="m",type="output"} 5`,
+		`cliproxy_auth_refresh_total{provider="claude",outcome="failure"} 1`,
+		`cliproxy_auth_status{auth_id="a1",provider="gemini",status="active"} 1`,
+		`cliproxy_auth_available{auth_id="a1",provider="gemini"} 1`,
+		`cliproxy_auth_cooldown_seconds{auth_id="a1",provider="gemini",model="m"}`,
+		"# TYPE cliproxy_request_duration_seconds histogram",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\n%s", want, out)
+		}
+	}
+}
+
+func TestEscapeLabelValue(t *testing.T) {
+	got := formatLabels([]label{{"model", "a\"b\\c\nd"}})
+	if want := `{model="a\"b\\c\nd"}`; got != want {
+		t.Fatalf("formatLabels() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,149 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ContentType is the media type of the Prometheus text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// durationBuckets are the upper bounds, in seconds, of the latency histograms.
+var durationBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+
+// histogram accumulates observations into fixed buckets.
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func newHistogram() *histogram {
+	return &histogram{counts: make([]uint64, len(durationBuckets))}
+}
+
+func (h *histogram) observe(value float64) {
+	for i, bound := range durationBuckets {
+		if value <= bound {
+			h.counts[i]++
+			break
+		}
+	}
+	h.sum += value
+	h.count++
+}
+
+// label is a single name/value pair attached to a sample.
+type label struct {
+	name  string
+	value string
+}
+
+// sample is one line of a metric family.
+type sample struct {
+	labels []label
+	value  float64
+}
+
+// writer renders metric families in the Prometheus text format.
+type writer struct {
+	w   io.Writer
+	err error
+}
+
+func (w *writer) printf(format string, args ...any) {
+	if w.err != nil {
+		return
+	}
+	_, w.err = fmt.Fprintf(w.w, format, args...)
+}
+
+func (w *writer) header(name, kind, help string) {
+	w.printf("# HELP %s %s\n", name, help)
+	w.printf("# TYPE %s %s\n", name, kind)
+}
+
+// family writes a counter or gauge family with samples sorted by label values.
+func (w *writer) family(name, kind, help string, samples []sample) {
+	w.header(name, kind, help)
+	sort.Slice(samples, func(i, j int) bool {
+		return labelKey(samples[i].labels) < labelKey(samples[j].labels)
+	})
+	for _, s := range samples {
+		w.printf("%s%s %s\n", name, formatLabels(s.labels), formatValue(s.value))
+	}
+}
+
+// histogramFamily writes a histogram family with one series per label set.
+func (w *writer) histogramFamily(name, help string, series map[seriesKey]*histogram) {
+	w.header(name, "histogram", help)
+	keys := make([]seriesKey, 0, len(series))
+	for key := range series {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return labelKey(keys[i].labels()) < labelKey(keys[j].labels())
+	})
+	for _, key := range keys {
+		h := series[key]
+		labels := key.labels()
+		var cumulative uint64
+		for i, bound := range durationBuckets {
+			cumulative += h.counts[i]
+			w.printf("%s_bucket%s %d\n", name, formatLabels(append(labels, label{"le", formatValue(bound)})), cumulative)
+		}
+		w.printf("%s_bucket%s %d\n", name, formatLabels(append(labels, label{"le", "+Inf"})), h.count)
+		w.printf("%s_sum%s %s\n", name, formatLabels(labels), formatValue(h.sum))
+		w.printf("%s_count%s %d\n", name, formatLabels(labels), h.count)
+	}
+}
+
+func labelKey(labels []label) string {
+	parts := make([]string, len(labels))
+	for i, l := range labels {
+		parts[i] = l.value
+	}
+	return strings.Join(parts, "\xff")
+}
+
+func formatLabels(labels []label) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, l := range labels {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(l.name)
+		b.WriteString(`="`)
+		b.WriteString(escapeLabelValue(l.value))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
+
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
@@ -0,0 +1,252 @@
+// Package ratelimit enforces per-client-key request, token and stream limits.
+// Token consumption is fed by usage records emitted by the runtime, so limits
+// reflect the tokens actually billed by upstream providers.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+// wildcardKey selects the limits applied to keys without an explicit entry.
+const wildcardKey = "*"
+
+const requestWindow = time.Minute
+
+// LimitError reports that a request was rejected by a limit.
+type LimitError struct {
+	// Reason is a human-readable description of the exceeded limit.
+	Reason string
+	// RetryAfter is the minimum wait before the request may succeed.
+	RetryAfter time.Duration
+}
+
+// Error implements error.
+func (e *LimitError) Error() string { return e.Reason }
+
+// keyState tracks the live counters for a single client key.
+type keyState struct {
+	requests []time.Time
+	day      string
+	tokens   int64
+	streams  int
+}
+
+// Limiter tracks usage per client API key and decides whether new requests are admitted.
+// It implements coreusage.Plugin so token quotas follow recorded usage.
+type Limiter struct {
+	mu     sync.Mutex
+	limits map[string]config.APIKeyLimit
+	states map[string]*keyState
+	now    func() time.Time
+}
+
+var defaultLimiter = NewLimiter(nil)
+
+func init() {
+	coreusage.RegisterPlugin(defaultLimiter)
+}
+
+// Default returns the shared limiter registered with the usage manager.
+func Default() *Limiter { return defaultLimiter }
+
+// NewLimiter constructs a limiter enforcing the given limits.
+func NewLimiter(limits []config.APIKeyLimit) *Limiter {
+	l := &Limiter{
+		states: make(map[string]*keyState),
+		now:    time.Now,
+	}
+	l.SetLimits(limits)
+	return l
+}
+
+// SetLimits replaces the configured limits. Live counters are preserved.
+func (l *Limiter) SetLimits(limits []config.APIKeyLimit) {
+	if l == nil {
+		return
+	}
+	next := make(map[string]config.APIKeyLimit, len(limits))
+	for _, entry := range limits {
+		if entry.APIKey == "" {
+			continue
+		}
+		next[entry.APIKey] = entry
+	}
+	l.mu.Lock()
+	l.limits = next
+	l.mu.Unlock()
+}
+
+// Limits returns the limits that apply to key and whether any limit is configured.
+func (l *Limiter) Limits(key string) (config.APIKeyLimit, bool) {
+	if l == nil {
+		return config.APIKeyLimit{}, false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limitsLocked(key)
+}
+
+func (l *Limiter) limitsLocked(key string) (config.APIKeyLimit, bool) {
+	limit, ok := l.limits[key]
+	if !ok {
+		limit, ok = l.limits[wildcardKey]
+	}
+	if !ok {
+		return config.APIKeyLimit{}, false
+	}
+	active := limit.RequestsPerMinute > 0 || limit.TokensPerDay > 0 || limit.MaxConcurrentStreams > 0
+	return limit, active
+}
+
+// Acquire admits a request for key or returns a *LimitError describing the exceeded limit.
+// The returned release function must be called once the request completes; it is never nil.
+func (l *Limiter) Acquire(key string, stream bool) (func(), error) {
+	return l.AcquireN(key, 1, stream)
+}
+
+// AcquireN admits n requests for key at once, as when one client request fans out into
+// several upstream requests. Either all n are admitted or none is charged. The returned
+// release function frees every stream taken and is never nil.
+func (l *Limiter) AcquireN(key string, n int, stream bool) (func(), error) {
+	noop := func() {}
+	if l == nil || key == "" || n <= 0 {
+		return noop, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limit, ok := l.limitsLocked(key)
+	if !ok {
+		return noop, nil
+	}
+	now := l.now()
+	state := l.stateLocked(key, now)
+
+	if limit.TokensPerDay > 0 && state.tokens >= limit.TokensPerDay {
+		return noop, &LimitError{
+			Reason:     fmt.Sprintf("daily token quota of %d exceeded for this API key", limit.TokensPerDay),
+			RetryAfter: untilNextDay(now),
+		}
+	}
+
+	if limit.RequestsPerMinute > 0 {
+		cutoff := now.Add(-requestWindow)
+		kept := state.requests[:0]
+		for _, ts := range state.requests {
+			if ts.After(cutoff) {
+				kept = append(kept, ts)
+			}
+		}
+		state.requests = kept
+		if n > limit.RequestsPerMinute {
+			return noop, &LimitError{
+				Reason:     fmt.Sprintf("%d requests exceed the rate limit of %d requests per minute for this API key", n, limit.RequestsPerMinute),
+				RetryAfter: requestWindow,
+			}
+		}
+		if over := len(state.requests) + n - limit.RequestsPerMinute; over > 0 {
+			return noop, &LimitError{
+				Reason:     fmt.Sprintf("rate limit of %d requests per minute exceeded for this API key", limit.RequestsPerMinute),
+				RetryAfter: state.requests[over-1].Add(requestWindow).Sub(now),
+			}
+		}
+	}
+
+	if stream && limit.MaxConcurrentStreams > 0 && state.streams+n > limit.MaxConcurrentStreams {
+		return noop, &LimitError{
+			Reason:     fmt.Sprintf("concurrent stream limit of %d reached for this API key", limit.MaxConcurrentStreams),
+			RetryAfter: time.Second,
+		}
+	}
+
+	if limit.RequestsPerMinute > 0 {
+		for i := 0; i < n; i++ {
+			state.requests = append(state.requests, now)
+		}
+	}
+	if !stream || limit.MaxConcurrentStreams <= 0 {
+		return noop, nil
+	}
+	state.streams += n
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			if state.streams -= n; state.streams < 0 {
+				state.streams = 0
+			}
+			l.mu.Unlock()
+		})
+	}, nil
+}
+
+// HandleUsage implements coreusage.Plugin by charging recorded tokens against the key's daily quota.
+func (l *Limiter) HandleUsage(_ context.Context, record coreusage.Record) {
+	if l == nil || record.APIKey == "" {
+		return
+	}
+	tokens := record.Detail.TotalTokens
+	if tokens == 0 {
+		tokens = record.Detail.InputTokens + record.Detail.OutputTokens + record.Detail.ReasoningTokens
+	}
+	if tokens <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	limit, ok := l.limitsLocked(record.APIKey)
+	if !ok || limit.TokensPerDay <= 0 {
+		return
+	}
+	state := l.stateLocked(record.APIKey, l.now())
+	state.tokens += tokens
+}
+
+// RestoreTokens adds tokens already consumed today, keyed by client key, to the daily counters.
+// It is used at startup to rebuild quotas from persisted usage so a restart does not reset them.
+func (l *Limiter) RestoreTokens(totals map[string]int64) {
+	if l == nil || len(totals) == 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	for key, tokens := range totals {
+		if key == "" || tokens <= 0 {
+			continue
+		}
+		l.stateLocked(key, now).tokens += tokens
+	}
+}
+
+// StartOfDay returns the UTC midnight at which the daily token quota containing now began.
+func StartOfDay(now time.Time) time.Time {
+	utc := now.UTC()
+	return time.Date(utc.Year(), utc.Month(), utc.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func (l *Limiter) stateLocked(key string, now time.Time) *keyState {
+	state, ok := l.states[key]
+	if !ok {
+		state = &keyState{}
+		l.states[key] = state
+	}
+	day := now.UTC().Format("2006-01-02")
+	if state.day != day {
+		state.day = day
+		state.tokens = 0
+	}
+	return state
+}
+
+func untilNextDay(now time.Time) time.Duration {
+	return StartOfDay(now).AddDate(0, 0, 1).Sub(now)
+}
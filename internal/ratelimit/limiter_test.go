@@ -0,0 +1,120 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+func newTestLimiter(limits ...config.APIKeyLimit) (*Limiter, *time.Time) {
+	now := time.Date(2025, 1, 2, 12, 0, 0, 0, time.UTC)
+	l := NewLimiter(limits)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestLimiter_RequestsPerMinute(t *testing.T) {
+	l, now := newTestLimiter(config.APIKeyLimit{APIKey: "k", RequestsPerMinute: 2})
+
+	for i := 0; i < 2; i++ {
+		if _, err := l.Acquire("k", false); err != nil {
+			t.Fatalf("Acquire() #%d error = %v", i, err)
+		}
+	}
+	_, err := l.Acquire("k", false)
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("Acquire() error = %v, want *LimitError", err)
+	}
+	if limitErr.RetryAfter != time.Minute {
+		t.Fatalf("RetryAfter = %v, want %v", limitErr.RetryAfter, time.Minute)
+	}
+
+	*now = now.Add(61 * time.Second)
+	if _, err = l.Acquire("k", false); err != nil {
+		t.Fatalf("Acquire() after window error = %v", err)
+	}
+}
+
+func TestLimiter_TokensPerDay(t *testing.T) {
+	l, now := newTestLimiter(config.APIKeyLimit{APIKey: "*", TokensPerDay: 100})
+
+	l.HandleUsage(context.Background(), coreusage.Record{APIKey: "k", Detail: coreusage.Detail{InputTokens: 60, OutputTokens: 40}})
+	_, err := l.Acquire("k", false)
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("Acquire() error = %v, want *LimitError", err)
+	}
+	if limitErr.RetryAfter != 12*time.Hour {
+		t.Fatalf("RetryAfter = %v, want %v", limitErr.RetryAfter, 12*time.Hour)
+	}
+
+	*now = now.Add(12 * time.Hour)
+	if _, err = l.Acquire("k", false); err != nil {
+		t.Fatalf("Acquire() on next day error = %v", err)
+	}
+}
+
+func TestLimiter_RestoreTokensCountsAgainstDailyQuota(t *testing.T) {
+	l, now := newTestLimiter(config.APIKeyLimit{APIKey: "k", TokensPerDay: 100})
+
+	l.RestoreTokens(map[string]int64{"k": 80})
+	if _, err := l.Acquire("k", false); err != nil {
+		t.Fatalf("Acquire() below quota error = %v", err)
+	}
+	l.HandleUsage(context.Background(), coreusage.Record{APIKey: "k", Detail: coreusage.Detail{TotalTokens: 20}})
+	if _, err := l.Acquire("k", false); err == nil {
+		t.Fatal("Acquire() after restored and new usage error = nil, want quota exceeded")
+	}
+
+	*now = now.Add(12 * time.Hour)
+	if _, err := l.Acquire("k", false); err != nil {
+		t.Fatalf("Acquire() on next day error = %v", err)
+	}
+}
+
+func TestLimiter_MaxConcurrentStreams(t *testing.T) {
+	l, _ := newTestLimiter(config.APIKeyLimit{APIKey: "k", MaxConcurrentStreams: 1})
+
+	release, err := l.Acquire("k", true)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	if _, err = l.Acquire("k", true); err == nil {
+		t.Fatal("Acquire() second stream error = nil, want limit error")
+	}
+	if _, err = l.Acquire("k", false); err != nil {
+		t.Fatalf("Acquire() non-stream error = %v", err)
+	}
+	release()
+	if _, err = l.Acquire("k", true); err != nil {
+		t.Fatalf("Acquire() after release error = %v", err)
+	}
+}
+
+func TestLimiter_AcquireNChargesAllOrNothing(t *testing.T) {
+	l, _ := newTestLimiter(config.APIKeyLimit{APIKey: "k", RequestsPerMinute: 5, MaxConcurrentStreams: 3})
+
+	release, err := l.AcquireN("k", 3, true)
+	if err != nil {
+		t.Fatalf("AcquireN(3) error = %v", err)
+	}
+	if _, err = l.AcquireN("k", 1, true); err == nil {
+		t.Fatal("AcquireN() beyond stream limit error = nil, want limit error")
+	}
+	release()
+	if _, err = l.AcquireN("k", 3, false); err == nil {
+		t.Fatal("AcquireN() beyond rate limit error = nil, want limit error")
+	}
+	if _, err = l.AcquireN("k", 2, false); err != nil {
+		t.Fatalf("AcquireN() within remaining budget error = %v", err)
+	}
+	var limitErr *LimitError
+	if _, err = l.AcquireN("k", 6, false); !errors.As(err, &limitErr) || limitErr.RetryAfter != time.Minute {
+		t.Fatalf("AcquireN() above the limit error = %v, want *LimitError", err)
+	}
+}
@@ -527,6 +527,88 @@ func GetAIStudioModels() []*ModelInfo {
 	}
 }
 
+// GetGeminiEmbeddingModels returns the Gemini embedding model definitions
+func GetGeminiEmbeddingModels() []*ModelInfo {
+	return []*ModelInfo{
+		{
+			ID:                         "gemini-embedding-001",
+			Object:                     "model",
+			Created:                    1752710400,
+			OwnedBy:                    "google",
+			Type:                       ModelTypeEmbedding,
+			Name:                       "models/gemini-embedding-001",
+			Version:                    "001",
+			DisplayName:                "Gemini Embedding 001",
+			Description:                "Obtain a distributed representation of a text.",
+			InputTokenLimit:            2048,
+			OutputTokenLimit:           1,
+			SupportedGenerationMethods: []string{"embedContent", "countTextTokens", "countTokens", "asyncBatchEmbedContent"},
+		},
+		{
+			ID:                         "text-embedding-004",
+			Object:                     "model",
+			Created:                    1713225600,
+			OwnedBy:                    "google",
+			Type:                       ModelTypeEmbedding,
+			Name:                       "models/text-embedding-004",
+			Version:                    "004",
+			DisplayName:                "Text Embedding 004",
+			Description:                "Obtain a distributed representation of a text.",
+			InputTokenLimit:            2048,
+			OutputTokenLimit:           1,
+			SupportedGenerationMethods: []string{"embedContent"},
+		},
+	}
+}
+
+// GetGeminiVertexEmbeddingModels returns the Vertex AI embedding model definitions
+func GetGeminiVertexEmbeddingModels() []*ModelInfo {
+	return []*ModelInfo{
+		{
+			ID:                         "gemini-embedding-001",
+			Object:                     "model",
+			Created:                    1752710400,
+			OwnedBy:                    "google",
+			Type:                       ModelTypeEmbedding,
+			Name:                       "models/gemini-embedding-001",
+			Version:                    "001",
+			DisplayName:                "Gemini Embedding 001",
+			Description:                "Obtain a distributed representation of a text.",
+			InputTokenLimit:            2048,
+			OutputTokenLimit:           1,
+			SupportedGenerationMethods: []string{"predict"},
+		},
+		{
+			ID:                         "text-embedding-005",
+			Object:                     "model",
+			Created:                    1731542400,
+			OwnedBy:                    "google",
+			Type:                       ModelTypeEmbedding,
+			Name:                       "models/text-embedding-005",
+			Version:                    "005",
+			DisplayName:                "Text Embedding 005",
+			Description:                "English and code text embedding model.",
+			InputTokenLimit:            2048,
+			OutputTokenLimit:           1,
+			SupportedGenerationMethods: []string{"predict"},
+		},
+		{
+			ID:                         "text-multilingual-embedding-002",
+			Object:                     "model",
+			Created:                    1715644800,
+			OwnedBy:                    "google",
+			Type:                       ModelTypeEmbedding,
+			Name:                       "models/text-multilingual-embedding-002",
+			Version:                    "002",
+			DisplayName:                "Text Multilingual Embedding 002",
+			Description:                "Multilingual text embedding model.",
+			InputTokenLimit:            2048,
+			OutputTokenLimit:           1,
+			SupportedGenerationMethods: []string{"predict"},
+		},
+	}
+}
+
 // GetOpenAIModels returns the standard OpenAI model definitions
 func GetOpenAIModels() []*ModelInfo {
 	return []*ModelInfo{
@@ -14,6 +14,10 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+// ModelTypeEmbedding marks models that only serve embedding requests.
+// Such models are excluded from chat model listings and automatic model resolution.
+const ModelTypeEmbedding = "embedding"
+
 // ModelInfo represents information about an available model
 type ModelInfo struct {
 	// ID is the unique identifier for the model
@@ -570,13 +574,23 @@ func (r *ModelRegistry) ClientSupportsModel(clientID, modelID string) bool {
 	return false
 }
 
-// GetAvailableModels returns all models that have at least one available client
+// GetAvailableModels returns all chat models that have at least one available client.
+// Embedding models are listed by GetAvailableEmbeddingModels instead.
 // Parameters:
 //   - handlerType: The handler type to filter models for (e.g., "openai", "claude", "gemini")
 //
 // Returns:
 //   - []map[string]any: List of available models in the requested format
 func (r *ModelRegistry) GetAvailableModels(handlerType string) []map[string]any {
+	return r.availableModels(handlerType, false)
+}
+
+// GetAvailableEmbeddingModels returns the embedding models that have at least one available client.
+func (r *ModelRegistry) GetAvailableEmbeddingModels(handlerType string) []map[string]any {
+	return r.availableModels(handlerType, true)
+}
+
+func (r *ModelRegistry) availableModels(handlerType string, embeddings bool) []map[string]any {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
@@ -584,6 +598,9 @@ func (r *ModelRegistry) GetAvailableModels(handlerType string) []map[string]any
 	quotaExpiredDuration := 5 * time.Minute
 
 	for _, registration := range r.models {
+		if isEmbedding := registration.Info != nil && registration.Info.Type == ModelTypeEmbedding; isEmbedding != embeddings {
+			continue
+		}
 		// Check if model has any non-quota-exceeded clients
 		availableClients := registration.Count
 		now := time.Now()
@@ -716,6 +733,31 @@ func (r *ModelRegistry) GetModelProviders(modelID string) []string {
 	return result
 }
 
+// GetProviderClientCounts returns the number of registered clients per provider for every model.
+// Returns:
+//   - map[string]map[string]int: Client counts keyed by model ID, then provider identifier
+func (r *ModelRegistry) GetProviderClientCounts() map[string]map[string]int {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	counts := make(map[string]map[string]int, len(r.models))
+	for modelID, registration := range r.models {
+		if registration == nil {
+			continue
+		}
+		providers := make(map[string]int, len(registration.Providers))
+		for name, count := range registration.Providers {
+			if count > 0 {
+				providers[name] = count
+			}
+		}
+		if len(providers) > 0 {
+			counts[modelID] = providers
+		}
+	}
+	return counts
+}
+
 // GetModelInfo returns the registered ModelInfo for the given model ID, if present.
 // Returns nil if the model is unknown to the registry.
 func (r *ModelRegistry) GetModelInfo(modelID string) *ModelInfo {
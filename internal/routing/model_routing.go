@@ -0,0 +1,170 @@
+// Package routing resolves client-visible model names to the models and providers
+// that serve them, based on the top-level model-routing configuration.
+package routing
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// Resolution describes where a routed model name should be served.
+type Resolution struct {
+	// Rule is the match pattern of the route that applied.
+	Rule string
+	// Model is the target model, qualified with the route prefix when one is set.
+	Model string
+	// Providers restricts execution to these providers when non-empty.
+	Providers []string
+}
+
+// Alias is a client-visible model name produced by an exact-match route.
+type Alias struct {
+	Name   string
+	Target string
+}
+
+// ModelRouter matches model names against the configured routes. Exact routes are
+// checked first, then wildcard and regex routes in configuration order.
+type ModelRouter struct {
+	exact    map[string]*compiledRoute
+	aliases  []Alias
+	patterns []*compiledRoute
+}
+
+type compiledRoute struct {
+	match     string
+	target    string
+	providers []string
+	re        *regexp.Regexp
+}
+
+// NewModelRouter compiles routes. Invalid patterns are logged and skipped.
+func NewModelRouter(routes []config.ModelRoute) *ModelRouter {
+	r := &ModelRouter{exact: make(map[string]*compiledRoute)}
+	for _, route := range routes {
+		compiled, err := compileRoute(route)
+		if err != nil {
+			log.Warnf("model routing: %v", err)
+			continue
+		}
+		if compiled.re != nil {
+			r.patterns = append(r.patterns, compiled)
+			continue
+		}
+		key := strings.ToLower(compiled.match)
+		if _, exists := r.exact[key]; exists {
+			continue
+		}
+		r.exact[key] = compiled
+		r.aliases = append(r.aliases, Alias{Name: compiled.match, Target: compiled.target})
+	}
+	return r
+}
+
+// ValidateRoutes reports the first route that cannot be compiled.
+func ValidateRoutes(routes []config.ModelRoute) error {
+	for _, route := range routes {
+		if _, err := compileRoute(route); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Empty reports whether the router has no routes.
+func (r *ModelRouter) Empty() bool {
+	return r == nil || (len(r.exact) == 0 && len(r.patterns) == 0)
+}
+
+// Resolve returns the route target for model, if any route matches it.
+func (r *ModelRouter) Resolve(model string) (Resolution, bool) {
+	model = strings.TrimSpace(model)
+	if r.Empty() || model == "" {
+		return Resolution{}, false
+	}
+	if route, ok := r.exact[strings.ToLower(model)]; ok {
+		return Resolution{Rule: route.match, Model: route.target, Providers: route.providers}, true
+	}
+	for _, route := range r.patterns {
+		match := route.re.FindStringSubmatchIndex(model)
+		if match == nil {
+			continue
+		}
+		target := string(route.re.ExpandString(nil, route.target, model, match))
+		if target == "" {
+			continue
+		}
+		return Resolution{Rule: route.match, Model: target, Providers: route.providers}, true
+	}
+	return Resolution{}, false
+}
+
+// Aliases lists the exact-match routes in configuration order.
+func (r *ModelRouter) Aliases() []Alias {
+	if r == nil {
+		return nil
+	}
+	return r.aliases
+}
+
+// FilterProviders keeps the providers allowed by the resolution, preserving order.
+func (res Resolution) FilterProviders(providers []string) []string {
+	if len(res.Providers) == 0 {
+		return providers
+	}
+	out := make([]string, 0, len(providers))
+	for _, provider := range providers {
+		for _, allowed := range res.Providers {
+			if strings.EqualFold(provider, allowed) {
+				out = append(out, provider)
+				break
+			}
+		}
+	}
+	return out
+}
+
+func compileRoute(route config.ModelRoute) (*compiledRoute, error) {
+	match := strings.TrimSpace(route.Match)
+	target := strings.TrimSpace(route.Target)
+	if match == "" || target == "" {
+		return nil, fmt.Errorf("route %q: match and target are required", match)
+	}
+	compiled := &compiledRoute{match: match, providers: route.Providers}
+
+	switch {
+	case route.Regex:
+		re, err := regexp.Compile("(?i)^(?:" + match + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("route %q: invalid regex: %w", match, err)
+		}
+		compiled.re = re
+	case strings.Contains(match, "*"):
+		parts := strings.Split(match, "*")
+		for i := range parts {
+			parts[i] = regexp.QuoteMeta(parts[i])
+		}
+		compiled.re = regexp.MustCompile("(?i)^" + strings.Join(parts, "(.*)") + "$")
+		// Each "*" in the target receives the text matched by the corresponding "*".
+		pieces := strings.Split(strings.ReplaceAll(target, "$", "$$"), "*")
+		var b strings.Builder
+		for i, piece := range pieces {
+			if i > 0 {
+				b.WriteString("${" + strconv.Itoa(i) + "}")
+			}
+			b.WriteString(piece)
+		}
+		target = b.String()
+	}
+
+	if prefix := strings.Trim(strings.TrimSpace(route.Prefix), "/"); prefix != "" && !strings.HasPrefix(target, prefix+"/") {
+		target = prefix + "/" + target
+	}
+	compiled.target = target
+	return compiled, nil
+}
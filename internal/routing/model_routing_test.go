@@ -0,0 +1,53 @@
+package routing
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestModelRouter_Resolve(t *testing.T) {
+	router := NewModelRouter([]config.ModelRoute{
+		{Match: "claude-*-latest", Target: "claude-*-20250929", Providers: []string{"claude"}},
+		{Match: "fast", Target: "gemini-2.5-flash", Prefix: "teamA"},
+		{Match: "gpt-(4o.*)", Regex: true, Target: "openai-$1"},
+		{Match: "(", Regex: true, Target: "broken"},
+		{Match: "FAST", Target: "ignored-duplicate"},
+	})
+
+	cases := []struct {
+		model string
+		want  Resolution
+		ok    bool
+	}{
+		{"Fast", Resolution{Rule: "fast", Model: "teamA/gemini-2.5-flash"}, true},
+		{"claude-sonnet-4-5-latest", Resolution{Rule: "claude-*-latest", Model: "claude-sonnet-4-5-20250929", Providers: []string{"claude"}}, true},
+		{"gpt-4o-mini", Resolution{Rule: "gpt-(4o.*)", Model: "openai-4o-mini"}, true},
+		{"my-gpt-4o", Resolution{}, false},
+		{"gemini-2.5-pro", Resolution{}, false},
+	}
+	for _, tc := range cases {
+		got, ok := router.Resolve(tc.model)
+		if ok != tc.ok || !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Resolve(%q) = %+v, %t; want %+v, %t", tc.model, got, ok, tc.want, tc.ok)
+		}
+	}
+
+	if aliases := router.Aliases(); len(aliases) != 1 || aliases[0].Name != "fast" {
+		t.Errorf("Aliases() = %+v, want only the exact route", aliases)
+	}
+	if err := ValidateRoutes([]config.ModelRoute{{Match: "(", Regex: true, Target: "x"}}); err == nil {
+		t.Error("ValidateRoutes() accepted an invalid regex")
+	}
+}
+
+func TestResolution_FilterProviders(t *testing.T) {
+	res := Resolution{Providers: []string{"claude"}}
+	if got := res.FilterProviders([]string{"gemini", "Claude"}); !reflect.DeepEqual(got, []string{"Claude"}) {
+		t.Fatalf("FilterProviders() = %v, want [Claude]", got)
+	}
+	if got := (Resolution{}).FilterProviders([]string{"gemini"}); !reflect.DeepEqual(got, []string{"gemini"}) {
+		t.Fatalf("FilterProviders() without restriction = %v", got)
+	}
+}
@@ -94,7 +94,9 @@ func recordAPIRequest(ctx context.Context, cfg *config.Config, info upstreamRequ
 }
 
 // recordAPIResponseMetadata captures upstream response status/header information for the latest attempt.
+// Rate limit headers are reported to the auth manager whether or not request logging is enabled.
 func recordAPIResponseMetadata(ctx context.Context, cfg *config.Config, status int, headers http.Header) {
+	reportRateLimitHeaders(ctx, headers)
 	if cfg == nil || !cfg.RequestLog {
 		return
 	}
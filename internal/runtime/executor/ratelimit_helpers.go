@@ -0,0 +1,99 @@
+package executor
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// reportRateLimitHeaders forwards the rate limit headers of an upstream response to the
+// auth manager so selectors can steer away from nearly exhausted credentials.
+func reportRateLimitHeaders(ctx context.Context, headers http.Header) {
+	if snapshot, ok := parseRateLimitHeaders(headers, time.Now()); ok {
+		cliproxyauth.ReportRateLimits(ctx, snapshot)
+	}
+}
+
+// parseRateLimitHeaders reads Anthropic style (anthropic-ratelimit-<kind>-<field>) and
+// OpenAI style (x-ratelimit-<field>-<kind>) rate limit headers plus Retry-After.
+func parseRateLimitHeaders(headers http.Header, now time.Time) (cliproxyauth.RateLimitSnapshot, bool) {
+	snapshot := cliproxyauth.RateLimitSnapshot{UpdatedAt: now}
+	if len(headers) == 0 {
+		return snapshot, false
+	}
+	found := false
+	anthropic := func(kind string) *cliproxyauth.RateLimitWindow {
+		prefix := "anthropic-ratelimit-" + kind + "-"
+		return parseRateLimitWindow(headers, prefix+"limit", prefix+"remaining", prefix+"reset", now)
+	}
+	openai := func(kind string) *cliproxyauth.RateLimitWindow {
+		return parseRateLimitWindow(headers, "x-ratelimit-limit-"+kind, "x-ratelimit-remaining-"+kind, "x-ratelimit-reset-"+kind, now)
+	}
+	for _, target := range []struct {
+		dst    **cliproxyauth.RateLimitWindow
+		window *cliproxyauth.RateLimitWindow
+	}{
+		{&snapshot.Requests, anthropic("requests")},
+		{&snapshot.Tokens, anthropic("tokens")},
+		{&snapshot.InputTokens, anthropic("input-tokens")},
+		{&snapshot.OutputTokens, anthropic("output-tokens")},
+		{&snapshot.Requests, openai("requests")},
+		{&snapshot.Tokens, openai("tokens")},
+	} {
+		if target.window != nil && *target.dst == nil {
+			*target.dst = target.window
+			found = true
+		}
+	}
+	if value := strings.TrimSpace(headers.Get("Retry-After")); value != "" {
+		if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds >= 0 {
+			snapshot.RetryAfter = now.Add(time.Duration(seconds * float64(time.Second)))
+			found = true
+		} else if at, errDate := http.ParseTime(value); errDate == nil {
+			snapshot.RetryAfter = at
+			found = true
+		}
+	}
+	return snapshot, found
+}
+
+// parseRateLimitWindow builds a window from its headers; it returns nil unless the
+// remaining count is present.
+func parseRateLimitWindow(headers http.Header, limitKey, remainingKey, resetKey string, now time.Time) *cliproxyauth.RateLimitWindow {
+	remaining, err := strconv.ParseInt(strings.TrimSpace(headers.Get(remainingKey)), 10, 64)
+	if err != nil {
+		return nil
+	}
+	window := &cliproxyauth.RateLimitWindow{Remaining: remaining}
+	if limit, errLimit := strconv.ParseInt(strings.TrimSpace(headers.Get(limitKey)), 10, 64); errLimit == nil {
+		window.Limit = limit
+	}
+	window.ResetAt = parseRateLimitReset(headers.Get(resetKey), now)
+	return window
+}
+
+// parseRateLimitReset accepts an RFC 3339 time, a Go duration ("6m0s", "20ms"), a number
+// of seconds, or a Unix timestamp.
+func parseRateLimitReset(value string, now time.Time) time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}
+	}
+	if at, err := time.Parse(time.RFC3339, value); err == nil {
+		return at
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(d)
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds >= 0 {
+		if seconds > 1e9 {
+			return time.Unix(int64(seconds), 0)
+		}
+		return now.Add(time.Duration(seconds * float64(time.Second)))
+	}
+	return time.Time{}
+}
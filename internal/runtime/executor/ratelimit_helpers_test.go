@@ -0,0 +1,37 @@
+package executor
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRateLimitHeaders(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	headers := http.Header{}
+	headers.Set("anthropic-ratelimit-requests-limit", "50")
+	headers.Set("anthropic-ratelimit-requests-remaining", "49")
+	headers.Set("anthropic-ratelimit-requests-reset", "2025-01-01T00:01:00Z")
+	headers.Set("x-ratelimit-limit-tokens", "30000")
+	headers.Set("x-ratelimit-remaining-tokens", "29000")
+	headers.Set("x-ratelimit-reset-tokens", "6m0s")
+	headers.Set("Retry-After", "30")
+
+	snapshot, ok := parseRateLimitHeaders(headers, now)
+	if !ok {
+		t.Fatal("parseRateLimitHeaders() found no rate limit headers")
+	}
+	if r := snapshot.Requests; r == nil || r.Limit != 50 || r.Remaining != 49 || !r.ResetAt.Equal(now.Add(time.Minute)) {
+		t.Fatalf("Requests = %+v", r)
+	}
+	if tk := snapshot.Tokens; tk == nil || tk.Limit != 30000 || tk.Remaining != 29000 || !tk.ResetAt.Equal(now.Add(6*time.Minute)) {
+		t.Fatalf("Tokens = %+v", tk)
+	}
+	if !snapshot.RetryAfter.Equal(now.Add(30 * time.Second)) {
+		t.Fatalf("RetryAfter = %v", snapshot.RetryAfter)
+	}
+
+	if _, ok = parseRateLimitHeaders(http.Header{"Content-Type": {"application/json"}}, now); ok {
+		t.Fatal("parseRateLimitHeaders() reported limits for a response without rate limit headers")
+	}
+}
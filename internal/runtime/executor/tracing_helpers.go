@@ -0,0 +1,19 @@
+package executor
+
+import (
+	"context"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/tracing"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+// translateRequest converts the client payload into the provider format, recording a
+// translation span when tracing is enabled.
+func translateRequest(ctx context.Context, from, to sdktranslator.Format, model string, payload []byte, stream bool) []byte {
+	_, span := tracing.Start(ctx, "translate.request", tracing.SpanKindInternal)
+	defer span.End()
+	span.SetAttribute("cliproxy.translate.from", from.String())
+	span.SetAttribute("cliproxy.translate.to", to.String())
+	span.SetAttribute("cliproxy.model", model)
+	return sdktranslator.TranslateRequest(from, to, model, payload, stream)
+}
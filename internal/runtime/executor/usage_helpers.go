@@ -82,6 +82,7 @@ func (r *usageReporter) publishWithOutcome(ctx context.Context, detail usage.Det
 			AuthIndex:   r.authIndex,
 			RequestedAt: r.requestedAt,
 			Failed:      failed,
+			Cache:       usage.CacheStatusFromContext(ctx),
 			Detail:      detail,
 		})
 	})
@@ -105,6 +106,7 @@ func (r *usageReporter) ensurePublished(ctx context.Context) {
 			AuthIndex:   r.authIndex,
 			RequestedAt: r.requestedAt,
 			Failed:      false,
+			Cache:       usage.CacheStatusFromContext(ctx),
 			Detail:      usage.Detail{},
 		})
 	})
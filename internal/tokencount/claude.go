@@ -0,0 +1,69 @@
+package tokencount
+
+import (
+	"fmt"
+
+	"github.com/tidwall/gjson"
+	"github.com/tiktoken-go/tokenizer"
+)
+
+// CountClaude approximates input tokens for Claude Messages API payloads.
+func CountClaude(enc tokenizer.Codec, payload []byte) (int64, error) {
+	if enc == nil {
+		return 0, fmt.Errorf("encoder is nil")
+	}
+	if len(payload) == 0 {
+		return 0, nil
+	}
+
+	root := gjson.ParseBytes(payload)
+	c := &counter{}
+	collectClaudeContent(root.Get("system"), c)
+	root.Get("messages").ForEach(func(_, message gjson.Result) bool {
+		c.add(message.Get("role").String())
+		collectClaudeContent(message.Get("content"), c)
+		return true
+	})
+	root.Get("tools").ForEach(func(_, tool gjson.Result) bool {
+		c.add(tool.Get("name").String())
+		c.add(tool.Get("description").String())
+		c.addJSON(tool.Get("input_schema"))
+		return true
+	})
+	c.addJSON(root.Get("tool_choice"))
+	return c.total(enc)
+}
+
+// collectClaudeContent adds a string or an array of content blocks.
+func collectClaudeContent(content gjson.Result, c *counter) {
+	if content.Type == gjson.String {
+		c.add(content.String())
+		return
+	}
+	content.ForEach(func(_, block gjson.Result) bool {
+		switch block.Get("type").String() {
+		case "text":
+			c.add(block.Get("text").String())
+		case "thinking":
+			c.add(block.Get("thinking").String())
+		case "redacted_thinking":
+			// Encrypted; its length says nothing about the tokens it stands for.
+		case "image":
+			c.extra += claudeImageTokens(block.Get("source.data").String())
+		case "document":
+			if block.Get("source.type").String() == "text" {
+				c.add(block.Get("source.data").String())
+			} else {
+				collectClaudeContent(block.Get("source.content"), c)
+			}
+		case "tool_use", "server_tool_use":
+			c.add(block.Get("name").String())
+			c.addJSON(block.Get("input"))
+		case "tool_result":
+			collectClaudeContent(block.Get("content"), c)
+		default:
+			c.addJSON(block)
+		}
+		return true
+	})
+}
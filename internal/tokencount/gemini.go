@@ -0,0 +1,64 @@
+package tokencount
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tiktoken-go/tokenizer"
+)
+
+// CountGemini approximates prompt tokens for Gemini generateContent payloads. Inline media
+// other than images is counted as a single image tile.
+func CountGemini(enc tokenizer.Codec, payload []byte) (int64, error) {
+	if enc == nil {
+		return 0, fmt.Errorf("encoder is nil")
+	}
+	if len(payload) == 0 {
+		return 0, nil
+	}
+
+	root := gjson.ParseBytes(payload)
+	c := &counter{}
+	systemInstruction := root.Get("systemInstruction")
+	if !systemInstruction.Exists() {
+		systemInstruction = root.Get("system_instruction")
+	}
+	collectGeminiParts(systemInstruction.Get("parts"), c)
+	root.Get("contents").ForEach(func(_, content gjson.Result) bool {
+		c.add(content.Get("role").String())
+		collectGeminiParts(content.Get("parts"), c)
+		return true
+	})
+	root.Get("tools").ForEach(func(_, tool gjson.Result) bool {
+		c.addJSON(tool)
+		return true
+	})
+	return c.total(enc)
+}
+
+func collectGeminiParts(parts gjson.Result, c *counter) {
+	parts.ForEach(func(_, part gjson.Result) bool {
+		switch {
+		case part.Get("text").Exists():
+			c.add(part.Get("text").String())
+		case part.Get("inlineData").Exists():
+			if strings.HasPrefix(part.Get("inlineData.mimeType").String(), "image/") {
+				c.extra += geminiImageTokens(part.Get("inlineData.data").String())
+			} else {
+				c.extra += geminiImageTileTokens
+			}
+		case part.Get("fileData").Exists():
+			c.extra += geminiImageTileTokens
+		case part.Get("functionCall").Exists():
+			c.add(part.Get("functionCall.name").String())
+			c.addJSON(part.Get("functionCall.args"))
+		case part.Get("functionResponse").Exists():
+			c.add(part.Get("functionResponse.name").String())
+			c.addJSON(part.Get("functionResponse.response"))
+		default:
+			c.addJSON(part)
+		}
+		return true
+	})
+}
@@ -0,0 +1,99 @@
+package tokencount
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	_ "image/gif"  // register GIF dimensions decoder
+	_ "image/jpeg" // register JPEG dimensions decoder
+	_ "image/png"  // register PNG dimensions decoder
+	"math"
+	"strings"
+)
+
+// Image estimates used when an image's dimensions cannot be read, for example for remote
+// URLs or unsupported encodings.
+const (
+	defaultOpenAIImageTokens = 765 // a 1024x1024 image at high detail
+	defaultClaudeImageTokens = 1600
+	geminiImageTileTokens    = 258
+)
+
+// imageHeaderBytes bounds how much base64 data is decoded to read image dimensions; JPEG
+// headers can follow sizeable EXIF blocks.
+const imageHeaderBytes = 128 << 10
+
+// imageDimensions reads the width and height of a base64 image, given either as a data URL
+// or as bare base64.
+func imageDimensions(data string) (int, int, bool) {
+	data = strings.TrimSpace(data)
+	if strings.HasPrefix(data, "data:") {
+		comma := strings.IndexByte(data, ',')
+		if comma < 0 || !strings.Contains(data[:comma], ";base64") {
+			return 0, 0, false
+		}
+		data = data[comma+1:]
+	}
+	if data == "" {
+		return 0, 0, false
+	}
+	if len(data) > imageHeaderBytes {
+		data = data[:imageHeaderBytes]
+	}
+	data = data[:len(data)/4*4]
+	raw, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return 0, 0, false
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(raw))
+	if err != nil || cfg.Width <= 0 || cfg.Height <= 0 {
+		return 0, 0, false
+	}
+	return cfg.Width, cfg.Height, true
+}
+
+// openAIImageTokens follows OpenAI's tiling: the image is fit into 2048x2048, its short
+// side scaled to 768, and each 512px tile costs 170 tokens on top of a base of 85.
+func openAIImageTokens(data, detail string) int64 {
+	if strings.EqualFold(detail, "low") {
+		return 85
+	}
+	w, h, ok := imageDimensions(data)
+	if !ok {
+		return defaultOpenAIImageTokens
+	}
+	width, height := float64(w), float64(h)
+	if scale := 2048 / math.Max(width, height); scale < 1 {
+		width, height = width*scale, height*scale
+	}
+	if scale := 768 / math.Min(width, height); scale < 1 {
+		width, height = width*scale, height*scale
+	}
+	tiles := math.Ceil(width/512) * math.Ceil(height/512)
+	return 85 + 170*int64(tiles)
+}
+
+// claudeImageTokens follows Anthropic's estimate of width*height/750 after images larger
+// than 1568px on their long edge are scaled down.
+func claudeImageTokens(data string) int64 {
+	w, h, ok := imageDimensions(data)
+	if !ok {
+		return defaultClaudeImageTokens
+	}
+	width, height := float64(w), float64(h)
+	if scale := 1568 / math.Max(width, height); scale < 1 {
+		width, height = width*scale, height*scale
+	}
+	return int64(math.Ceil(width * height / 750))
+}
+
+// geminiImageTokens follows Gemini's estimate of 258 tokens for images up to 384px on both
+// sides and 258 tokens per 768px tile otherwise.
+func geminiImageTokens(data string) int64 {
+	w, h, ok := imageDimensions(data)
+	if !ok || (w <= 384 && h <= 384) {
+		return geminiImageTileTokens
+	}
+	tiles := math.Ceil(float64(w)/768) * math.Ceil(float64(h)/768)
+	return geminiImageTileTokens * int64(tiles)
+}
@@ -0,0 +1,214 @@
+package tokencount
+
+import (
+	"fmt"
+
+	"github.com/tidwall/gjson"
+	"github.com/tiktoken-go/tokenizer"
+)
+
+// CountOpenAIChat approximates prompt tokens for OpenAI chat completions payloads.
+func CountOpenAIChat(enc tokenizer.Codec, payload []byte) (int64, error) {
+	if enc == nil {
+		return 0, fmt.Errorf("encoder is nil")
+	}
+	if len(payload) == 0 {
+		return 0, nil
+	}
+
+	root := gjson.ParseBytes(payload)
+	c := &counter{segments: make([]string, 0, 32)}
+
+	collectOpenAIMessages(root.Get("messages"), c)
+	collectOpenAITools(root.Get("tools"), c)
+	collectOpenAIFunctions(root.Get("functions"), c)
+	c.addJSON(root.Get("tool_choice"))
+	collectOpenAIResponseFormat(root.Get("response_format"), c)
+	c.add(root.Get("input").String())
+	c.add(root.Get("prompt").String())
+
+	return c.total(enc)
+}
+
+// CountResponses approximates prompt tokens for OpenAI Responses API payloads.
+func CountResponses(enc tokenizer.Codec, payload []byte) (int64, error) {
+	if enc == nil {
+		return 0, fmt.Errorf("encoder is nil")
+	}
+	if len(payload) == 0 {
+		return 0, nil
+	}
+
+	root := gjson.ParseBytes(payload)
+	c := &counter{}
+	c.add(root.Get("instructions").String())
+
+	input := root.Get("input")
+	if input.Type == gjson.String {
+		c.add(input.String())
+	}
+	input.ForEach(func(_, item gjson.Result) bool {
+		switch item.Get("type").String() {
+		case "function_call":
+			c.add(item.Get("name").String())
+			c.add(item.Get("arguments").String())
+		case "function_call_output":
+			c.addJSON(item.Get("output"))
+		case "reasoning":
+			item.Get("summary").ForEach(func(_, part gjson.Result) bool {
+				c.add(part.Get("text").String())
+				return true
+			})
+		default:
+			c.add(item.Get("role").String())
+			if content := item.Get("content"); content.Type == gjson.String {
+				c.add(content.String())
+			} else {
+				collectOpenAIContent(content, c)
+			}
+			c.add(item.Get("text").String())
+		}
+		return true
+	})
+
+	collectOpenAITools(root.Get("tools"), c)
+	c.addJSON(root.Get("tool_choice"))
+	if format := root.Get("text.format"); format.Exists() {
+		c.add(format.Get("name").String())
+		c.addJSON(format.Get("schema"))
+	}
+	return c.total(enc)
+}
+
+func collectOpenAIMessages(messages gjson.Result, c *counter) {
+	if !messages.Exists() || !messages.IsArray() {
+		return
+	}
+	messages.ForEach(func(_, message gjson.Result) bool {
+		c.add(message.Get("role").String())
+		c.add(message.Get("name").String())
+		collectOpenAIContent(message.Get("content"), c)
+		collectOpenAIToolCalls(message.Get("tool_calls"), c)
+		collectOpenAIFunctionCall(message.Get("function_call"), c)
+		return true
+	})
+}
+
+func collectOpenAIContent(content gjson.Result, c *counter) {
+	if !content.Exists() {
+		return
+	}
+	if content.Type == gjson.String {
+		c.add(content.String())
+		return
+	}
+	if content.IsArray() {
+		content.ForEach(func(_, part gjson.Result) bool {
+			partType := part.Get("type").String()
+			switch partType {
+			case "text", "input_text", "output_text":
+				c.add(part.Get("text").String())
+			case "image_url":
+				c.extra += openAIImageTokens(part.Get("image_url.url").String(), part.Get("image_url.detail").String())
+			case "input_image":
+				c.extra += openAIImageTokens(part.Get("image_url").String(), part.Get("detail").String())
+			case "input_audio", "output_audio", "audio":
+				c.add(part.Get("id").String())
+			case "tool_result":
+				c.add(part.Get("name").String())
+				collectOpenAIContent(part.Get("content"), c)
+			default:
+				if part.IsArray() {
+					collectOpenAIContent(part, c)
+					return true
+				}
+				c.addJSON(part)
+			}
+			return true
+		})
+		return
+	}
+	if content.Type == gjson.JSON {
+		c.add(content.Raw)
+	}
+}
+
+func collectOpenAIToolCalls(calls gjson.Result, c *counter) {
+	if !calls.Exists() || !calls.IsArray() {
+		return
+	}
+	calls.ForEach(func(_, call gjson.Result) bool {
+		c.add(call.Get("id").String())
+		c.add(call.Get("type").String())
+		if function := call.Get("function"); function.Exists() {
+			appendFunction(function, c)
+			c.add(function.Get("arguments").String())
+		}
+		return true
+	})
+}
+
+func collectOpenAIFunctionCall(call gjson.Result, c *counter) {
+	if !call.Exists() {
+		return
+	}
+	c.add(call.Get("name").String())
+	c.add(call.Get("arguments").String())
+}
+
+func collectOpenAITools(tools gjson.Result, c *counter) {
+	if !tools.Exists() {
+		return
+	}
+	if !tools.IsArray() {
+		appendToolPayload(tools, c)
+		return
+	}
+	tools.ForEach(func(_, tool gjson.Result) bool {
+		appendToolPayload(tool, c)
+		return true
+	})
+}
+
+func collectOpenAIFunctions(functions gjson.Result, c *counter) {
+	if !functions.Exists() || !functions.IsArray() {
+		return
+	}
+	functions.ForEach(func(_, function gjson.Result) bool {
+		appendFunction(function, c)
+		return true
+	})
+}
+
+func collectOpenAIResponseFormat(format gjson.Result, c *counter) {
+	if !format.Exists() {
+		return
+	}
+	c.add(format.Get("type").String())
+	c.add(format.Get("name").String())
+	if schema := format.Get("json_schema"); schema.Exists() {
+		c.add(schema.Raw)
+	}
+	if schema := format.Get("schema"); schema.Exists() {
+		c.add(schema.Raw)
+	}
+}
+
+// appendToolPayload adds a chat completions tool ({"function": {...}}) or a Responses API
+// tool, whose function fields sit at the top level.
+func appendToolPayload(tool gjson.Result, c *counter) {
+	if !tool.Exists() {
+		return
+	}
+	c.add(tool.Get("type").String())
+	appendFunction(tool, c)
+	if function := tool.Get("function"); function.Exists() {
+		appendFunction(function, c)
+	}
+}
+
+func appendFunction(function gjson.Result, c *counter) {
+	c.add(function.Get("name").String())
+	c.add(function.Get("description").String())
+	c.addJSON(function.Get("parameters"))
+}
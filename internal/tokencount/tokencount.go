@@ -0,0 +1,105 @@
+// Package tokencount estimates prompt tokens locally for every supported request format,
+// for use when an upstream offers no token counting endpoint and to reject requests that
+// exceed a model's input limit before they reach a provider.
+package tokencount
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tiktoken-go/tokenizer"
+)
+
+// TokenizerForModel returns a tokenizer codec suitable for an OpenAI-style model id.
+// Other model families use o200k_base, which approximates their tokenizers closely enough
+// for estimates.
+func TokenizerForModel(model string) (tokenizer.Codec, error) {
+	sanitized := strings.ToLower(strings.TrimSpace(model))
+	switch {
+	case sanitized == "":
+		return tokenizer.Get(tokenizer.Cl100kBase)
+	case strings.HasPrefix(sanitized, "gpt-5"):
+		return tokenizer.ForModel(tokenizer.GPT5)
+	case strings.HasPrefix(sanitized, "gpt-4.1"):
+		return tokenizer.ForModel(tokenizer.GPT41)
+	case strings.HasPrefix(sanitized, "gpt-4o"):
+		return tokenizer.ForModel(tokenizer.GPT4o)
+	case strings.HasPrefix(sanitized, "gpt-4"):
+		return tokenizer.ForModel(tokenizer.GPT4)
+	case strings.HasPrefix(sanitized, "gpt-3.5"), strings.HasPrefix(sanitized, "gpt-3"):
+		return tokenizer.ForModel(tokenizer.GPT35Turbo)
+	case strings.HasPrefix(sanitized, "o1"):
+		return tokenizer.ForModel(tokenizer.O1)
+	case strings.HasPrefix(sanitized, "o3"):
+		return tokenizer.ForModel(tokenizer.O3)
+	case strings.HasPrefix(sanitized, "o4"):
+		return tokenizer.ForModel(tokenizer.O4Mini)
+	default:
+		return tokenizer.Get(tokenizer.O200kBase)
+	}
+}
+
+// Estimate approximates the prompt tokens of payload, a request in the given format
+// ("openai", "openai-response", "claude", "gemini" or "gemini-cli") for model.
+func Estimate(format, model string, payload []byte) (int64, error) {
+	enc, err := TokenizerForModel(model)
+	if err != nil {
+		return 0, err
+	}
+	switch format {
+	case "openai":
+		return CountOpenAIChat(enc, payload)
+	case "openai-response":
+		return CountResponses(enc, payload)
+	case "claude":
+		return CountClaude(enc, payload)
+	case "gemini":
+		return CountGemini(enc, payload)
+	case "gemini-cli":
+		request := gjson.GetBytes(payload, "request")
+		if !request.Exists() {
+			return CountGemini(enc, payload)
+		}
+		return CountGemini(enc, []byte(request.Raw))
+	default:
+		return 0, fmt.Errorf("tokencount: unsupported format %q", format)
+	}
+}
+
+// counter accumulates text segments to encode and tokens estimated without the
+// tokenizer, such as images.
+type counter struct {
+	segments []string
+	extra    int64
+}
+
+func (c *counter) add(value string) {
+	if trimmed := strings.TrimSpace(value); trimmed != "" {
+		c.segments = append(c.segments, trimmed)
+	}
+}
+
+// addJSON adds a raw JSON value, or the value itself when it is a JSON string.
+func (c *counter) addJSON(value gjson.Result) {
+	if !value.Exists() {
+		return
+	}
+	if value.Type == gjson.String {
+		c.add(value.String())
+		return
+	}
+	c.add(value.Raw)
+}
+
+func (c *counter) total(enc tokenizer.Codec) (int64, error) {
+	joined := strings.Join(c.segments, "\n")
+	if joined == "" {
+		return c.extra, nil
+	}
+	count, err := enc.Count(joined)
+	if err != nil {
+		return 0, err
+	}
+	return int64(count) + c.extra, nil
+}
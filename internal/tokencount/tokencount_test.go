@@ -0,0 +1,68 @@
+package tokencount
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"testing"
+)
+
+func pngBase64(t *testing.T, width, height int) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatalf("png.Encode() error = %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func TestEstimate_CountsTextAcrossFormats(t *testing.T) {
+	payloads := map[string]string{
+		"openai":          `{"messages":[{"role":"user","content":"hello there"}]}`,
+		"openai-response": `{"instructions":"be brief","input":[{"type":"message","role":"user","content":[{"type":"input_text","text":"hello there"}]}]}`,
+		"claude":          `{"system":"be brief","messages":[{"role":"user","content":[{"type":"text","text":"hello there"}]}]}`,
+		"gemini":          `{"contents":[{"role":"user","parts":[{"text":"hello there"}]}]}`,
+		"gemini-cli":      `{"request":{"contents":[{"role":"user","parts":[{"text":"hello there"}]}]}}`,
+	}
+	for format, payload := range payloads {
+		count, err := Estimate(format, "", []byte(payload))
+		if err != nil || count < 3 || count > 12 {
+			t.Errorf("Estimate(%s) = %d, %v; want a small positive count", format, count, err)
+		}
+	}
+	if _, err := Estimate("openai-embeddings", "", []byte(`{}`)); err == nil {
+		t.Error("Estimate() for an unsupported format error = nil")
+	}
+}
+
+func TestEstimate_ImagesByDimension(t *testing.T) {
+	data := pngBase64(t, 1500, 750)
+
+	claude, err := Estimate("claude", "", []byte(`{"messages":[{"role":"user","content":[{"type":"image","source":{"type":"base64","media_type":"image/png","data":"`+data+`"}}]}]}`))
+	if err != nil {
+		t.Fatalf("Estimate(claude) error = %v", err)
+	}
+	// 1500*750/750 image tokens plus the role.
+	if claude < 1500 || claude > 1505 {
+		t.Errorf("Estimate(claude) = %d, want about 1500", claude)
+	}
+
+	openai, err := Estimate("openai", "gpt-4o", []byte(`{"messages":[{"role":"user","content":[{"type":"image_url","image_url":{"url":"data:image/png;base64,`+data+`"}}]}]}`))
+	if err != nil {
+		t.Fatalf("Estimate(openai) error = %v", err)
+	}
+	// Scaled to 1536x768: 3x2 tiles of 170 tokens plus 85.
+	if openai < 1105 || openai > 1110 {
+		t.Errorf("Estimate(openai) = %d, want about 1105", openai)
+	}
+
+	gemini, err := Estimate("gemini", "", []byte(`{"contents":[{"parts":[{"inlineData":{"mimeType":"image/png","data":"`+data+`"}}]}]}`))
+	if err != nil {
+		t.Fatalf("Estimate(gemini) error = %v", err)
+	}
+	// 2x1 tiles of 768px.
+	if gemini != 2*geminiImageTileTokens {
+		t.Errorf("Estimate(gemini) = %d, want %d", gemini, 2*geminiImageTileTokens)
+	}
+}
@@ -0,0 +1,157 @@
+package tokencount
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// conversationPath returns the JSON path of the turn array of a format.
+func conversationPath(format string) string {
+	switch format {
+	case "openai", "claude":
+		return "messages"
+	case "openai-response":
+		return "input"
+	case "gemini":
+		return "contents"
+	case "gemini-cli":
+		return "request.contents"
+	default:
+		return ""
+	}
+}
+
+// pinned reports whether an item is a system or developer instruction, which truncation
+// never drops.
+func pinned(item gjson.Result) bool {
+	switch item.Get("role").String() {
+	case "system", "developer":
+		return true
+	}
+	return false
+}
+
+// startsTurn reports whether an item opens a new turn: a user message that does not
+// carry tool results. Tool calls and their results therefore always share a turn.
+func startsTurn(format string, item gjson.Result) bool {
+	role := item.Get("role").String()
+	switch format {
+	case "openai-response":
+		itemType := item.Get("type").String()
+		return role == "user" && (itemType == "" || itemType == "message")
+	case "claude":
+		if role != "user" {
+			return false
+		}
+		hasToolResult := false
+		item.Get("content").ForEach(func(_, block gjson.Result) bool {
+			hasToolResult = block.Get("type").String() == "tool_result"
+			return !hasToolResult
+		})
+		return !hasToolResult
+	case "gemini", "gemini-cli":
+		if role != "user" && role != "" {
+			return false
+		}
+		hasResponse := false
+		item.Get("parts").ForEach(func(_, part gjson.Result) bool {
+			hasResponse = part.Get("functionResponse").Exists()
+			return !hasResponse
+		})
+		return !hasResponse
+	default:
+		return role == "user"
+	}
+}
+
+// Truncate drops the oldest turns of a conversation until its estimated prompt fits in
+// limit tokens. System prompts are kept, a tool call is never separated from its result,
+// and the latest turn is always kept. It returns the truncated payload with its estimate,
+// or an error when the payload cannot be made to fit.
+func Truncate(format, model string, payload []byte, limit int64) ([]byte, int64, error) {
+	total, err := Estimate(format, model, payload)
+	if err != nil {
+		return nil, 0, err
+	}
+	if total <= limit {
+		return payload, total, nil
+	}
+	path := conversationPath(format)
+	items := gjson.GetBytes(payload, path)
+	if !items.IsArray() {
+		return nil, total, fmt.Errorf("tokencount: %s payload has no turns to drop", format)
+	}
+
+	all := items.Array()
+	var kept []int
+	var turns [][]int
+	for i, item := range all {
+		switch {
+		case pinned(item):
+			kept = append(kept, i)
+		case startsTurn(format, item) || len(turns) == 0:
+			turns = append(turns, []int{i})
+		default:
+			turns[len(turns)-1] = append(turns[len(turns)-1], i)
+		}
+	}
+	if len(turns) < 2 {
+		return nil, total, fmt.Errorf("tokencount: a single turn of %d tokens exceeds the limit of %d", total, limit)
+	}
+
+	// withItems rewrites the turn array to the listed items, in their original order.
+	withItems := func(indexes []int) ([]byte, error) {
+		sorted := append([]int(nil), indexes...)
+		sort.Ints(sorted)
+		raw := make([]byte, 0, len(items.Raw))
+		raw = append(raw, '[')
+		for i, index := range sorted {
+			if i > 0 {
+				raw = append(raw, ',')
+			}
+			raw = append(raw, all[index].Raw...)
+		}
+		raw = append(raw, ']')
+		return sjson.SetRawBytes(payload, path, raw)
+	}
+	estimateWith := func(indexes []int) (int64, error) {
+		candidate, errSet := withItems(indexes)
+		if errSet != nil {
+			return 0, errSet
+		}
+		return Estimate(format, model, candidate)
+	}
+	base, err := estimateWith(kept)
+	if err != nil {
+		return nil, total, err
+	}
+
+	// Drop whole turns from the oldest, subtracting each turn's own estimate.
+	dropped := 0
+	for ; dropped < len(turns)-1 && total > limit; dropped++ {
+		cost, errTurn := estimateWith(append(append([]int(nil), kept...), turns[dropped]...))
+		if errTurn != nil {
+			return nil, total, errTurn
+		}
+		total -= cost - base
+	}
+	remaining := kept
+	for _, turn := range turns[dropped:] {
+		remaining = append(remaining, turn...)
+	}
+	truncated, err := withItems(remaining)
+	if err != nil {
+		return nil, total, err
+	}
+	total, err = Estimate(format, model, truncated)
+	if err != nil {
+		return nil, total, err
+	}
+	if total > limit {
+		return nil, total, fmt.Errorf("tokencount: %d tokens remain after dropping %d turns, above the limit of %d", total, dropped, limit)
+	}
+	return truncated, total, nil
+}
@@ -0,0 +1,55 @@
+package tokencount
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestTruncate_KeepsToolPairsAndSystemPrompt(t *testing.T) {
+	filler := strings.Repeat("lorem ipsum dolor sit amet ", 20)
+	payload := []byte(`{"system":"be brief","messages":[` +
+		`{"role":"user","content":"` + filler + `"},` +
+		`{"role":"assistant","content":[{"type":"tool_use","id":"t1","name":"search","input":{}}]},` +
+		`{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"` + filler + `"}]},` +
+		`{"role":"assistant","content":"done"},` +
+		`{"role":"user","content":"next question"}]}`)
+
+	full, err := Estimate("claude", "claude-sonnet-4", payload)
+	if err != nil {
+		t.Fatalf("Estimate() error: %v", err)
+	}
+	out, count, err := Truncate("claude", "claude-sonnet-4", payload, full/4)
+	if err != nil {
+		t.Fatalf("Truncate() error: %v", err)
+	}
+	messages := gjson.GetBytes(out, "messages").Array()
+	if len(messages) != 1 || messages[0].Get("content").String() != "next question" {
+		t.Fatalf("Truncate() kept %s, want only the latest turn", gjson.GetBytes(out, "messages").Raw)
+	}
+	if count > full/4 || gjson.GetBytes(out, "system").String() != "be brief" {
+		t.Fatalf("Truncate() = %d tokens, system %q", count, gjson.GetBytes(out, "system").String())
+	}
+
+	if _, _, err = Truncate("claude", "claude-sonnet-4", payload, 1); err == nil {
+		t.Fatal("Truncate() fit an impossible limit")
+	}
+}
+
+func TestTruncate_PinsOpenAISystemMessages(t *testing.T) {
+	filler := strings.Repeat("lorem ipsum dolor sit amet ", 20)
+	payload := []byte(`{"messages":[{"role":"system","content":"rules"},` +
+		`{"role":"user","content":"` + filler + `"},{"role":"assistant","content":"` + filler + `"},` +
+		`{"role":"user","content":"short"},{"role":"assistant","content":"ok"},` +
+		`{"role":"user","content":"last"}]}`)
+	full, _ := Estimate("openai", "gpt-4o", payload)
+	out, _, err := Truncate("openai", "gpt-4o", payload, full/2)
+	if err != nil {
+		t.Fatalf("Truncate() error: %v", err)
+	}
+	messages := gjson.GetBytes(out, "messages").Array()
+	if len(messages) != 4 || messages[0].Get("role").String() != "system" || messages[1].Get("content").String() != "short" {
+		t.Fatalf("Truncate() kept %s", gjson.GetBytes(out, "messages").Raw)
+	}
+}
@@ -0,0 +1,226 @@
+// Package tracing records request spans and exports them to an OpenTelemetry collector
+// over OTLP/HTTP using the JSON encoding. Spans cover the inbound handler, request
+// translation, conductor attempts and upstream HTTP calls, and W3C traceparent headers
+// are honoured on inbound requests and injected into upstream requests.
+package tracing
+
+import (
+	"context"
+	"encoding/binary"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultEndpoint    = "http://127.0.0.1:4318/v1/traces"
+	defaultServiceName = "cli-proxy-api"
+	exportQueueSize    = 2048
+	exportBatchSize    = 256
+	exportInterval     = 5 * time.Second
+	exportTimeout      = 10 * time.Second
+)
+
+// Tracer creates spans and forwards finished spans to the exporter.
+type Tracer struct {
+	// source is the configuration as supplied, used to detect changes on reload.
+	source      config.TracingConfig
+	cfg         config.TracingConfig
+	sampleRatio float64
+
+	queue  chan *Span
+	stop   chan struct{}
+	done   chan struct{}
+	once   sync.Once
+	client *http.Client
+}
+
+var current atomic.Pointer[Tracer]
+
+var configureMu sync.Mutex
+
+// Configure applies the tracing configuration. A running exporter is flushed and replaced
+// when the configuration changes; tracing stops when cfg.Enabled is false.
+func Configure(cfg config.TracingConfig) {
+	configureMu.Lock()
+	defer configureMu.Unlock()
+
+	previous := current.Load()
+	if previous != nil && cfg.Enabled && tracingConfigEqual(previous.source, cfg) {
+		return
+	}
+	var next *Tracer
+	if cfg.Enabled {
+		next = newTracer(cfg)
+		log.Infof("tracing enabled, exporting spans to %s", next.cfg.Endpoint)
+	}
+	current.Store(next)
+	if previous != nil {
+		go previous.shutdown(context.Background())
+		if next == nil {
+			log.Info("tracing disabled")
+		}
+	}
+}
+
+// Shutdown flushes pending spans and stops the exporter.
+func Shutdown(ctx context.Context) {
+	configureMu.Lock()
+	defer configureMu.Unlock()
+	if previous := current.Swap(nil); previous != nil {
+		previous.shutdown(ctx)
+	}
+}
+
+// Enabled reports whether spans are currently recorded.
+func Enabled() bool {
+	return current.Load() != nil
+}
+
+// Start begins a span as a child of the span or remote parent carried by ctx. It returns
+// ctx unchanged and a nil span when tracing is disabled.
+func Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	tracer := current.Load()
+	if tracer == nil {
+		return ctx, nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	span := &Span{tracer: tracer, name: name, kind: kind, start: time.Now()}
+	if parent, ok := parentFromContext(ctx); ok && parent.IsValid() {
+		span.sc.TraceID = parent.TraceID
+		span.sc.Sampled = parent.Sampled
+		span.parentID = parent.SpanID
+	} else {
+		span.sc.TraceID = randomTraceID()
+		span.sc.Sampled = tracer.sample(span.sc.TraceID)
+	}
+	span.sc.SpanID = randomSpanID()
+	return ContextWithSpan(ctx, span), span
+}
+
+// Inject writes the traceparent header for the active span in ctx, if any.
+func Inject(ctx context.Context, header http.Header) {
+	if header == nil {
+		return
+	}
+	if span := SpanFromContext(ctx); span != nil {
+		header.Set(TraceparentHeader, span.sc.Traceparent())
+	}
+}
+
+func newTracer(cfg config.TracingConfig) *Tracer {
+	source := cfg
+	cfg.Endpoint = strings.TrimSpace(cfg.Endpoint)
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = defaultEndpoint
+	}
+	cfg.ServiceName = strings.TrimSpace(cfg.ServiceName)
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = defaultServiceName
+	}
+	ratio := cfg.SampleRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+	t := &Tracer{
+		source:      source,
+		cfg:         cfg,
+		sampleRatio: ratio,
+		queue:       make(chan *Span, exportQueueSize),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+		client:      &http.Client{Timeout: exportTimeout},
+	}
+	go t.run()
+	return t
+}
+
+// sample decides whether a new root trace is recorded, deterministically by trace ID.
+func (t *Tracer) sample(traceID [16]byte) bool {
+	if t.sampleRatio >= 1 {
+		return true
+	}
+	bound := uint64(t.sampleRatio * float64(1<<63))
+	return binary.BigEndian.Uint64(traceID[8:])>>1 < bound
+}
+
+func (t *Tracer) export(span *Span) {
+	select {
+	case t.queue <- span:
+	default:
+		log.Debug("tracing export queue full, dropping span")
+	}
+}
+
+func (t *Tracer) run() {
+	defer close(t.done)
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+
+	batch := make([]*Span, 0, exportBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+		if err := t.send(ctx, batch); err != nil {
+			log.Debugf("tracing export failed: %v", err)
+		}
+		cancel()
+		batch = batch[:0]
+	}
+	for {
+		select {
+		case span := <-t.queue:
+			batch = append(batch, span)
+			if len(batch) >= exportBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-t.stop:
+			for {
+				select {
+				case span := <-t.queue:
+					batch = append(batch, span)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (t *Tracer) shutdown(ctx context.Context) {
+	t.once.Do(func() { close(t.stop) })
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	select {
+	case <-t.done:
+	case <-ctx.Done():
+	}
+}
+
+func tracingConfigEqual(a, b config.TracingConfig) bool {
+	if a.Enabled != b.Enabled || a.ServiceName != b.ServiceName || a.SampleRatio != b.SampleRatio || len(a.Headers) != len(b.Headers) {
+		return false
+	}
+	if strings.TrimSpace(a.Endpoint) != strings.TrimSpace(b.Endpoint) {
+		return false
+	}
+	for k, v := range a.Headers {
+		if b.Headers[k] != v {
+			return false
+		}
+	}
+	return true
+}
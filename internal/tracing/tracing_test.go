@@ -0,0 +1,86 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	const header = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, ok := ParseTraceparent(header)
+	if !ok {
+		t.Fatalf("ParseTraceparent(%q) ok = false", header)
+	}
+	if !sc.Sampled || sc.TraceIDString() != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.SpanIDString() != "00f067aa0ba902b7" {
+		t.Fatalf("ParseTraceparent() = %+v", sc)
+	}
+	if got := sc.Traceparent(); got != header {
+		t.Fatalf("Traceparent() = %q, want %q", got, header)
+	}
+
+	for _, invalid := range []string{
+		"",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"00-xyz92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	} {
+		if _, ok := ParseTraceparent(invalid); ok {
+			t.Errorf("ParseTraceparent(%q) ok = true, want false", invalid)
+		}
+	}
+}
+
+func TestExportContinuesRemoteTrace(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received otlpRequest
+	)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("decode export body: %v", err)
+		}
+	}))
+	defer collector.Close()
+
+	Configure(config.TracingConfig{Enabled: true, Endpoint: collector.URL})
+	defer Configure(config.TracingConfig{})
+
+	parent, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := ContextWithRemoteParent(context.Background(), parent)
+	ctx, span := Start(ctx, "inbound", SpanKindServer)
+	span.SetAttribute("cliproxy.request_id", "abcd1234")
+
+	header := http.Header{}
+	Inject(ctx, header)
+	if got, _ := ParseTraceparent(header.Get(TraceparentHeader)); got.SpanID != span.SpanContext().SpanID {
+		t.Fatalf("injected traceparent = %q, want span %s", header.Get(TraceparentHeader), span.SpanContext().SpanIDString())
+	}
+	span.End()
+	Shutdown(context.Background())
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received.ResourceSpans) != 1 || len(received.ResourceSpans[0].ScopeSpans) != 1 {
+		t.Fatalf("export body = %+v", received)
+	}
+	spans := received.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 1 {
+		t.Fatalf("exported %d spans, want 1", len(spans))
+	}
+	got := spans[0]
+	if got.TraceID != parent.TraceIDString() || got.ParentSpanID != parent.SpanIDString() || got.Kind != int(SpanKindServer) {
+		t.Fatalf("exported span = %+v", got)
+	}
+	if len(got.Attributes) != 1 || got.Attributes[0].Value.StringValue == nil || *got.Attributes[0].Value.StringValue != "abcd1234" {
+		t.Fatalf("exported attributes = %+v", got.Attributes)
+	}
+}
@@ -0,0 +1,39 @@
+package responses
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertClaudeResponseToOpenAIResponses_FunctionCallDoneCarriesName(t *testing.T) {
+	events := []string{
+		`data: {"type":"message_start","message":{"id":"msg_1","usage":{"input_tokens":5,"output_tokens":0}}}`,
+		`data: {"type":"content_block_start","index":0,"content_block":{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{}}}`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"{\"city\":\"Lyon\"}"}}`,
+		`data: {"type":"content_block_stop","index":0}`,
+	}
+	var param any
+	var done gjson.Result
+	for _, event := range events {
+		for _, chunk := range ConvertClaudeResponseToOpenAIResponses(context.Background(), "claude-sonnet-4-5", nil, nil, []byte(event), &param) {
+			for _, line := range strings.Split(chunk, "\n") {
+				payload, ok := strings.CutPrefix(line, "data: ")
+				if ok && gjson.Get(payload, "type").String() == "response.output_item.done" {
+					done = gjson.Parse(payload)
+				}
+			}
+		}
+	}
+	if !done.Exists() {
+		t.Fatal("no response.output_item.done event emitted")
+	}
+	if got := done.Get("item.name").String(); got != "get_weather" {
+		t.Fatalf("item.name = %q, want get_weather (item = %s)", got, done.Get("item").Raw)
+	}
+	if got := done.Get("item.arguments").String(); got != `{"city":"Lyon"}` {
+		t.Fatalf("item.arguments = %q", got)
+	}
+}
@@ -241,6 +241,11 @@ func ConvertCodexResponseToOpenAI(_ context.Context, modelName string, originalR
 		return []string{}
 	case "response.output_text.delta":
 		if deltaResult := rootResult.Get("delta"); deltaResult.Exists() {
+			if compat == "think-tags" && params.ThinkOpen && !params.ThinkClosed {
+				params.ThinkOpen = false
+				params.ThinkClosed = true
+				return []string{makeContentChunk("</think>"), makeContentChunk(deltaResult.String())}
+			}
 			return []string{makeContentChunk(deltaResult.String())}
 		}
 		return []string{}
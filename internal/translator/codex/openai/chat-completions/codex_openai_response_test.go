@@ -0,0 +1,29 @@
+package chat_completions
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertCodexResponseToOpenAI_ThinkTagsCloseBeforeAnswer(t *testing.T) {
+	events := []string{
+		`data: {"type":"response.created","response":{"id":"resp_1","created_at":1,"model":"gpt-5"}}`,
+		`data: {"type":"response.reasoning_summary_part.added"}`,
+		`data: {"type":"response.reasoning_summary_text.delta","delta":"thinking"}`,
+		`data: {"type":"response.output_text.delta","delta":"Hello"}`,
+		`data: {"type":"response.output_text.delta","delta":" world"}`,
+	}
+	var param any
+	var content strings.Builder
+	for _, event := range events {
+		for _, chunk := range ConvertCodexResponseToOpenAI(context.Background(), "gpt-5", nil, nil, []byte(event), &param) {
+			content.WriteString(gjson.Get(chunk, "choices.0.delta.content").String())
+		}
+	}
+	if got, want := content.String(), "<think>thinking</think>Hello world"; got != want {
+		t.Fatalf("content = %q, want %q", got, want)
+	}
+}
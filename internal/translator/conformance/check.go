@@ -0,0 +1,63 @@
+package conformance
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// CheckRequest compares a client request with its translation and returns the invariants
+// the translation broke: declared tools, tool calls and results, images, and text.
+func CheckRequest(source, translated Summary) []string {
+	var violations []string
+	if !reflect.DeepEqual(source.Tools, translated.Tools) {
+		violations = append(violations, fmt.Sprintf("declared tools %v became %v", source.Tools, translated.Tools))
+	}
+	violations = append(violations, compareToolCalls(source.ToolCalls, translated.ToolCalls)...)
+	if source.ToolResults != translated.ToolResults {
+		violations = append(violations, fmt.Sprintf("%d tool results became %d", source.ToolResults, translated.ToolResults))
+	}
+	if source.Images != translated.Images {
+		violations = append(violations, fmt.Sprintf("%d images became %d", source.Images, translated.Images))
+	}
+	joined := strings.Join(translated.Texts, "\n")
+	for _, text := range source.Texts {
+		if !strings.Contains(joined, strings.TrimSpace(text)) {
+			violations = append(violations, fmt.Sprintf("text %q was dropped", text))
+		}
+	}
+	return violations
+}
+
+// CheckResponse compares an upstream response with its translation and returns the
+// invariants the translation broke: text, thinking, tool calls, stop reason and usage.
+func CheckResponse(upstream, translated Summary) []string {
+	var violations []string
+	if got, want := strings.TrimSpace(strings.Join(translated.Texts, "")), strings.TrimSpace(strings.Join(upstream.Texts, "")); got != want {
+		violations = append(violations, fmt.Sprintf("text %q became %q", want, got))
+	}
+	if upstream.Thinking && !translated.Thinking {
+		violations = append(violations, "thinking was dropped")
+	}
+	violations = append(violations, compareToolCalls(upstream.ToolCalls, translated.ToolCalls)...)
+	if upstream.StopReason != "" && upstream.StopReason != translated.StopReason {
+		violations = append(violations, fmt.Sprintf("stop reason %q became %q", upstream.StopReason, translated.StopReason))
+	}
+	if total := upstream.InputTokens + upstream.OutputTokens; total > 0 && total != translated.InputTokens+translated.OutputTokens {
+		violations = append(violations, fmt.Sprintf("usage %d+%d became %d+%d", upstream.InputTokens, upstream.OutputTokens, translated.InputTokens, translated.OutputTokens))
+	}
+	return violations
+}
+
+func compareToolCalls(want, got []ToolCall) []string {
+	if len(want) != len(got) {
+		return []string{fmt.Sprintf("tool calls %v became %v", want, got)}
+	}
+	var violations []string
+	for i := range want {
+		if want[i] != got[i] {
+			violations = append(violations, fmt.Sprintf("tool call %d %s(%s) became %s(%s)", i, want[i].Name, want[i].Arguments, got[i].Name, got[i].Arguments))
+		}
+	}
+	return violations
+}
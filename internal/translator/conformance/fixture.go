@@ -0,0 +1,74 @@
+// Package conformance replays recorded client requests and upstream responses through the
+// registered translators and checks that the content survives each conversion. Fixtures are
+// recorded from request logs (see Record) and replayed by the package tests, which also
+// compare the fixture's own translation pair against golden files.
+package conformance
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+// Fixture is one recorded exchange: the request a client sent in its own format and the
+// upstream response the provider returned in the provider format.
+type Fixture struct {
+	// Name identifies the fixture and names its golden file.
+	Name string `json:"name"`
+	// From is the client format the request was sent in.
+	From sdktranslator.Format `json:"from"`
+	// To is the provider format the request was translated to.
+	To sdktranslator.Format `json:"to"`
+	// Model is the model requested by the client.
+	Model string `json:"model"`
+	// Stream reports whether the exchange was streamed.
+	Stream bool `json:"stream"`
+	// Request is the client request body.
+	Request json.RawMessage `json:"request"`
+	// Response holds the upstream response: one entry per line for streams, or the
+	// whole body for non-streaming exchanges.
+	Response []string `json:"response"`
+}
+
+// LoadFixtures reads every *.json fixture in dir, sorted by name.
+func LoadFixtures(dir string) ([]Fixture, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	fixtures := make([]Fixture, 0, len(paths))
+	for _, path := range paths {
+		data, errRead := os.ReadFile(path)
+		if errRead != nil {
+			return nil, errRead
+		}
+		var fixture Fixture
+		if errUnmarshal := json.Unmarshal(data, &fixture); errUnmarshal != nil {
+			return nil, fmt.Errorf("conformance: parse %s: %w", path, errUnmarshal)
+		}
+		if fixture.Name == "" {
+			fixture.Name = strings.TrimSuffix(filepath.Base(path), ".json")
+		}
+		fixtures = append(fixtures, fixture)
+	}
+	return fixtures, nil
+}
+
+// SaveFixture writes fixture to dir as <name>.json.
+func SaveFixture(dir string, fixture Fixture) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, fixture.Name+".json")
+	return path, os.WriteFile(path, append(data, '\n'), 0o644)
+}
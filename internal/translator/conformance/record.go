@@ -0,0 +1,206 @@
+package conformance
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+var (
+	sectionHeader    = regexp.MustCompile(`^=== ([A-Z ]+?)(?: (\d+))? ===$`)
+	fixtureNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+)
+
+// logSection is one "=== NAME ===" section of a request log.
+type logSection struct {
+	name string
+	body string
+}
+
+// Record converts request logs into fixtures under outDir. logPath is a single log file
+// or a directory of *.log files; in a directory, logs that cannot be recorded (errors,
+// model listings, missing upstream sections) are skipped and reported in the error.
+func Record(logPath, outDir string) ([]string, error) {
+	info, err := os.Stat(logPath)
+	if err != nil {
+		return nil, err
+	}
+	paths := []string{logPath}
+	if info.IsDir() {
+		if paths, err = filepath.Glob(filepath.Join(logPath, "*.log")); err != nil {
+			return nil, err
+		}
+		sort.Strings(paths)
+	}
+	var written []string
+	var skipped []error
+	for _, path := range paths {
+		data, errRead := os.ReadFile(path)
+		if errRead != nil {
+			return written, errRead
+		}
+		fixture, errParse := ParseRequestLog(strings.TrimSuffix(filepath.Base(path), ".log"), data)
+		if errParse != nil {
+			if !info.IsDir() {
+				return nil, errParse
+			}
+			skipped = append(skipped, fmt.Errorf("%s: %w", filepath.Base(path), errParse))
+			continue
+		}
+		out, errSave := SaveFixture(outDir, fixture)
+		if errSave != nil {
+			return written, errSave
+		}
+		written = append(written, out)
+	}
+	return written, errors.Join(skipped...)
+}
+
+// ParseRequestLog builds a fixture from a request log written by the request logger. The
+// client format comes from the request URL and the provider format from the last upstream
+// attempt, whose response becomes the fixture response.
+func ParseRequestLog(name string, data []byte) (Fixture, error) {
+	var info, requestBody, apiRequest, apiResponse string
+	for _, section := range splitSections(string(data)) {
+		switch section.name {
+		case "REQUEST INFO":
+			info = section.body
+		case "REQUEST BODY":
+			requestBody = strings.TrimSpace(section.body)
+		case "API REQUEST":
+			apiRequest = section.body
+		case "API RESPONSE":
+			apiResponse = section.body
+		}
+	}
+
+	fixture := Fixture{Name: fixtureNameChars.ReplaceAllString(name, "_")}
+	url := headerValue(info, "URL")
+	if idx := strings.IndexByte(url, '?'); idx >= 0 {
+		url = url[:idx]
+	}
+	var ok bool
+	if fixture.From, fixture.Stream, ok = clientFormat(url); !ok {
+		return Fixture{}, fmt.Errorf("conformance: %s is not a translated endpoint", url)
+	}
+	if !gjson.Valid(requestBody) {
+		return Fixture{}, errors.New("conformance: request body is not JSON")
+	}
+	fixture.Request = json.RawMessage(requestBody)
+	if fixture.From != sdktranslator.FormatGemini && fixture.From != sdktranslator.FormatGeminiCLI {
+		fixture.Stream = gjson.Get(requestBody, "stream").Bool()
+	}
+	fixture.Model = gjson.Get(requestBody, "model").String()
+	if fixture.From == sdktranslator.FormatGemini {
+		fixture.Model = strings.SplitN(strings.TrimPrefix(url, "/v1beta/models/"), ":", 2)[0]
+	}
+
+	provider := ""
+	for _, field := range strings.Split(headerValue(apiRequest, "Auth"), ",") {
+		if value, found := strings.CutPrefix(strings.TrimSpace(field), "provider="); found {
+			provider = value
+		}
+	}
+	if provider == "" {
+		return Fixture{}, errors.New("conformance: log has no upstream request")
+	}
+	fixture.To = providerFormat(provider)
+
+	_, body, found := strings.Cut(apiResponse, "\nBody:\n")
+	if !found || strings.TrimSpace(body) == "" {
+		return Fixture{}, errors.New("conformance: log has no upstream response body")
+	}
+	if !fixture.Stream {
+		fixture.Response = []string{strings.TrimSpace(body)}
+		return fixture, nil
+	}
+	for _, line := range strings.Split(body, "\n\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			fixture.Response = append(fixture.Response, line)
+		}
+	}
+	return fixture, nil
+}
+
+// splitSections splits a request log on its section headers. Numbered sections keep the
+// last attempt, which is the one whose response reached the client.
+func splitSections(log string) []logSection {
+	var sections []logSection
+	var current *logSection
+	var body bytes.Buffer
+	flush := func() {
+		if current != nil {
+			current.body = body.String()
+			sections = append(sections, *current)
+		}
+		body.Reset()
+	}
+	for _, line := range strings.SplitAfter(log, "\n") {
+		if match := sectionHeader.FindStringSubmatch(strings.TrimRight(line, "\r\n")); match != nil {
+			flush()
+			current = &logSection{name: match[1]}
+			continue
+		}
+		body.WriteString(line)
+	}
+	flush()
+	return sections
+}
+
+func headerValue(section, key string) string {
+	for _, line := range strings.Split(section, "\n") {
+		if value, found := strings.CutPrefix(line, key+": "); found {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// clientFormat maps a proxy endpoint to the format its handler speaks.
+func clientFormat(path string) (sdktranslator.Format, bool, bool) {
+	switch {
+	case path == "/v1/chat/completions":
+		return sdktranslator.FormatOpenAI, false, true
+	case path == "/v1/responses":
+		return sdktranslator.FormatOpenAIResponse, false, true
+	case path == "/v1/messages":
+		return sdktranslator.FormatClaude, false, true
+	case strings.HasPrefix(path, "/v1beta/models/"):
+		if strings.HasSuffix(path, ":streamGenerateContent") {
+			return sdktranslator.FormatGemini, true, true
+		}
+		return sdktranslator.FormatGemini, false, strings.HasSuffix(path, ":generateContent")
+	case strings.HasPrefix(path, "/v1internal:"):
+		if strings.HasSuffix(path, ":streamGenerateContent") {
+			return sdktranslator.FormatGeminiCLI, true, true
+		}
+		return sdktranslator.FormatGeminiCLI, false, strings.HasSuffix(path, ":generateContent")
+	}
+	return "", false, false
+}
+
+// providerFormat maps an executor identifier to the format it sends upstream.
+func providerFormat(provider string) sdktranslator.Format {
+	switch provider {
+	case "codex":
+		return sdktranslator.FormatCodex
+	case "claude":
+		return sdktranslator.FormatClaude
+	case "gemini", "vertex", "aistudio":
+		return sdktranslator.FormatGemini
+	case "gemini-cli":
+		return sdktranslator.FormatGeminiCLI
+	case "antigravity":
+		return sdktranslator.FormatAntigravity
+	}
+	return sdktranslator.FormatOpenAI
+}
@@ -0,0 +1,116 @@
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"strings"
+
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+// Result is the output of replaying a fixture through one translation pair.
+type Result struct {
+	// Request is the client request translated to the provider format.
+	Request []byte
+	// Response is the upstream response translated back to the client format.
+	Response []string
+}
+
+// ReplayRequest translates the fixture request through pair. The fixture must have been
+// recorded in pair.From.
+func ReplayRequest(pair sdktranslator.Pair, fixture Fixture) []byte {
+	return sdktranslator.TranslateRequest(pair.From, pair.To, fixture.Model, bytes.Clone(fixture.Request), fixture.Stream)
+}
+
+// ReplayResponse translates the fixture's upstream response back through pair, feeding it
+// the way the provider executor does. The fixture must have been recorded in pair.To;
+// the original request is only available when it was also recorded in pair.From.
+func ReplayResponse(ctx context.Context, pair sdktranslator.Pair, fixture Fixture) []string {
+	var original, translated []byte
+	if pair.From == fixture.From {
+		original = bytes.Clone(fixture.Request)
+		translated = ReplayRequest(pair, fixture)
+	}
+	// Executors pass the client's alt parameter this way; fixtures replay the SSE form.
+	ctx = context.WithValue(ctx, "alt", "")
+	var param any
+	if !fixture.Stream {
+		body := nonStreamBody(pair.To, fixture.Response)
+		return []string{sdktranslator.TranslateNonStream(ctx, pair.To, pair.From, fixture.Model, original, translated, body, &param)}
+	}
+	var out []string
+	for _, chunk := range streamChunks(pair.To, fixture.Response) {
+		out = append(out, sdktranslator.TranslateStream(ctx, pair.To, pair.From, fixture.Model, original, translated, chunk, &param)...)
+	}
+	return out
+}
+
+// SummarizeUpstream extracts the content of the fixture's upstream response as the
+// executor sees it.
+func SummarizeUpstream(fixture Fixture) Summary {
+	if fixture.Stream {
+		return SummarizeResponse(fixture.To, fixture.Response, true)
+	}
+	return SummarizeResponse(fixture.To, []string{string(nonStreamBody(fixture.To, fixture.Response))}, false)
+}
+
+// Replay translates the fixture through its own recorded pair.
+func Replay(ctx context.Context, fixture Fixture) Result {
+	pair := sdktranslator.Pair{From: fixture.From, To: fixture.To}
+	return Result{Request: ReplayRequest(pair, fixture), Response: ReplayResponse(ctx, pair, fixture)}
+}
+
+// streamChunks mirrors how each provider executor hands upstream stream lines to the
+// translator: Gemini-family executors pass JSON payloads and finish with [DONE], the
+// others pass every raw line.
+func streamChunks(format sdktranslator.Format, lines []string) [][]byte {
+	var chunks [][]byte
+	switch format {
+	case sdktranslator.FormatGemini, sdktranslator.FormatAntigravity:
+		for _, line := range lines {
+			if payload := jsonPayload(line); payload != nil {
+				chunks = append(chunks, payload)
+			}
+		}
+		chunks = append(chunks, []byte("[DONE]"))
+	case sdktranslator.FormatGeminiCLI:
+		for _, line := range lines {
+			if strings.HasPrefix(line, "data:") {
+				chunks = append(chunks, []byte(line))
+			}
+		}
+		chunks = append(chunks, []byte("[DONE]"))
+	default:
+		for _, line := range lines {
+			chunks = append(chunks, []byte(line))
+		}
+	}
+	return chunks
+}
+
+// nonStreamBody returns the upstream body the executor translates for a non-streaming
+// exchange. Codex always streams, so its executor translates the response.completed event.
+func nonStreamBody(format sdktranslator.Format, lines []string) []byte {
+	if format == sdktranslator.FormatCodex {
+		for _, line := range lines {
+			payload := jsonPayload(line)
+			if gjson.GetBytes(payload, "type").String() == "response.completed" {
+				return payload
+			}
+		}
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// jsonPayload strips the SSE data prefix from line and returns the JSON object it carries.
+func jsonPayload(line string) []byte {
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, "data:") {
+		trimmed = strings.TrimSpace(strings.TrimPrefix(trimmed, "data:"))
+	}
+	if !strings.HasPrefix(trimmed, "{") {
+		return nil
+	}
+	return []byte(trimmed)
+}
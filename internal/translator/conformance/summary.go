@@ -0,0 +1,449 @@
+package conformance
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+// ToolCall is a tool invocation with its arguments in canonical JSON form.
+type ToolCall struct {
+	Name      string
+	Arguments string
+}
+
+// Summary is the format-independent content of a request or a response.
+type Summary struct {
+	// Texts lists the text segments in order; responses concatenate into one segment.
+	Texts []string
+	// Thinking reports whether any reasoning content is present.
+	Thinking bool
+	// ToolCalls lists the tool invocations in order.
+	ToolCalls []ToolCall
+	// ToolResults counts the tool results returned to the model.
+	ToolResults int
+	// Tools lists the declared tool names, sorted.
+	Tools []string
+	// Images counts image inputs.
+	Images int
+	// StopReason is the finish reason normalized to stop, length or tool_calls.
+	StopReason string
+	// InputTokens and OutputTokens are the reported usage, reasoning included.
+	InputTokens  int64
+	OutputTokens int64
+}
+
+// SummarizeRequest extracts the content of a request body in format.
+func SummarizeRequest(format sdktranslator.Format, raw []byte) Summary {
+	root := gjson.ParseBytes(raw)
+	var s Summary
+	switch format {
+	case sdktranslator.FormatOpenAI:
+		for _, msg := range root.Get("messages").Array() {
+			if msg.Get("role").String() == "tool" {
+				s.ToolResults++
+				continue
+			}
+			s.addOpenAIContent(msg.Get("content"))
+			for _, call := range msg.Get("tool_calls").Array() {
+				s.ToolCalls = append(s.ToolCalls, newToolCall(call.Get("function.name").String(), call.Get("function.arguments").String()))
+			}
+			if msg.Get("reasoning_content").String() != "" {
+				s.Thinking = true
+			}
+		}
+		for _, tool := range root.Get("tools").Array() {
+			s.addTool(tool.Get("function.name").String())
+		}
+	case sdktranslator.FormatOpenAIResponse, sdktranslator.FormatCodex:
+		s.addText(root.Get("instructions").String())
+		input := root.Get("input")
+		if input.Type == gjson.String {
+			s.addText(input.String())
+		}
+		for _, item := range input.Array() {
+			switch item.Get("type").String() {
+			case "function_call":
+				s.ToolCalls = append(s.ToolCalls, newToolCall(item.Get("name").String(), item.Get("arguments").String()))
+			case "function_call_output":
+				s.ToolResults++
+			case "reasoning":
+				s.Thinking = true
+			case "", "message":
+				s.addOpenAIContent(item.Get("content"))
+			}
+		}
+		for _, tool := range root.Get("tools").Array() {
+			s.addTool(tool.Get("name").String())
+		}
+	case sdktranslator.FormatClaude:
+		s.addClaudeContent(root.Get("system"))
+		for _, msg := range root.Get("messages").Array() {
+			s.addClaudeContent(msg.Get("content"))
+		}
+		for _, tool := range root.Get("tools").Array() {
+			s.addTool(tool.Get("name").String())
+		}
+	case sdktranslator.FormatGemini, sdktranslator.FormatGeminiCLI, sdktranslator.FormatAntigravity:
+		if format != sdktranslator.FormatGemini {
+			root = root.Get("request")
+		}
+		system := root.Get("systemInstruction")
+		if !system.Exists() {
+			system = root.Get("system_instruction")
+		}
+		s.addGeminiParts(system.Get("parts"))
+		for _, content := range root.Get("contents").Array() {
+			s.addGeminiParts(content.Get("parts"))
+		}
+		for _, tool := range root.Get("tools").Array() {
+			declarations := tool.Get("functionDeclarations")
+			if !declarations.Exists() {
+				declarations = tool.Get("function_declarations")
+			}
+			for _, declaration := range declarations.Array() {
+				s.addTool(declaration.Get("name").String())
+			}
+		}
+	}
+	sort.Strings(s.Tools)
+	return s
+}
+
+// SummarizeResponse extracts the content of a response in format. Stream responses are
+// given as the emitted chunks, each holding one or more SSE lines.
+func SummarizeResponse(format sdktranslator.Format, chunks []string, stream bool) Summary {
+	var events []gjson.Result
+	for _, chunk := range chunks {
+		if !stream {
+			events = append(events, gjson.Parse(chunk))
+			continue
+		}
+		for _, line := range strings.Split(chunk, "\n") {
+			if payload := jsonPayload(line); payload != nil {
+				events = append(events, gjson.ParseBytes(payload))
+			}
+		}
+	}
+
+	r := responseSummary{calls: map[int64]*ToolCall{}, args: map[int64]*strings.Builder{}}
+	for _, event := range events {
+		switch format {
+		case sdktranslator.FormatOpenAI:
+			r.openAIEvent(event)
+		case sdktranslator.FormatOpenAIResponse, sdktranslator.FormatCodex:
+			r.responsesEvent(event, stream)
+		case sdktranslator.FormatClaude:
+			r.claudeEvent(event)
+		case sdktranslator.FormatGemini:
+			r.geminiEvent(event)
+		case sdktranslator.FormatGeminiCLI, sdktranslator.FormatAntigravity:
+			r.geminiEvent(event.Get("response"))
+		}
+	}
+	return r.summary()
+}
+
+func (s *Summary) addText(text string) {
+	if strings.TrimSpace(text) != "" {
+		s.Texts = append(s.Texts, text)
+	}
+}
+
+func (s *Summary) addTool(name string) {
+	if name != "" {
+		s.Tools = append(s.Tools, name)
+	}
+}
+
+func (s *Summary) addOpenAIContent(content gjson.Result) {
+	if content.Type == gjson.String {
+		s.addText(content.String())
+		return
+	}
+	for _, part := range content.Array() {
+		switch part.Get("type").String() {
+		case "text", "input_text", "output_text":
+			s.addText(part.Get("text").String())
+		case "image_url", "input_image":
+			s.Images++
+		}
+	}
+}
+
+func (s *Summary) addClaudeContent(content gjson.Result) {
+	if content.Type == gjson.String {
+		s.addText(content.String())
+		return
+	}
+	for _, block := range content.Array() {
+		switch block.Get("type").String() {
+		case "text":
+			s.addText(block.Get("text").String())
+		case "image":
+			s.Images++
+		case "tool_use":
+			s.ToolCalls = append(s.ToolCalls, newToolCall(block.Get("name").String(), block.Get("input").Raw))
+		case "tool_result":
+			s.ToolResults++
+		case "thinking", "redacted_thinking":
+			s.Thinking = true
+		}
+	}
+}
+
+func (s *Summary) addGeminiParts(parts gjson.Result) {
+	for _, part := range parts.Array() {
+		switch {
+		case part.Get("functionCall").Exists():
+			s.ToolCalls = append(s.ToolCalls, newToolCall(part.Get("functionCall.name").String(), part.Get("functionCall.args").Raw))
+		case part.Get("functionResponse").Exists():
+			s.ToolResults++
+		case part.Get("inlineData").Exists(), part.Get("inline_data").Exists(), part.Get("fileData").Exists():
+			s.Images++
+		case part.Get("thought").Bool():
+			s.Thinking = true
+		case part.Get("text").Exists():
+			s.addText(part.Get("text").String())
+		}
+	}
+}
+
+// responseSummary accumulates response events; tool calls are keyed by their stream index
+// so argument fragments join up.
+type responseSummary struct {
+	text     strings.Builder
+	thinking bool
+	order    []int64
+	calls    map[int64]*ToolCall
+	args     map[int64]*strings.Builder
+	stop     string
+	input    int64
+	output   int64
+}
+
+func (r *responseSummary) call(index int64, name string) {
+	if _, ok := r.calls[index]; !ok {
+		r.order = append(r.order, index)
+		r.calls[index] = &ToolCall{}
+		r.args[index] = &strings.Builder{}
+	}
+	if name != "" {
+		r.calls[index].Name = name
+	}
+}
+
+func (r *responseSummary) openAIEvent(event gjson.Result) {
+	choice := event.Get("choices.0")
+	message := choice.Get("delta")
+	if !message.Exists() {
+		message = choice.Get("message")
+	}
+	r.text.WriteString(message.Get("content").String())
+	if message.Get("reasoning_content").String() != "" || message.Get("reasoning").Exists() {
+		r.thinking = true
+	}
+	for i, call := range message.Get("tool_calls").Array() {
+		index := int64(i)
+		if call.Get("index").Exists() {
+			index = call.Get("index").Int()
+		}
+		r.call(index, call.Get("function.name").String())
+		r.args[index].WriteString(call.Get("function.arguments").String())
+	}
+	switch reason := choice.Get("finish_reason").String(); reason {
+	case "":
+	case "tool_calls", "function_call":
+		r.stop = "tool_calls"
+	default:
+		r.stop = reason
+	}
+	if usage := event.Get("usage"); usage.IsObject() {
+		r.input, r.output = usage.Get("prompt_tokens").Int(), usage.Get("completion_tokens").Int()
+	}
+}
+
+func (r *responseSummary) responsesEvent(event gjson.Result, stream bool) {
+	response := event
+	switch event.Get("type").String() {
+	case "response.output_text.delta":
+		r.text.WriteString(event.Get("delta").String())
+		return
+	case "response.reasoning_summary_text.delta", "response.reasoning_text.delta":
+		r.thinking = true
+		return
+	case "response.output_item.done":
+		if stream {
+			r.responsesItem(event.Get("item"), false)
+		}
+		return
+	case "response.completed", "response.incomplete":
+		response = event.Get("response")
+	case "":
+		if event.Get("object").String() != "response" {
+			return
+		}
+	default:
+		return
+	}
+	if !stream {
+		for _, item := range response.Get("output").Array() {
+			r.responsesItem(item, true)
+		}
+	}
+	r.stop = "stop"
+	if response.Get("status").String() == "incomplete" && response.Get("incomplete_details.reason").String() == "max_output_tokens" {
+		r.stop = "length"
+	}
+	if usage := response.Get("usage"); usage.IsObject() {
+		r.input, r.output = usage.Get("input_tokens").Int(), usage.Get("output_tokens").Int()
+	}
+}
+
+func (r *responseSummary) responsesItem(item gjson.Result, withText bool) {
+	switch item.Get("type").String() {
+	case "function_call":
+		index := int64(len(r.order))
+		r.call(index, item.Get("name").String())
+		r.args[index].WriteString(item.Get("arguments").String())
+	case "reasoning":
+		if len(item.Get("summary").Array()) > 0 || item.Get("encrypted_content").String() != "" || len(item.Get("content").Array()) > 0 {
+			r.thinking = true
+		}
+	case "message":
+		if withText {
+			for _, part := range item.Get("content").Array() {
+				r.text.WriteString(part.Get("text").String())
+			}
+		}
+	}
+}
+
+func (r *responseSummary) claudeEvent(event gjson.Result) {
+	switch event.Get("type").String() {
+	case "message":
+		for i, block := range event.Get("content").Array() {
+			r.claudeBlock(int64(i), block)
+		}
+		r.claudeStop(event.Get("stop_reason").String())
+		r.input, r.output = event.Get("usage.input_tokens").Int(), event.Get("usage.output_tokens").Int()
+	case "message_start":
+		r.input = event.Get("message.usage.input_tokens").Int()
+	case "content_block_start":
+		r.claudeBlock(event.Get("index").Int(), event.Get("content_block"))
+	case "content_block_delta":
+		delta := event.Get("delta")
+		switch delta.Get("type").String() {
+		case "text_delta":
+			r.text.WriteString(delta.Get("text").String())
+		case "thinking_delta":
+			r.thinking = true
+		case "input_json_delta":
+			index := event.Get("index").Int()
+			r.call(index, "")
+			r.args[index].WriteString(delta.Get("partial_json").String())
+		}
+	case "message_delta":
+		r.claudeStop(event.Get("delta.stop_reason").String())
+		if input := event.Get("usage.input_tokens"); input.Exists() && input.Int() > 0 {
+			r.input = input.Int()
+		}
+		if output := event.Get("usage.output_tokens"); output.Exists() {
+			r.output = output.Int()
+		}
+	}
+}
+
+func (r *responseSummary) claudeBlock(index int64, block gjson.Result) {
+	switch block.Get("type").String() {
+	case "text":
+		r.text.WriteString(block.Get("text").String())
+	case "thinking", "redacted_thinking":
+		r.thinking = true
+	case "tool_use":
+		r.call(index, block.Get("name").String())
+		if input := block.Get("input"); input.IsObject() && len(input.Map()) > 0 {
+			r.args[index].WriteString(input.Raw)
+		}
+	}
+}
+
+func (r *responseSummary) claudeStop(reason string) {
+	switch reason {
+	case "":
+	case "end_turn", "stop_sequence":
+		r.stop = "stop"
+	case "max_tokens":
+		r.stop = "length"
+	case "tool_use":
+		r.stop = "tool_calls"
+	default:
+		r.stop = reason
+	}
+}
+
+func (r *responseSummary) geminiEvent(event gjson.Result) {
+	candidate := event.Get("candidates.0")
+	for _, part := range candidate.Get("content.parts").Array() {
+		switch {
+		case part.Get("functionCall").Exists():
+			index := int64(len(r.order))
+			r.call(index, part.Get("functionCall.name").String())
+			r.args[index].WriteString(part.Get("functionCall.args").Raw)
+		case part.Get("thought").Bool():
+			r.thinking = true
+		default:
+			r.text.WriteString(part.Get("text").String())
+		}
+	}
+	switch reason := candidate.Get("finishReason").String(); reason {
+	case "":
+	case "STOP":
+		r.stop = "stop"
+	case "MAX_TOKENS":
+		r.stop = "length"
+	default:
+		r.stop = strings.ToLower(reason)
+	}
+	if usage := event.Get("usageMetadata"); usage.IsObject() {
+		r.input = usage.Get("promptTokenCount").Int()
+		r.output = usage.Get("candidatesTokenCount").Int() + usage.Get("thoughtsTokenCount").Int()
+	}
+}
+
+func (r *responseSummary) summary() Summary {
+	s := Summary{Thinking: r.thinking, StopReason: r.stop, InputTokens: r.input, OutputTokens: r.output}
+	text := r.text.String()
+	// Chat completions clients may receive reasoning inline as a leading <think> block.
+	if rest, found := strings.CutPrefix(text, "<think>"); found {
+		if _, answer, closed := strings.Cut(rest, "</think>"); closed {
+			s.Thinking, text = true, answer
+		}
+	}
+	s.addText(text)
+	for _, index := range r.order {
+		s.ToolCalls = append(s.ToolCalls, newToolCall(r.calls[index].Name, r.args[index].String()))
+	}
+	if len(s.ToolCalls) > 0 && s.StopReason == "stop" {
+		s.StopReason = "tool_calls"
+	}
+	return s
+}
+
+// newToolCall canonicalizes the arguments so equivalent JSON compares equal.
+func newToolCall(name, arguments string) ToolCall {
+	arguments = strings.TrimSpace(arguments)
+	if arguments == "" {
+		arguments = "{}"
+	}
+	var decoded any
+	if err := json.Unmarshal([]byte(arguments), &decoded); err == nil {
+		if canonical, errMarshal := json.Marshal(decoded); errMarshal == nil {
+			arguments = string(canonical)
+		}
+	}
+	return ToolCall{Name: name, Arguments: arguments}
+}
@@ -28,12 +28,11 @@ var dataTag = []byte("data:")
 // Returns:
 //   - []string: A slice of strings, each containing a Gemini CLI-compatible JSON response.
 func ConvertGeminiResponseToGeminiCLI(_ context.Context, _ string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, _ *any) []string {
-	if !bytes.HasPrefix(rawJSON, dataTag) {
-		return []string{}
+	// The Gemini executor hands over bare JSON payloads, while Vertex passes raw SSE lines.
+	if bytes.HasPrefix(rawJSON, dataTag) {
+		rawJSON = bytes.TrimSpace(rawJSON[5:])
 	}
-	rawJSON = bytes.TrimSpace(rawJSON[5:])
-
-	if bytes.Equal(rawJSON, []byte("[DONE]")) {
+	if len(rawJSON) == 0 || rawJSON[0] != '{' {
 		return []string{}
 	}
 	json := `{"response": {}}`
@@ -0,0 +1,25 @@
+package geminiCLI
+
+import (
+	"context"
+	"testing"
+)
+
+func TestConvertGeminiResponseToGeminiCLI_AcceptsBareAndSSEPayloads(t *testing.T) {
+	payload := `{"candidates":[{"content":{"parts":[{"text":"hi"}]}}]}`
+	want := `{"response": ` + payload + `}`
+	for name, line := range map[string]string{
+		"bare json": payload,
+		"sse line":  "data: " + payload,
+	} {
+		got := ConvertGeminiResponseToGeminiCLI(context.Background(), "", nil, nil, []byte(line), nil)
+		if len(got) != 1 || got[0] != want {
+			t.Fatalf("%s: got %q, want %q", name, got, want)
+		}
+	}
+	for _, line := range []string{"[DONE]", "data: [DONE]", "", "event: message"} {
+		if got := ConvertGeminiResponseToGeminiCLI(context.Background(), "", nil, nil, []byte(line), nil); len(got) != 0 {
+			t.Fatalf("%q: got %q, want no output", line, got)
+		}
+	}
+}
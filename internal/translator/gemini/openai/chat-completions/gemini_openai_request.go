@@ -99,6 +99,11 @@ func ConvertOpenAIRequestToGemini(modelName string, inputRawJSON []byte, _ bool)
 		out, _ = sjson.SetBytes(out, "generationConfig.topK", tkr.Num)
 	}
 
+	// Multiple choices -> candidateCount
+	if nr := gjson.GetBytes(rawJSON, "n"); nr.Exists() && nr.Type == gjson.Number && nr.Int() > 1 {
+		out, _ = sjson.SetBytes(out, "generationConfig.candidateCount", nr.Int())
+	}
+
 	// Map OpenAI modalities -> Gemini generationConfig.responseModalities
 	// e.g. "modalities": ["image", "text"] -> ["IMAGE", "TEXT"]
 	if mods := gjson.GetBytes(rawJSON, "modalities"); mods.Exists() && mods.IsArray() {
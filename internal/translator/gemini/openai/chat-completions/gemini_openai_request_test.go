@@ -0,0 +1,18 @@
+package chat_completions
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertOpenAIRequestToGemini_MapsChoicesToCandidateCount(t *testing.T) {
+	out := ConvertOpenAIRequestToGemini("gemini-2.5-pro", []byte(`{"model":"gemini-2.5-pro","n":3,"messages":[{"role":"user","content":"hi"}]}`), false)
+	if got := gjson.GetBytes(out, "generationConfig.candidateCount").Int(); got != 3 {
+		t.Fatalf("candidateCount = %d, want 3 in %s", got, out)
+	}
+	out = ConvertOpenAIRequestToGemini("gemini-2.5-pro", []byte(`{"model":"gemini-2.5-pro","n":1,"messages":[{"role":"user","content":"hi"}]}`), false)
+	if gjson.GetBytes(out, "generationConfig.candidateCount").Exists() {
+		t.Fatalf("n=1 set candidateCount: %s", out)
+	}
+}
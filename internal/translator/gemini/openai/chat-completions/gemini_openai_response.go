@@ -21,7 +21,8 @@ import (
 // convertGeminiResponseToOpenAIChatParams holds parameters for response conversion.
 type convertGeminiResponseToOpenAIChatParams struct {
 	UnixTimestamp int64
-	FunctionIndex int
+	// FunctionIndex tracks the next tool call index per candidate.
+	FunctionIndex map[int64]int
 }
 
 // functionCallIDCounter provides a process-wide unique counter for function call identifiers.
@@ -32,6 +33,8 @@ var functionCallIDCounter uint64
 // It processes various Gemini event types and transforms them into OpenAI-compatible JSON responses.
 // The function handles text content, tool calls, reasoning content, and usage metadata, outputting
 // responses that match the OpenAI API format. It supports incremental updates for streaming responses.
+// When the request asked for several candidates, each candidate becomes its own chunk whose
+// choice index matches the candidate index.
 //
 // Parameters:
 //   - ctx: The context for the request, used for cancellation and timeout handling
@@ -45,9 +48,10 @@ func ConvertGeminiResponseToOpenAI(_ context.Context, _ string, originalRequestR
 	if *param == nil {
 		*param = &convertGeminiResponseToOpenAIChatParams{
 			UnixTimestamp: 0,
-			FunctionIndex: 0,
+			FunctionIndex: map[int64]int{},
 		}
 	}
+	params := (*param).(*convertGeminiResponseToOpenAIChatParams)
 
 	if bytes.HasPrefix(rawJSON, []byte("data:")) {
 		rawJSON = bytes.TrimSpace(rawJSON[5:])
@@ -69,11 +73,11 @@ func ConvertGeminiResponseToOpenAI(_ context.Context, _ string, originalRequestR
 	if createTimeResult := gjson.GetBytes(rawJSON, "createTime"); createTimeResult.Exists() {
 		t, err := time.Parse(time.RFC3339Nano, createTimeResult.String())
 		if err == nil {
-			(*param).(*convertGeminiResponseToOpenAIChatParams).UnixTimestamp = t.Unix()
+			params.UnixTimestamp = t.Unix()
 		}
-		template, _ = sjson.Set(template, "created", (*param).(*convertGeminiResponseToOpenAIChatParams).UnixTimestamp)
+		template, _ = sjson.Set(template, "created", params.UnixTimestamp)
 	} else {
-		template, _ = sjson.Set(template, "created", (*param).(*convertGeminiResponseToOpenAIChatParams).UnixTimestamp)
+		template, _ = sjson.Set(template, "created", params.UnixTimestamp)
 	}
 
 	// Extract and set the response ID.
@@ -81,12 +85,6 @@ func ConvertGeminiResponseToOpenAI(_ context.Context, _ string, originalRequestR
 		template, _ = sjson.Set(template, "id", responseIDResult.String())
 	}
 
-	// Extract and set the finish reason.
-	if finishReasonResult := gjson.GetBytes(rawJSON, "candidates.0.finishReason"); finishReasonResult.Exists() {
-		template, _ = sjson.Set(template, "choices.0.finish_reason", strings.ToLower(finishReasonResult.String()))
-		template, _ = sjson.Set(template, "choices.0.native_finish_reason", strings.ToLower(finishReasonResult.String()))
-	}
-
 	// Extract and set usage metadata (token counts).
 	if usageResult := gjson.GetBytes(rawJSON, "usageMetadata"); usageResult.Exists() {
 		cachedTokenCount := usageResult.Get("cachedContentTokenCount").Int()
@@ -112,96 +110,122 @@ func ConvertGeminiResponseToOpenAI(_ context.Context, _ string, originalRequestR
 		}
 	}
 
-	// Process the main content part of the response.
-	partsResult := gjson.GetBytes(rawJSON, "candidates.0.content.parts")
-	hasFunctionCall := false
-	if partsResult.IsArray() {
-		partResults := partsResult.Array()
-		for i := 0; i < len(partResults); i++ {
-			partResult := partResults[i]
-			partTextResult := partResult.Get("text")
-			functionCallResult := partResult.Get("functionCall")
-			inlineDataResult := partResult.Get("inlineData")
-			if !inlineDataResult.Exists() {
-				inlineDataResult = partResult.Get("inline_data")
-			}
-			thoughtSignatureResult := partResult.Get("thoughtSignature")
-			if !thoughtSignatureResult.Exists() {
-				thoughtSignatureResult = partResult.Get("thought_signature")
-			}
-
-			hasThoughtSignature := thoughtSignatureResult.Exists() && thoughtSignatureResult.String() != ""
-			hasContentPayload := partTextResult.Exists() || functionCallResult.Exists() || inlineDataResult.Exists()
+	candidates := gjson.GetBytes(rawJSON, "candidates").Array()
+	if len(candidates) == 0 {
+		// Usage-only chunks still produce a single choice.
+		candidates = []gjson.Result{{}}
+	}
+	out := make([]string, 0, len(candidates))
+	for candidatePos, candidate := range candidates {
+		chunk := template
+		if candidatePos > 0 {
+			// Usage covers every candidate, so only the first chunk reports it.
+			chunk, _ = sjson.Delete(chunk, "usage")
+		}
+		candidateIndex := int64(candidatePos)
+		if indexResult := candidate.Get("index"); indexResult.Exists() {
+			candidateIndex = indexResult.Int()
+		}
+		chunk, _ = sjson.Set(chunk, "choices.0.index", candidateIndex)
 
-			// Skip pure thoughtSignature parts but keep any actual payload in the same part.
-			if hasThoughtSignature && !hasContentPayload {
-				continue
-			}
+		// Extract and set the finish reason.
+		if finishReasonResult := candidate.Get("finishReason"); finishReasonResult.Exists() {
+			chunk, _ = sjson.Set(chunk, "choices.0.finish_reason", strings.ToLower(finishReasonResult.String()))
+			chunk, _ = sjson.Set(chunk, "choices.0.native_finish_reason", strings.ToLower(finishReasonResult.String()))
+		}
 
-			if partTextResult.Exists() {
-				text := partTextResult.String()
-				// Handle text content, distinguishing between regular content and reasoning/thoughts.
-				if partResult.Get("thought").Bool() {
-					template, _ = sjson.Set(template, "choices.0.delta.reasoning_content", text)
-				} else {
-					template, _ = sjson.Set(template, "choices.0.delta.content", text)
+		// Process the main content part of the response.
+		partsResult := candidate.Get("content.parts")
+		hasFunctionCall := false
+		if partsResult.IsArray() {
+			partResults := partsResult.Array()
+			for i := 0; i < len(partResults); i++ {
+				partResult := partResults[i]
+				partTextResult := partResult.Get("text")
+				functionCallResult := partResult.Get("functionCall")
+				inlineDataResult := partResult.Get("inlineData")
+				if !inlineDataResult.Exists() {
+					inlineDataResult = partResult.Get("inline_data")
 				}
-				template, _ = sjson.Set(template, "choices.0.delta.role", "assistant")
-			} else if functionCallResult.Exists() {
-				// Handle function call content.
-				hasFunctionCall = true
-				toolCallsResult := gjson.Get(template, "choices.0.delta.tool_calls")
-				functionCallIndex := (*param).(*convertGeminiResponseToOpenAIChatParams).FunctionIndex
-				(*param).(*convertGeminiResponseToOpenAIChatParams).FunctionIndex++
-				if toolCallsResult.Exists() && toolCallsResult.IsArray() {
-					functionCallIndex = len(toolCallsResult.Array())
-				} else {
-					template, _ = sjson.SetRaw(template, "choices.0.delta.tool_calls", `[]`)
+				thoughtSignatureResult := partResult.Get("thoughtSignature")
+				if !thoughtSignatureResult.Exists() {
+					thoughtSignatureResult = partResult.Get("thought_signature")
 				}
 
-				functionCallTemplate := `{"id": "","index": 0,"type": "function","function": {"name": "","arguments": ""}}`
-				fcName := functionCallResult.Get("name").String()
-				functionCallTemplate, _ = sjson.Set(functionCallTemplate, "id", fmt.Sprintf("%s-%d-%d", fcName, time.Now().UnixNano(), atomic.AddUint64(&functionCallIDCounter, 1)))
-				functionCallTemplate, _ = sjson.Set(functionCallTemplate, "index", functionCallIndex)
-				functionCallTemplate, _ = sjson.Set(functionCallTemplate, "function.name", fcName)
-				if fcArgsResult := functionCallResult.Get("args"); fcArgsResult.Exists() {
-					functionCallTemplate, _ = sjson.Set(functionCallTemplate, "function.arguments", fcArgsResult.Raw)
-				}
-				template, _ = sjson.Set(template, "choices.0.delta.role", "assistant")
-				template, _ = sjson.SetRaw(template, "choices.0.delta.tool_calls.-1", functionCallTemplate)
-			} else if inlineDataResult.Exists() {
-				data := inlineDataResult.Get("data").String()
-				if data == "" {
+				hasThoughtSignature := thoughtSignatureResult.Exists() && thoughtSignatureResult.String() != ""
+				hasContentPayload := partTextResult.Exists() || functionCallResult.Exists() || inlineDataResult.Exists()
+
+				// Skip pure thoughtSignature parts but keep any actual payload in the same part.
+				if hasThoughtSignature && !hasContentPayload {
 					continue
 				}
-				mimeType := inlineDataResult.Get("mimeType").String()
-				if mimeType == "" {
-					mimeType = inlineDataResult.Get("mime_type").String()
-				}
-				if mimeType == "" {
-					mimeType = "image/png"
-				}
-				imageURL := fmt.Sprintf("data:%s;base64,%s", mimeType, data)
-				imagesResult := gjson.Get(template, "choices.0.delta.images")
-				if !imagesResult.Exists() || !imagesResult.IsArray() {
-					template, _ = sjson.SetRaw(template, "choices.0.delta.images", `[]`)
+
+				if partTextResult.Exists() {
+					text := partTextResult.String()
+					// Handle text content, distinguishing between regular content and reasoning/thoughts.
+					if partResult.Get("thought").Bool() {
+						chunk, _ = sjson.Set(chunk, "choices.0.delta.reasoning_content", text)
+					} else {
+						chunk, _ = sjson.Set(chunk, "choices.0.delta.content", text)
+					}
+					chunk, _ = sjson.Set(chunk, "choices.0.delta.role", "assistant")
+				} else if functionCallResult.Exists() {
+					// Handle function call content.
+					hasFunctionCall = true
+					toolCallsResult := gjson.Get(chunk, "choices.0.delta.tool_calls")
+					functionCallIndex := params.FunctionIndex[candidateIndex]
+					params.FunctionIndex[candidateIndex]++
+					if toolCallsResult.Exists() && toolCallsResult.IsArray() {
+						functionCallIndex = len(toolCallsResult.Array())
+					} else {
+						chunk, _ = sjson.SetRaw(chunk, "choices.0.delta.tool_calls", `[]`)
+					}
+
+					functionCallTemplate := `{"id": "","index": 0,"type": "function","function": {"name": "","arguments": ""}}`
+					fcName := functionCallResult.Get("name").String()
+					functionCallTemplate, _ = sjson.Set(functionCallTemplate, "id", fmt.Sprintf("%s-%d-%d", fcName, time.Now().UnixNano(), atomic.AddUint64(&functionCallIDCounter, 1)))
+					functionCallTemplate, _ = sjson.Set(functionCallTemplate, "index", functionCallIndex)
+					functionCallTemplate, _ = sjson.Set(functionCallTemplate, "function.name", fcName)
+					if fcArgsResult := functionCallResult.Get("args"); fcArgsResult.Exists() {
+						functionCallTemplate, _ = sjson.Set(functionCallTemplate, "function.arguments", fcArgsResult.Raw)
+					}
+					chunk, _ = sjson.Set(chunk, "choices.0.delta.role", "assistant")
+					chunk, _ = sjson.SetRaw(chunk, "choices.0.delta.tool_calls.-1", functionCallTemplate)
+				} else if inlineDataResult.Exists() {
+					data := inlineDataResult.Get("data").String()
+					if data == "" {
+						continue
+					}
+					mimeType := inlineDataResult.Get("mimeType").String()
+					if mimeType == "" {
+						mimeType = inlineDataResult.Get("mime_type").String()
+					}
+					if mimeType == "" {
+						mimeType = "image/png"
+					}
+					imageURL := fmt.Sprintf("data:%s;base64,%s", mimeType, data)
+					imagesResult := gjson.Get(chunk, "choices.0.delta.images")
+					if !imagesResult.Exists() || !imagesResult.IsArray() {
+						chunk, _ = sjson.SetRaw(chunk, "choices.0.delta.images", `[]`)
+					}
+					imageIndex := len(gjson.Get(chunk, "choices.0.delta.images").Array())
+					imagePayload := `{"type":"image_url","image_url":{"url":""}}`
+					imagePayload, _ = sjson.Set(imagePayload, "index", imageIndex)
+					imagePayload, _ = sjson.Set(imagePayload, "image_url.url", imageURL)
+					chunk, _ = sjson.Set(chunk, "choices.0.delta.role", "assistant")
+					chunk, _ = sjson.SetRaw(chunk, "choices.0.delta.images.-1", imagePayload)
 				}
-				imageIndex := len(gjson.Get(template, "choices.0.delta.images").Array())
-				imagePayload := `{"type":"image_url","image_url":{"url":""}}`
-				imagePayload, _ = sjson.Set(imagePayload, "index", imageIndex)
-				imagePayload, _ = sjson.Set(imagePayload, "image_url.url", imageURL)
-				template, _ = sjson.Set(template, "choices.0.delta.role", "assistant")
-				template, _ = sjson.SetRaw(template, "choices.0.delta.images.-1", imagePayload)
 			}
 		}
-	}
 
-	if hasFunctionCall {
-		template, _ = sjson.Set(template, "choices.0.finish_reason", "tool_calls")
-		template, _ = sjson.Set(template, "choices.0.native_finish_reason", "tool_calls")
+		if hasFunctionCall {
+			chunk, _ = sjson.Set(chunk, "choices.0.finish_reason", "tool_calls")
+			chunk, _ = sjson.Set(chunk, "choices.0.native_finish_reason", "tool_calls")
+		}
+		out = append(out, chunk)
 	}
 
-	return []string{template}
+	return out
 }
 
 // ConvertGeminiResponseToOpenAINonStream converts a non-streaming Gemini response to a non-streaming OpenAI response.
@@ -219,7 +243,7 @@ func ConvertGeminiResponseToOpenAI(_ context.Context, _ string, originalRequestR
 //   - string: An OpenAI-compatible JSON response containing all message content and metadata
 func ConvertGeminiResponseToOpenAINonStream(_ context.Context, _ string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, _ *any) string {
 	var unixTimestamp int64
-	template := `{"id":"","object":"chat.completion","created":123456,"model":"model","choices":[{"index":0,"message":{"role":"assistant","content":null,"reasoning_content":null,"tool_calls":null},"finish_reason":null,"native_finish_reason":null}]}`
+	template := `{"id":"","object":"chat.completion","created":123456,"model":"model","choices":[]}`
 	if modelVersionResult := gjson.GetBytes(rawJSON, "modelVersion"); modelVersionResult.Exists() {
 		template, _ = sjson.Set(template, "model", modelVersionResult.String())
 	}
@@ -238,11 +262,6 @@ func ConvertGeminiResponseToOpenAINonStream(_ context.Context, _ string, origina
 		template, _ = sjson.Set(template, "id", responseIDResult.String())
 	}
 
-	if finishReasonResult := gjson.GetBytes(rawJSON, "candidates.0.finishReason"); finishReasonResult.Exists() {
-		template, _ = sjson.Set(template, "choices.0.finish_reason", strings.ToLower(finishReasonResult.String()))
-		template, _ = sjson.Set(template, "choices.0.native_finish_reason", strings.ToLower(finishReasonResult.String()))
-	}
-
 	if usageResult := gjson.GetBytes(rawJSON, "usageMetadata"); usageResult.Exists() {
 		if candidatesTokenCountResult := usageResult.Get("candidatesTokenCount"); candidatesTokenCountResult.Exists() {
 			template, _ = sjson.Set(template, "usage.completion_tokens", candidatesTokenCountResult.Int())
@@ -267,74 +286,93 @@ func ConvertGeminiResponseToOpenAINonStream(_ context.Context, _ string, origina
 		}
 	}
 
-	// Process the main content part of the response.
-	partsResult := gjson.GetBytes(rawJSON, "candidates.0.content.parts")
-	hasFunctionCall := false
-	if partsResult.IsArray() {
-		partsResults := partsResult.Array()
-		for i := 0; i < len(partsResults); i++ {
-			partResult := partsResults[i]
-			partTextResult := partResult.Get("text")
-			functionCallResult := partResult.Get("functionCall")
-			inlineDataResult := partResult.Get("inlineData")
-			if !inlineDataResult.Exists() {
-				inlineDataResult = partResult.Get("inline_data")
-			}
+	candidates := gjson.GetBytes(rawJSON, "candidates").Array()
+	if len(candidates) == 0 {
+		candidates = []gjson.Result{{}}
+	}
+	for candidatePos, candidate := range candidates {
+		choice := `{"index":0,"message":{"role":"assistant","content":null,"reasoning_content":null,"tool_calls":null},"finish_reason":null,"native_finish_reason":null}`
+		candidateIndex := int64(candidatePos)
+		if indexResult := candidate.Get("index"); indexResult.Exists() {
+			candidateIndex = indexResult.Int()
+		}
+		choice, _ = sjson.Set(choice, "index", candidateIndex)
 
-			if partTextResult.Exists() {
-				// Append text content, distinguishing between regular content and reasoning.
-				if partResult.Get("thought").Bool() {
-					template, _ = sjson.Set(template, "choices.0.message.reasoning_content", partTextResult.String())
-				} else {
-					template, _ = sjson.Set(template, "choices.0.message.content", partTextResult.String())
-				}
-				template, _ = sjson.Set(template, "choices.0.message.role", "assistant")
-			} else if functionCallResult.Exists() {
-				// Append function call content to the tool_calls array.
-				hasFunctionCall = true
-				toolCallsResult := gjson.Get(template, "choices.0.message.tool_calls")
-				if !toolCallsResult.Exists() || !toolCallsResult.IsArray() {
-					template, _ = sjson.SetRaw(template, "choices.0.message.tool_calls", `[]`)
-				}
-				functionCallItemTemplate := `{"id": "","type": "function","function": {"name": "","arguments": ""}}`
-				fcName := functionCallResult.Get("name").String()
-				functionCallItemTemplate, _ = sjson.Set(functionCallItemTemplate, "id", fmt.Sprintf("%s-%d-%d", fcName, time.Now().UnixNano(), atomic.AddUint64(&functionCallIDCounter, 1)))
-				functionCallItemTemplate, _ = sjson.Set(functionCallItemTemplate, "function.name", fcName)
-				if fcArgsResult := functionCallResult.Get("args"); fcArgsResult.Exists() {
-					functionCallItemTemplate, _ = sjson.Set(functionCallItemTemplate, "function.arguments", fcArgsResult.Raw)
-				}
-				template, _ = sjson.Set(template, "choices.0.message.role", "assistant")
-				template, _ = sjson.SetRaw(template, "choices.0.message.tool_calls.-1", functionCallItemTemplate)
-			} else if inlineDataResult.Exists() {
-				data := inlineDataResult.Get("data").String()
-				if data == "" {
-					continue
-				}
-				mimeType := inlineDataResult.Get("mimeType").String()
-				if mimeType == "" {
-					mimeType = inlineDataResult.Get("mime_type").String()
-				}
-				if mimeType == "" {
-					mimeType = "image/png"
+		if finishReasonResult := candidate.Get("finishReason"); finishReasonResult.Exists() {
+			choice, _ = sjson.Set(choice, "finish_reason", strings.ToLower(finishReasonResult.String()))
+			choice, _ = sjson.Set(choice, "native_finish_reason", strings.ToLower(finishReasonResult.String()))
+		}
+
+		// Process the main content part of the response.
+		partsResult := candidate.Get("content.parts")
+		hasFunctionCall := false
+		if partsResult.IsArray() {
+			partsResults := partsResult.Array()
+			for i := 0; i < len(partsResults); i++ {
+				partResult := partsResults[i]
+				partTextResult := partResult.Get("text")
+				functionCallResult := partResult.Get("functionCall")
+				inlineDataResult := partResult.Get("inlineData")
+				if !inlineDataResult.Exists() {
+					inlineDataResult = partResult.Get("inline_data")
 				}
-				imageURL := fmt.Sprintf("data:%s;base64,%s", mimeType, data)
-				imagesResult := gjson.Get(template, "choices.0.message.images")
-				if !imagesResult.Exists() || !imagesResult.IsArray() {
-					template, _ = sjson.SetRaw(template, "choices.0.message.images", `[]`)
+
+				if partTextResult.Exists() {
+					// Append text content, distinguishing between regular content and reasoning.
+					if partResult.Get("thought").Bool() {
+						choice, _ = sjson.Set(choice, "message.reasoning_content", partTextResult.String())
+					} else {
+						choice, _ = sjson.Set(choice, "message.content", partTextResult.String())
+					}
+					choice, _ = sjson.Set(choice, "message.role", "assistant")
+				} else if functionCallResult.Exists() {
+					// Append function call content to the tool_calls array.
+					hasFunctionCall = true
+					toolCallsResult := gjson.Get(choice, "message.tool_calls")
+					if !toolCallsResult.Exists() || !toolCallsResult.IsArray() {
+						choice, _ = sjson.SetRaw(choice, "message.tool_calls", `[]`)
+					}
+					functionCallItemTemplate := `{"id": "","type": "function","function": {"name": "","arguments": ""}}`
+					fcName := functionCallResult.Get("name").String()
+					functionCallItemTemplate, _ = sjson.Set(functionCallItemTemplate, "id", fmt.Sprintf("%s-%d-%d", fcName, time.Now().UnixNano(), atomic.AddUint64(&functionCallIDCounter, 1)))
+					functionCallItemTemplate, _ = sjson.Set(functionCallItemTemplate, "function.name", fcName)
+					if fcArgsResult := functionCallResult.Get("args"); fcArgsResult.Exists() {
+						functionCallItemTemplate, _ = sjson.Set(functionCallItemTemplate, "function.arguments", fcArgsResult.Raw)
+					}
+					choice, _ = sjson.Set(choice, "message.role", "assistant")
+					choice, _ = sjson.SetRaw(choice, "message.tool_calls.-1", functionCallItemTemplate)
+				} else if inlineDataResult.Exists() {
+					data := inlineDataResult.Get("data").String()
+					if data == "" {
+						continue
+					}
+					mimeType := inlineDataResult.Get("mimeType").String()
+					if mimeType == "" {
+						mimeType = inlineDataResult.Get("mime_type").String()
+					}
+					if mimeType == "" {
+						mimeType = "image/png"
+					}
+					imageURL := fmt.Sprintf("data:%s;base64,%s", mimeType, data)
+					imagesResult := gjson.Get(choice, "message.images")
+					if !imagesResult.Exists() || !imagesResult.IsArray() {
+						choice, _ = sjson.SetRaw(choice, "message.images", `[]`)
+					}
+					imageIndex := len(gjson.Get(choice, "message.images").Array())
+					imagePayload := `{"type":"image_url","image_url":{"url":""}}`
+					imagePayload, _ = sjson.Set(imagePayload, "index", imageIndex)
+					imagePayload, _ = sjson.Set(imagePayload, "image_url.url", imageURL)
+					choice, _ = sjson.Set(choice, "message.role", "assistant")
+					choice, _ = sjson.SetRaw(choice, "message.images.-1", imagePayload)
 				}
-				imageIndex := len(gjson.Get(template, "choices.0.message.images").Array())
-				imagePayload := `{"type":"image_url","image_url":{"url":""}}`
-				imagePayload, _ = sjson.Set(imagePayload, "index", imageIndex)
-				imagePayload, _ = sjson.Set(imagePayload, "image_url.url", imageURL)
-				template, _ = sjson.Set(template, "choices.0.message.role", "assistant")
-				template, _ = sjson.SetRaw(template, "choices.0.message.images.-1", imagePayload)
 			}
 		}
-	}
 
-	if hasFunctionCall {
-		template, _ = sjson.Set(template, "choices.0.finish_reason", "tool_calls")
-		template, _ = sjson.Set(template, "choices.0.native_finish_reason", "tool_calls")
+		if hasFunctionCall {
+			choice, _ = sjson.Set(choice, "finish_reason", "tool_calls")
+			choice, _ = sjson.Set(choice, "native_finish_reason", "tool_calls")
+		}
+		template, _ = sjson.SetRaw(template, "choices.-1", choice)
 	}
 
 	return template
@@ -0,0 +1,70 @@
+package chat_completions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertGeminiResponseToOpenAI_MultipleCandidates(t *testing.T) {
+	var param any
+	first := `{"responseId":"resp-1","candidates":[` +
+		`{"index":0,"content":{"parts":[{"functionCall":{"name":"lookup","args":{"q":"a"}}}]}},` +
+		`{"index":1,"content":{"parts":[{"text":"hello"}]}}],` +
+		`"usageMetadata":{"promptTokenCount":10,"candidatesTokenCount":6,"totalTokenCount":16}}`
+	chunks := ConvertGeminiResponseToOpenAI(context.Background(), "", nil, nil, []byte(first), &param)
+	if len(chunks) != 2 {
+		t.Fatalf("chunks = %q, want one per candidate", chunks)
+	}
+	if got := gjson.Get(chunks[0], "choices.0.index").Int(); got != 0 {
+		t.Fatalf("first chunk choice index = %d, want 0", got)
+	}
+	if got := gjson.Get(chunks[0], "choices.0.delta.tool_calls.0.index").Int(); got != 0 {
+		t.Fatalf("candidate 0 tool call index = %d, want 0", got)
+	}
+	if gjson.Get(chunks[1], "choices.0.index").Int() != 1 || gjson.Get(chunks[1], "choices.0.delta.content").String() != "hello" {
+		t.Fatalf("second chunk = %s, want candidate 1 text", chunks[1])
+	}
+	if gjson.Get(chunks[0], "usage.total_tokens").Int() != 16 || gjson.Get(chunks[1], "usage").Exists() {
+		t.Fatalf("usage reported on %s and %s, want only the first chunk", chunks[0], chunks[1])
+	}
+
+	second := `{"responseId":"resp-1","candidates":[` +
+		`{"index":1,"content":{"parts":[{"functionCall":{"name":"lookup","args":{"q":"b"}}}]}},` +
+		`{"index":0,"content":{"parts":[{"functionCall":{"name":"lookup","args":{"q":"c"}}}]},"finishReason":"STOP"}]}`
+	chunks = ConvertGeminiResponseToOpenAI(context.Background(), "", nil, nil, []byte(second), &param)
+	if len(chunks) != 2 {
+		t.Fatalf("chunks = %q, want one per candidate", chunks)
+	}
+	if gjson.Get(chunks[0], "choices.0.index").Int() != 1 || gjson.Get(chunks[0], "choices.0.delta.tool_calls.0.index").Int() != 0 {
+		t.Fatalf("candidate 1 first tool call = %s, want tool call index 0", chunks[0])
+	}
+	if gjson.Get(chunks[1], "choices.0.index").Int() != 0 || gjson.Get(chunks[1], "choices.0.delta.tool_calls.0.index").Int() != 1 {
+		t.Fatalf("candidate 0 second tool call = %s, want tool call index 1", chunks[1])
+	}
+	if gjson.Get(chunks[1], "choices.0.finish_reason").String() != "tool_calls" {
+		t.Fatalf("candidate 0 finish reason = %s, want tool_calls", chunks[1])
+	}
+}
+
+func TestConvertGeminiResponseToOpenAINonStream_MultipleCandidates(t *testing.T) {
+	raw := `{"responseId":"resp-2","candidates":[` +
+		`{"index":0,"content":{"parts":[{"text":"first"}]},"finishReason":"STOP"},` +
+		`{"index":1,"content":{"parts":[{"functionCall":{"name":"lookup","args":{}}}]},"finishReason":"STOP"}],` +
+		`"usageMetadata":{"promptTokenCount":10,"candidatesTokenCount":6,"totalTokenCount":16}}`
+	out := ConvertGeminiResponseToOpenAINonStream(context.Background(), "", nil, nil, []byte(raw), nil)
+	choices := gjson.Get(out, "choices").Array()
+	if len(choices) != 2 {
+		t.Fatalf("choices = %s, want two", gjson.Get(out, "choices").Raw)
+	}
+	if choices[0].Get("index").Int() != 0 || choices[0].Get("message.content").String() != "first" || choices[0].Get("finish_reason").String() != "stop" {
+		t.Fatalf("choice 0 = %s", choices[0].Raw)
+	}
+	if choices[1].Get("index").Int() != 1 || choices[1].Get("message.tool_calls.0.function.name").String() != "lookup" || choices[1].Get("finish_reason").String() != "tool_calls" {
+		t.Fatalf("choice 1 = %s", choices[1].Raw)
+	}
+	if gjson.Get(out, "usage.prompt_tokens").Int() != 10 || gjson.Get(out, "usage.total_tokens").Int() != 16 {
+		t.Fatalf("usage = %s, want it reported once for the whole response", gjson.Get(out, "usage").Raw)
+	}
+}
@@ -0,0 +1,63 @@
+// Package embeddings provides request translation functionality for OpenAI to Gemini embeddings.
+// It converts OpenAI Embeddings requests into Gemini batchEmbedContents JSON using gjson/sjson only.
+package embeddings
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ConvertOpenAIRequestToGemini converts an OpenAI Embeddings request (raw JSON)
+// into a Gemini batchEmbedContents request. Every input item becomes one entry
+// of the "requests" array so single and batched inputs share the same upstream call.
+//
+// Parameters:
+//   - modelName: The name of the model to use for the request
+//   - rawJSON: The raw JSON request data from the OpenAI API
+//   - stream: Unused; embeddings are never streamed
+//
+// Returns:
+//   - []byte: The transformed request data in Gemini API format
+func ConvertOpenAIRequestToGemini(modelName string, inputRawJSON []byte, _ bool) []byte {
+	rawJSON := bytes.Clone(inputRawJSON)
+	out := []byte(`{"requests":[]}`)
+
+	model := strings.TrimSpace(modelName)
+	if model != "" && !strings.HasPrefix(model, "models/") {
+		model = "models/" + model
+	}
+
+	dimensions := gjson.GetBytes(rawJSON, "dimensions")
+	for _, text := range embeddingInputs(gjson.GetBytes(rawJSON, "input")) {
+		entry := `{"content":{"parts":[{"text":""}]}}`
+		if model != "" {
+			entry, _ = sjson.Set(entry, "model", model)
+		}
+		entry, _ = sjson.Set(entry, "content.parts.0.text", text)
+		if dimensions.Exists() && dimensions.Int() > 0 {
+			entry, _ = sjson.Set(entry, "outputDimensionality", dimensions.Int())
+		}
+		out, _ = sjson.SetRawBytes(out, "requests.-1", []byte(entry))
+	}
+	return out
+}
+
+// embeddingInputs flattens the OpenAI "input" field into a list of texts.
+// Token arrays never reach this point: Gemini only embeds text, so the executors reject them.
+func embeddingInputs(input gjson.Result) []string {
+	if !input.Exists() {
+		return nil
+	}
+	if !input.IsArray() {
+		return []string{input.String()}
+	}
+	items := input.Array()
+	texts := make([]string, 0, len(items))
+	for _, item := range items {
+		texts = append(texts, item.String())
+	}
+	return texts
+}
@@ -0,0 +1,80 @@
+// Package embeddings provides response translation functionality for Gemini to OpenAI embeddings.
+// This package converts Gemini batchEmbedContents responses into the OpenAI Embeddings list format.
+package embeddings
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"math"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ConvertGeminiResponseToOpenAINonStream converts a Gemini batchEmbedContents response
+// into an OpenAI Embeddings response. Vectors keep the order of the original inputs and
+// are base64 encoded when the client requested encoding_format "base64".
+//
+// Parameters:
+//   - ctx: The context for the request (unused in current implementation)
+//   - modelName: The name of the model being used for the response
+//   - originalRequestRawJSON: The original OpenAI Embeddings request
+//   - requestRawJSON: The translated Gemini request (unused in current implementation)
+//   - rawJSON: The raw JSON response from the Gemini API
+//   - param: A pointer to a parameter object for the conversion (unused in current implementation)
+//
+// Returns:
+//   - string: An OpenAI-compatible embeddings response
+func ConvertGeminiResponseToOpenAINonStream(_ context.Context, modelName string, originalRequestRawJSON, _ []byte, rawJSON []byte, _ *any) string {
+	out := `{"object":"list","data":[],"model":"","usage":{"prompt_tokens":0,"total_tokens":0}}`
+
+	model := gjson.GetBytes(originalRequestRawJSON, "model").String()
+	if model == "" {
+		model = modelName
+	}
+	out, _ = sjson.Set(out, "model", model)
+
+	useBase64 := strings.EqualFold(gjson.GetBytes(originalRequestRawJSON, "encoding_format").String(), "base64")
+
+	embeddings := gjson.GetBytes(rawJSON, "embeddings")
+	if !embeddings.Exists() {
+		// embedContent returns a single embedding object instead of a list.
+		if single := gjson.GetBytes(rawJSON, "embedding"); single.Exists() {
+			embeddings = gjson.Parse(`[` + single.Raw + `]`)
+		}
+	}
+	for idx, embedding := range embeddings.Array() {
+		item := `{"object":"embedding","index":0,"embedding":[]}`
+		item, _ = sjson.Set(item, "index", idx)
+		values := embedding.Get("values")
+		if useBase64 {
+			item, _ = sjson.Set(item, "embedding", encodeEmbeddingBase64(values))
+		} else if values.IsArray() {
+			item, _ = sjson.SetRaw(item, "embedding", values.Raw)
+		}
+		out, _ = sjson.SetRaw(out, "data.-1", item)
+	}
+
+	if usage := gjson.GetBytes(rawJSON, "usageMetadata"); usage.Exists() {
+		promptTokens := usage.Get("promptTokenCount").Int()
+		totalTokens := usage.Get("totalTokenCount").Int()
+		if totalTokens == 0 {
+			totalTokens = promptTokens
+		}
+		out, _ = sjson.Set(out, "usage.prompt_tokens", promptTokens)
+		out, _ = sjson.Set(out, "usage.total_tokens", totalTokens)
+	}
+	return out
+}
+
+// encodeEmbeddingBase64 packs the vector as little-endian float32 values, matching OpenAI.
+func encodeEmbeddingBase64(values gjson.Result) string {
+	items := values.Array()
+	buf := make([]byte, 4*len(items))
+	for i, v := range items {
+		binary.LittleEndian.PutUint32(buf[i*4:], math.Float32bits(float32(v.Float())))
+	}
+	return base64.StdEncoding.EncodeToString(buf)
+}
@@ -0,0 +1,75 @@
+package embeddings
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertOpenAIRequestToGemini_BatchInput(t *testing.T) {
+	inputJSON := []byte(`{"model":"gemini-embedding-001","input":["first","second"],"dimensions":256}`)
+
+	output := ConvertOpenAIRequestToGemini("gemini-embedding-001", inputJSON, false)
+
+	requests := gjson.GetBytes(output, "requests").Array()
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(requests))
+	}
+	if got := requests[1].Get("content.parts.0.text").String(); got != "second" {
+		t.Errorf("Expected second input text 'second', got '%s'", got)
+	}
+	if got := requests[0].Get("model").String(); got != "models/gemini-embedding-001" {
+		t.Errorf("Expected model 'models/gemini-embedding-001', got '%s'", got)
+	}
+	if got := requests[0].Get("outputDimensionality").Int(); got != 256 {
+		t.Errorf("Expected outputDimensionality 256, got %d", got)
+	}
+}
+
+func TestConvertOpenAIRequestToGemini_SingleInput(t *testing.T) {
+	inputJSON := []byte(`{"model":"text-embedding-004","input":"hello"}`)
+
+	output := ConvertOpenAIRequestToGemini("text-embedding-004", inputJSON, false)
+
+	requests := gjson.GetBytes(output, "requests").Array()
+	if len(requests) != 1 {
+		t.Fatalf("Expected 1 request, got %d", len(requests))
+	}
+	if requests[0].Get("outputDimensionality").Exists() {
+		t.Errorf("Expected no outputDimensionality when dimensions is absent")
+	}
+}
+
+func TestConvertGeminiResponseToOpenAINonStream(t *testing.T) {
+	original := []byte(`{"model":"gemini-embedding-001","input":["a","b"]}`)
+	response := []byte(`{"embeddings":[{"values":[0.1,0.2]},{"values":[0.3,0.4]}],"usageMetadata":{"promptTokenCount":4,"totalTokenCount":4}}`)
+
+	output := ConvertGeminiResponseToOpenAINonStream(context.Background(), "gemini-embedding-001", original, nil, response, nil)
+
+	data := gjson.Get(output, "data").Array()
+	if len(data) != 2 {
+		t.Fatalf("Expected 2 embeddings, got %d", len(data))
+	}
+	if got := data[1].Get("index").Int(); got != 1 {
+		t.Errorf("Expected index 1, got %d", got)
+	}
+	if got := data[1].Get("embedding.1").Float(); got != 0.4 {
+		t.Errorf("Expected embedding value 0.4, got %v", got)
+	}
+	if got := gjson.Get(output, "usage.prompt_tokens").Int(); got != 4 {
+		t.Errorf("Expected prompt_tokens 4, got %d", got)
+	}
+}
+
+func TestConvertGeminiResponseToOpenAINonStream_Base64(t *testing.T) {
+	original := []byte(`{"model":"gemini-embedding-001","input":"a","encoding_format":"base64"}`)
+	response := []byte(`{"embeddings":[{"values":[1.0]}]}`)
+
+	output := ConvertGeminiResponseToOpenAINonStream(context.Background(), "gemini-embedding-001", original, nil, response, nil)
+
+	// 1.0 as little-endian float32 is 00 00 80 3f.
+	if got := gjson.Get(output, "data.0.embedding").String(); got != "AACAPw==" {
+		t.Errorf("Expected base64 embedding 'AACAPw==', got '%s'", got)
+	}
+}
@@ -64,8 +64,11 @@ type RequestStatistics struct {
 	successCount  int64
 	failureCount  int64
 	totalTokens   int64
+	cacheHits     int64
+	cacheMisses   int64
 
-	apis map[string]*apiStats
+	apis       map[string]*apiStats
+	guardrails map[string]*GuardrailSnapshot
 
 	requestsByDay  map[string]int64
 	requestsByHour map[int]int64
@@ -94,6 +97,7 @@ type RequestDetail struct {
 	AuthIndex string     `json:"auth_index"`
 	Tokens    TokenStats `json:"tokens"`
 	Failed    bool       `json:"failed"`
+	Cache     string     `json:"cache,omitempty"`
 }
 
 // TokenStats captures the token usage breakdown for a request.
@@ -111,15 +115,27 @@ type StatisticsSnapshot struct {
 	SuccessCount  int64 `json:"success_count"`
 	FailureCount  int64 `json:"failure_count"`
 	TotalTokens   int64 `json:"total_tokens"`
+	CacheHits     int64 `json:"cache_hits"`
+	CacheMisses   int64 `json:"cache_misses"`
 
 	APIs map[string]APISnapshot `json:"apis"`
 
+	// Guardrails counts content-filter matches by rule name.
+	Guardrails map[string]GuardrailSnapshot `json:"guardrails,omitempty"`
+
 	RequestsByDay  map[string]int64 `json:"requests_by_day"`
 	RequestsByHour map[string]int64 `json:"requests_by_hour"`
 	TokensByDay    map[string]int64 `json:"tokens_by_day"`
 	TokensByHour   map[string]int64 `json:"tokens_by_hour"`
 }
 
+// GuardrailSnapshot counts content-filter matches for one rule by action taken.
+type GuardrailSnapshot struct {
+	Redacted int64 `json:"redacted"`
+	Blocked  int64 `json:"blocked"`
+	Logged   int64 `json:"logged"`
+}
+
 // APISnapshot summarises metrics for a single API key.
 type APISnapshot struct {
 	TotalRequests int64                    `json:"total_requests"`
@@ -143,6 +159,7 @@ func GetRequestStatistics() *RequestStatistics { return defaultRequestStatistics
 func NewRequestStatistics() *RequestStatistics {
 	return &RequestStatistics{
 		apis:           make(map[string]*apiStats),
+		guardrails:     make(map[string]*GuardrailSnapshot),
 		requestsByDay:  make(map[string]int64),
 		requestsByHour: make(map[int]int64),
 		tokensByDay:    make(map[string]int64),
@@ -158,27 +175,11 @@ func (s *RequestStatistics) Record(ctx context.Context, record coreusage.Record)
 	if !statisticsEnabled.Load() {
 		return
 	}
-	timestamp := record.RequestedAt
-	if timestamp.IsZero() {
-		timestamp = time.Now()
-	}
-	detail := normaliseDetail(record.Detail)
-	totalTokens := detail.TotalTokens
-	statsKey := record.APIKey
-	if statsKey == "" {
-		statsKey = resolveAPIIdentifier(ctx, record)
-	}
-	failed := record.Failed
-	if !failed {
-		failed = !resolveSuccess(ctx)
-	}
-	success := !failed
-	modelName := record.Model
-	if modelName == "" {
-		modelName = "unknown"
-	}
-	dayKey := timestamp.Format("2006-01-02")
-	hourKey := timestamp.Hour()
+	statsKey, modelName, detail := resolveRecordDetail(ctx, record)
+	totalTokens := detail.Tokens.TotalTokens
+	success := !detail.Failed
+	dayKey := detail.Timestamp.Format("2006-01-02")
+	hourKey := detail.Timestamp.Hour()
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -190,19 +191,14 @@ func (s *RequestStatistics) Record(ctx context.Context, record coreusage.Record)
 		s.failureCount++
 	}
 	s.totalTokens += totalTokens
+	s.countCacheStatus(detail.Cache)
 
 	stats, ok := s.apis[statsKey]
 	if !ok {
 		stats = &apiStats{Models: make(map[string]*modelStats)}
 		s.apis[statsKey] = stats
 	}
-	s.updateAPIStats(stats, modelName, RequestDetail{
-		Timestamp: timestamp,
-		Source:    record.Source,
-		AuthIndex: record.AuthIndex,
-		Tokens:    detail,
-		Failed:    failed,
-	})
+	s.updateAPIStats(stats, modelName, detail)
 
 	s.requestsByDay[dayKey]++
 	s.requestsByHour[hourKey]++
@@ -210,6 +206,68 @@ func (s *RequestStatistics) Record(ctx context.Context, record coreusage.Record)
 	s.tokensByHour[hourKey] += totalTokens
 }
 
+// resolveRecordDetail derives the API key, model name and request detail for a usage record.
+func resolveRecordDetail(ctx context.Context, record coreusage.Record) (string, string, RequestDetail) {
+	timestamp := record.RequestedAt
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	statsKey := record.APIKey
+	if statsKey == "" {
+		statsKey = resolveAPIIdentifier(ctx, record)
+	}
+	failed := record.Failed
+	if !failed {
+		failed = !resolveSuccess(ctx)
+	}
+	modelName := record.Model
+	if modelName == "" {
+		modelName = "unknown"
+	}
+	return statsKey, modelName, RequestDetail{
+		Timestamp: timestamp,
+		Source:    record.Source,
+		AuthIndex: record.AuthIndex,
+		Tokens:    normaliseDetail(record.Detail),
+		Failed:    failed,
+		Cache:     record.Cache,
+	}
+}
+
+// RecordGuardrail counts a content-filter match for rule. Action is "redact", "block" or "log".
+func (s *RequestStatistics) RecordGuardrail(rule, action string) {
+	if s == nil || !statisticsEnabled.Load() {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.guardrails == nil {
+		s.guardrails = make(map[string]*GuardrailSnapshot)
+	}
+	counts := s.guardrails[rule]
+	if counts == nil {
+		counts = &GuardrailSnapshot{}
+		s.guardrails[rule] = counts
+	}
+	switch action {
+	case "redact":
+		counts.Redacted++
+	case "block":
+		counts.Blocked++
+	case "log":
+		counts.Logged++
+	}
+}
+
+func (s *RequestStatistics) countCacheStatus(status string) {
+	switch status {
+	case coreusage.CacheHit:
+		s.cacheHits++
+	case coreusage.CacheMiss:
+		s.cacheMisses++
+	}
+}
+
 func (s *RequestStatistics) updateAPIStats(stats *apiStats, model string, detail RequestDetail) {
 	stats.TotalRequests++
 	stats.TotalTokens += detail.Tokens.TotalTokens
@@ -237,6 +295,8 @@ func (s *RequestStatistics) Snapshot() StatisticsSnapshot {
 	result.SuccessCount = s.successCount
 	result.FailureCount = s.failureCount
 	result.TotalTokens = s.totalTokens
+	result.CacheHits = s.cacheHits
+	result.CacheMisses = s.cacheMisses
 
 	result.APIs = make(map[string]APISnapshot, len(s.apis))
 	for apiName, stats := range s.apis {
@@ -257,6 +317,13 @@ func (s *RequestStatistics) Snapshot() StatisticsSnapshot {
 		result.APIs[apiName] = apiSnapshot
 	}
 
+	if len(s.guardrails) > 0 {
+		result.Guardrails = make(map[string]GuardrailSnapshot, len(s.guardrails))
+		for rule, counts := range s.guardrails {
+			result.Guardrails[rule] = *counts
+		}
+	}
+
 	result.RequestsByDay = make(map[string]int64, len(s.requestsByDay))
 	for k, v := range s.requestsByDay {
 		result.RequestsByDay[k] = v
@@ -363,6 +430,7 @@ func (s *RequestStatistics) recordImported(apiName, modelName string, stats *api
 		s.successCount++
 	}
 	s.totalTokens += totalTokens
+	s.countCacheStatus(detail.Cache)
 
 	s.updateAPIStats(stats, modelName, detail)
 
@@ -379,13 +447,14 @@ func dedupKey(apiName, modelName string, detail RequestDetail) string {
 	timestamp := detail.Timestamp.UTC().Format(time.RFC3339Nano)
 	tokens := normaliseTokenStats(detail.Tokens)
 	return fmt.Sprintf(
-		"%s|%s|%s|%s|%s|%t|%d|%d|%d|%d|%d",
+		"%s|%s|%s|%s|%s|%t|%s|%d|%d|%d|%d|%d",
 		apiName,
 		modelName,
 		timestamp,
 		detail.Source,
 		detail.AuthIndex,
 		detail.Failed,
+		detail.Cache,
 		tokens.InputTokens,
 		tokens.OutputTokens,
 		tokens.ReasoningTokens,
@@ -0,0 +1,224 @@
+package usage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	persistQueueSize     = 1024
+	persistBatchSize     = 128
+	persistFlushInterval = 2 * time.Second
+	persistPruneInterval = time.Hour
+)
+
+// PersistedRecord is the durable representation of a single request detail.
+type PersistedRecord struct {
+	API    string        `json:"api"`
+	Model  string        `json:"model"`
+	Detail RequestDetail `json:"detail"`
+}
+
+// Sink stores usage records durably so aggregates survive restarts.
+type Sink interface {
+	// Append writes the given records to the backing store.
+	Append(ctx context.Context, records []PersistedRecord) error
+	// Load returns all records requested at or after since. A zero since loads everything.
+	Load(ctx context.Context, since time.Time) ([]PersistedRecord, error)
+	// Prune removes records requested before the given time.
+	Prune(ctx context.Context, before time.Time) error
+	// Close releases resources held by the sink.
+	Close() error
+}
+
+// PersistentPlugin forwards usage records to a Sink in batches.
+// It implements coreusage.Plugin and runs its writer in a background goroutine so
+// slow storage never blocks the usage dispatcher.
+type PersistentPlugin struct {
+	sink      Sink
+	retention time.Duration
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan PersistedRecord
+	done   chan struct{}
+}
+
+// NewPersistentPlugin constructs a plugin writing to sink.
+//
+// Parameters:
+//   - sink: The durable storage backend
+//   - retention: How long records are kept; zero keeps records forever
+//
+// Returns:
+//   - *PersistentPlugin: A started plugin instance
+func NewPersistentPlugin(sink Sink, retention time.Duration) *PersistentPlugin {
+	if retention < 0 {
+		retention = 0
+	}
+	p := &PersistentPlugin{
+		sink:      sink,
+		retention: retention,
+		queue:     make(chan PersistedRecord, persistQueueSize),
+		done:      make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+// HandleUsage implements coreusage.Plugin.
+// Records are dropped with a warning when the write queue is full.
+func (p *PersistentPlugin) HandleUsage(ctx context.Context, record coreusage.Record) {
+	if p == nil || p.sink == nil || !statisticsEnabled.Load() {
+		return
+	}
+	apiName, modelName, detail := resolveRecordDetail(ctx, record)
+	entry := PersistedRecord{API: apiName, Model: modelName, Detail: detail}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return
+	}
+	select {
+	case p.queue <- entry:
+	default:
+		log.Warn("usage persistence: write queue full, dropping record")
+	}
+}
+
+// Restore loads persisted records within the retention window and merges them into stats.
+func (p *PersistentPlugin) Restore(ctx context.Context, stats *RequestStatistics) (MergeResult, error) {
+	if p == nil || p.sink == nil || stats == nil {
+		return MergeResult{}, nil
+	}
+	records, err := p.sink.Load(ctx, p.cutoff())
+	if err != nil {
+		return MergeResult{}, err
+	}
+	return stats.MergeSnapshot(snapshotFromRecords(records)), nil
+}
+
+// TokensSince sums the persisted total tokens per API key for records requested at or after since.
+// It lets per-key daily token quotas resume after a restart.
+func (p *PersistentPlugin) TokensSince(ctx context.Context, since time.Time) (map[string]int64, error) {
+	if p == nil || p.sink == nil {
+		return nil, nil
+	}
+	if cutoff := p.cutoff(); cutoff.After(since) {
+		since = cutoff
+	}
+	records, err := p.sink.Load(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+	totals := make(map[string]int64)
+	for _, record := range records {
+		if record.API == "" || record.Detail.Tokens.TotalTokens <= 0 {
+			continue
+		}
+		totals[record.API] += record.Detail.Tokens.TotalTokens
+	}
+	return totals, nil
+}
+
+// Close flushes pending records and closes the underlying sink.
+func (p *PersistentPlugin) Close() error {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	close(p.queue)
+	p.mu.Unlock()
+
+	<-p.done
+	if p.sink == nil {
+		return nil
+	}
+	return p.sink.Close()
+}
+
+func (p *PersistentPlugin) run() {
+	defer close(p.done)
+
+	flushTicker := time.NewTicker(persistFlushInterval)
+	defer flushTicker.Stop()
+	pruneTicker := time.NewTicker(persistPruneInterval)
+	defer pruneTicker.Stop()
+
+	p.prune()
+	batch := make([]PersistedRecord, 0, persistBatchSize)
+	for {
+		select {
+		case entry, ok := <-p.queue:
+			if !ok {
+				p.flush(batch)
+				return
+			}
+			batch = append(batch, entry)
+			if len(batch) >= persistBatchSize {
+				p.flush(batch)
+				batch = batch[:0]
+			}
+		case <-flushTicker.C:
+			p.flush(batch)
+			batch = batch[:0]
+		case <-pruneTicker.C:
+			p.prune()
+		}
+	}
+}
+
+func (p *PersistentPlugin) flush(batch []PersistedRecord) {
+	if len(batch) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := p.sink.Append(ctx, batch); err != nil {
+		log.Errorf("usage persistence: failed to append %d records: %v", len(batch), err)
+	}
+}
+
+func (p *PersistentPlugin) prune() {
+	cutoff := p.cutoff()
+	if cutoff.IsZero() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if err := p.sink.Prune(ctx, cutoff); err != nil {
+		log.Errorf("usage persistence: failed to prune records: %v", err)
+	}
+}
+
+func (p *PersistentPlugin) cutoff() time.Time {
+	if p.retention <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(-p.retention)
+}
+
+// snapshotFromRecords groups persisted records into a snapshot suitable for MergeSnapshot.
+func snapshotFromRecords(records []PersistedRecord) StatisticsSnapshot {
+	snapshot := StatisticsSnapshot{APIs: make(map[string]APISnapshot)}
+	for _, record := range records {
+		apiSnapshot, ok := snapshot.APIs[record.API]
+		if !ok {
+			apiSnapshot = APISnapshot{Models: make(map[string]ModelSnapshot)}
+		}
+		modelSnapshot := apiSnapshot.Models[record.Model]
+		modelSnapshot.Details = append(modelSnapshot.Details, record.Detail)
+		apiSnapshot.Models[record.Model] = modelSnapshot
+		snapshot.APIs[record.API] = apiSnapshot
+	}
+	return snapshot
+}
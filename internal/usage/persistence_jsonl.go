@@ -0,0 +1,167 @@
+package usage
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// JSONLSink appends usage records to a newline-delimited JSON file.
+type JSONLSink struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewJSONLSink creates a sink writing to path, creating parent directories as needed.
+func NewJSONLSink(path string) (*JSONLSink, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, fmt.Errorf("usage jsonl sink: path is required")
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("usage jsonl sink: resolve path: %w", err)
+	}
+	if err = os.MkdirAll(filepath.Dir(absPath), 0o700); err != nil {
+		return nil, fmt.Errorf("usage jsonl sink: create directory: %w", err)
+	}
+	return &JSONLSink{path: absPath}, nil
+}
+
+// Path returns the absolute file path backing the sink.
+func (s *JSONLSink) Path() string { return s.path }
+
+// Append implements Sink.
+func (s *JSONLSink) Append(_ context.Context, records []PersistedRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	var buf strings.Builder
+	for _, record := range records {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("usage jsonl sink: marshal record: %w", err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("usage jsonl sink: open file: %w", err)
+	}
+	if _, err = file.WriteString(buf.String()); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("usage jsonl sink: write file: %w", err)
+	}
+	return file.Close()
+}
+
+// Load implements Sink.
+func (s *JSONLSink) Load(_ context.Context, since time.Time) ([]PersistedRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.readLocked(func(record PersistedRecord) bool {
+		return since.IsZero() || !record.Detail.Timestamp.Before(since)
+	})
+}
+
+// Prune implements Sink. The file is rewritten atomically without the expired records.
+func (s *JSONLSink) Prune(_ context.Context, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := 0
+	kept, err := s.readLocked(func(record PersistedRecord) bool {
+		if record.Detail.Timestamp.Before(before) {
+			removed++
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return nil
+	}
+
+	tmpPath := s.path + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("usage jsonl sink: create temp file: %w", err)
+	}
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, record := range kept {
+		if err = encoder.Encode(record); err != nil {
+			_ = file.Close()
+			_ = os.Remove(tmpPath)
+			return fmt.Errorf("usage jsonl sink: write temp file: %w", err)
+		}
+	}
+	if err = writer.Flush(); err != nil {
+		_ = file.Close()
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("usage jsonl sink: flush temp file: %w", err)
+	}
+	if err = file.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("usage jsonl sink: close temp file: %w", err)
+	}
+	if err = os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("usage jsonl sink: replace file: %w", err)
+	}
+	log.Debugf("usage jsonl sink: pruned %d records older than %s", removed, before.Format(time.RFC3339))
+	return nil
+}
+
+// Close implements Sink.
+func (s *JSONLSink) Close() error { return nil }
+
+func (s *JSONLSink) readLocked(keep func(PersistedRecord) bool) ([]PersistedRecord, error) {
+	file, err := os.Open(s.path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("usage jsonl sink: open file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	var records []PersistedRecord
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Bytes()
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+		var record PersistedRecord
+		if err = json.Unmarshal(line, &record); err != nil {
+			log.Warnf("usage jsonl sink: skipping malformed line %d: %v", lineNo, err)
+			continue
+		}
+		if keep(record) {
+			records = append(records, record)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("usage jsonl sink: read file: %w", err)
+	}
+	return records, nil
+}
@@ -0,0 +1,188 @@
+package usage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+const defaultUsageTable = "usage_records"
+
+// PostgresSinkConfig captures the connection settings for a PostgreSQL-backed sink.
+type PostgresSinkConfig struct {
+	DSN    string
+	Schema string
+	Table  string
+}
+
+// PostgresSink stores usage records in a PostgreSQL table.
+type PostgresSink struct {
+	db  *sql.DB
+	cfg PostgresSinkConfig
+}
+
+// NewPostgresSink connects to PostgreSQL and ensures the usage table exists.
+func NewPostgresSink(ctx context.Context, cfg PostgresSinkConfig) (*PostgresSink, error) {
+	cfg.DSN = strings.TrimSpace(cfg.DSN)
+	if cfg.DSN == "" {
+		return nil, fmt.Errorf("usage postgres sink: DSN is required")
+	}
+	cfg.Schema = strings.TrimSpace(cfg.Schema)
+	if cfg.Table = strings.TrimSpace(cfg.Table); cfg.Table == "" {
+		cfg.Table = defaultUsageTable
+	}
+
+	db, err := sql.Open("pgx", cfg.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("usage postgres sink: open database connection: %w", err)
+	}
+	if err = db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("usage postgres sink: ping database: %w", err)
+	}
+	sink := &PostgresSink{db: db, cfg: cfg}
+	if err = sink.ensureSchema(ctx); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return sink, nil
+}
+
+func (s *PostgresSink) ensureSchema(ctx context.Context) error {
+	if s.cfg.Schema != "" {
+		query := fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s", quoteIdentifier(s.cfg.Schema))
+		if _, err := s.db.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("usage postgres sink: create schema: %w", err)
+		}
+	}
+	table := s.tableName()
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id BIGSERIAL PRIMARY KEY,
+			api TEXT NOT NULL,
+			model TEXT NOT NULL,
+			requested_at TIMESTAMPTZ NOT NULL,
+			source TEXT NOT NULL DEFAULT '',
+			auth_index TEXT NOT NULL DEFAULT '',
+			failed BOOLEAN NOT NULL DEFAULT FALSE,
+			cache TEXT NOT NULL DEFAULT '',
+			input_tokens BIGINT NOT NULL DEFAULT 0,
+			output_tokens BIGINT NOT NULL DEFAULT 0,
+			reasoning_tokens BIGINT NOT NULL DEFAULT 0,
+			cached_tokens BIGINT NOT NULL DEFAULT 0,
+			total_tokens BIGINT NOT NULL DEFAULT 0
+		)
+	`, table)); err != nil {
+		return fmt.Errorf("usage postgres sink: create table: %w", err)
+	}
+	// Tables created before response caching lack the cache column.
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS cache TEXT NOT NULL DEFAULT ''", table)); err != nil {
+		return fmt.Errorf("usage postgres sink: add cache column: %w", err)
+	}
+	index := quoteIdentifier(s.cfg.Table + "_requested_at_idx")
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (requested_at)", index, table)); err != nil {
+		return fmt.Errorf("usage postgres sink: create index: %w", err)
+	}
+	return nil
+}
+
+// Append implements Sink.
+func (s *PostgresSink) Append(ctx context.Context, records []PersistedRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("usage postgres sink: begin transaction: %w", err)
+	}
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf(`
+		INSERT INTO %s (api, model, requested_at, source, auth_index, failed, cache,
+			input_tokens, output_tokens, reasoning_tokens, cached_tokens, total_tokens)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`, s.tableName()))
+	if err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("usage postgres sink: prepare insert: %w", err)
+	}
+	defer func() { _ = stmt.Close() }()
+	for _, record := range records {
+		detail := record.Detail
+		if _, err = stmt.ExecContext(ctx,
+			record.API, record.Model, detail.Timestamp.UTC(), detail.Source, detail.AuthIndex, detail.Failed, detail.Cache,
+			detail.Tokens.InputTokens, detail.Tokens.OutputTokens, detail.Tokens.ReasoningTokens,
+			detail.Tokens.CachedTokens, detail.Tokens.TotalTokens,
+		); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("usage postgres sink: insert record: %w", err)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("usage postgres sink: commit: %w", err)
+	}
+	return nil
+}
+
+// Load implements Sink.
+func (s *PostgresSink) Load(ctx context.Context, since time.Time) ([]PersistedRecord, error) {
+	query := fmt.Sprintf(`
+		SELECT api, model, requested_at, source, auth_index, failed, cache,
+			input_tokens, output_tokens, reasoning_tokens, cached_tokens, total_tokens
+		FROM %s WHERE requested_at >= $1 ORDER BY requested_at
+	`, s.tableName())
+	rows, err := s.db.QueryContext(ctx, query, since.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("usage postgres sink: query records: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var records []PersistedRecord
+	for rows.Next() {
+		var record PersistedRecord
+		detail := &record.Detail
+		if err = rows.Scan(
+			&record.API, &record.Model, &detail.Timestamp, &detail.Source, &detail.AuthIndex, &detail.Failed, &detail.Cache,
+			&detail.Tokens.InputTokens, &detail.Tokens.OutputTokens, &detail.Tokens.ReasoningTokens,
+			&detail.Tokens.CachedTokens, &detail.Tokens.TotalTokens,
+		); err != nil {
+			return nil, fmt.Errorf("usage postgres sink: scan record: %w", err)
+		}
+		records = append(records, record)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("usage postgres sink: iterate records: %w", err)
+	}
+	return records, nil
+}
+
+// Prune implements Sink.
+func (s *PostgresSink) Prune(ctx context.Context, before time.Time) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE requested_at < $1", s.tableName())
+	if _, err := s.db.ExecContext(ctx, query, before.UTC()); err != nil {
+		return fmt.Errorf("usage postgres sink: prune records: %w", err)
+	}
+	return nil
+}
+
+// Close implements Sink.
+func (s *PostgresSink) Close() error {
+	if s == nil || s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+func (s *PostgresSink) tableName() string {
+	if s.cfg.Schema == "" {
+		return quoteIdentifier(s.cfg.Table)
+	}
+	return quoteIdentifier(s.cfg.Schema) + "." + quoteIdentifier(s.cfg.Table)
+}
+
+func quoteIdentifier(identifier string) string {
+	replaced := strings.ReplaceAll(identifier, "\"", "\"\"")
+	return "\"" + replaced + "\""
+}
@@ -0,0 +1,98 @@
+package usage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestJSONLSink_AppendLoadPrune(t *testing.T) {
+	sink, err := NewJSONLSink(filepath.Join(t.TempDir(), "usage", "usage.jsonl"))
+	if err != nil {
+		t.Fatalf("NewJSONLSink() error = %v", err)
+	}
+	ctx := context.Background()
+	now := time.Now().UTC()
+	records := []PersistedRecord{
+		{API: "key-a", Model: "gpt-5", Detail: RequestDetail{Timestamp: now.Add(-48 * time.Hour), Tokens: TokenStats{TotalTokens: 10}}},
+		{API: "key-a", Model: "gpt-5", Detail: RequestDetail{Timestamp: now, Tokens: TokenStats{TotalTokens: 20}}},
+	}
+	if err = sink.Append(ctx, records); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+
+	loaded, err := sink.Load(ctx, now.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(loaded) != 1 || loaded[0].Detail.Tokens.TotalTokens != 20 {
+		t.Fatalf("Load() = %+v, want only the recent record", loaded)
+	}
+
+	if err = sink.Prune(ctx, now.Add(-24*time.Hour)); err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	loaded, err = sink.Load(ctx, time.Time{})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(loaded) != 1 {
+		t.Fatalf("Load() after prune returned %d records, want 1", len(loaded))
+	}
+}
+
+func TestPersistentPlugin_RestoreMergesRecords(t *testing.T) {
+	sink, err := NewJSONLSink(filepath.Join(t.TempDir(), "usage.jsonl"))
+	if err != nil {
+		t.Fatalf("NewJSONLSink() error = %v", err)
+	}
+	now := time.Now().UTC()
+	if err = sink.Append(context.Background(), []PersistedRecord{
+		{API: "key-a", Model: "gemini-2.5-pro", Detail: RequestDetail{Timestamp: now, Tokens: TokenStats{InputTokens: 3, OutputTokens: 4}}},
+		{API: "key-b", Model: "gemini-2.5-pro", Detail: RequestDetail{Timestamp: now, Failed: true}},
+	}); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+
+	plugin := NewPersistentPlugin(sink, 24*time.Hour)
+	defer func() { _ = plugin.Close() }()
+	stats := NewRequestStatistics()
+	result, err := plugin.Restore(context.Background(), stats)
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if result.Added != 2 {
+		t.Fatalf("Restore() added = %d, want 2", result.Added)
+	}
+	snapshot := stats.Snapshot()
+	if snapshot.TotalRequests != 2 || snapshot.FailureCount != 1 || snapshot.TotalTokens != 7 {
+		t.Fatalf("snapshot totals = %d/%d/%d, want 2/1/7", snapshot.TotalRequests, snapshot.FailureCount, snapshot.TotalTokens)
+	}
+}
+
+func TestPersistentPlugin_TokensSinceSumsPerKey(t *testing.T) {
+	sink, err := NewJSONLSink(filepath.Join(t.TempDir(), "usage.jsonl"))
+	if err != nil {
+		t.Fatalf("NewJSONLSink() error = %v", err)
+	}
+	now := time.Now().UTC()
+	if err = sink.Append(context.Background(), []PersistedRecord{
+		{API: "key-a", Model: "m", Detail: RequestDetail{Timestamp: now.Add(-48 * time.Hour), Tokens: TokenStats{TotalTokens: 1000}}},
+		{API: "key-a", Model: "m", Detail: RequestDetail{Timestamp: now, Tokens: TokenStats{TotalTokens: 7}}},
+		{API: "key-a", Model: "m", Detail: RequestDetail{Timestamp: now, Tokens: TokenStats{TotalTokens: 5}}},
+		{API: "key-b", Model: "m", Detail: RequestDetail{Timestamp: now, Tokens: TokenStats{TotalTokens: 3}}},
+	}); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+
+	plugin := NewPersistentPlugin(sink, 0)
+	defer func() { _ = plugin.Close() }()
+	totals, err := plugin.TokensSince(context.Background(), now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("TokensSince() error = %v", err)
+	}
+	if totals["key-a"] != 12 || totals["key-b"] != 3 || len(totals) != 2 {
+		t.Fatalf("TokensSince() = %v, want key-a:12 key-b:3", totals)
+	}
+}
@@ -69,6 +69,11 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	} else if !reflect.DeepEqual(trimStrings(oldCfg.APIKeys), trimStrings(newCfg.APIKeys)) {
 		changes = append(changes, "api-keys: values updated (count unchanged, redacted)")
 	}
+	if len(oldCfg.APIKeyPolicies) != len(newCfg.APIKeyPolicies) {
+		changes = append(changes, fmt.Sprintf("api-key-policies count: %d -> %d", len(oldCfg.APIKeyPolicies), len(newCfg.APIKeyPolicies)))
+	} else if !reflect.DeepEqual(oldCfg.APIKeyPolicies, newCfg.APIKeyPolicies) {
+		changes = append(changes, "api-key-policies: updated (count unchanged, redacted)")
+	}
 	if len(oldCfg.GeminiKey) != len(newCfg.GeminiKey) {
 		changes = append(changes, fmt.Sprintf("gemini-api-key count: %d -> %d", len(oldCfg.GeminiKey), len(newCfg.GeminiKey)))
 	} else {
@@ -186,6 +191,58 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 		changes = append(changes, fmt.Sprintf("ampcode.force-model-mappings: %t -> %t", oldCfg.AmpCode.ForceModelMappings, newCfg.AmpCode.ForceModelMappings))
 	}
 
+	if oldCfg.Guardrails.Enabled != newCfg.Guardrails.Enabled {
+		changes = append(changes, fmt.Sprintf("guardrails.enabled: %t -> %t", oldCfg.Guardrails.Enabled, newCfg.Guardrails.Enabled))
+	}
+	if !reflect.DeepEqual(oldCfg.Guardrails.Rules, newCfg.Guardrails.Rules) || oldCfg.Guardrails.ApplyToResponses != newCfg.Guardrails.ApplyToResponses {
+		changes = append(changes, fmt.Sprintf("guardrails: updated (%d -> %d rules)", len(oldCfg.Guardrails.Rules), len(newCfg.Guardrails.Rules)))
+	}
+	if oldCfg.Routing.Hedging.Enabled != newCfg.Routing.Hedging.Enabled {
+		changes = append(changes, fmt.Sprintf("routing.hedging.enabled: %t -> %t", oldCfg.Routing.Hedging.Enabled, newCfg.Routing.Hedging.Enabled))
+	}
+	if oldCfg.Routing.Hedging.DelayMS != newCfg.Routing.Hedging.DelayMS {
+		changes = append(changes, fmt.Sprintf("routing.hedging.delay-ms: %d -> %d", oldCfg.Routing.Hedging.DelayMS, newCfg.Routing.Hedging.DelayMS))
+	}
+	if !reflect.DeepEqual(oldCfg.Routing.Hedging.Models, newCfg.Routing.Hedging.Models) {
+		changes = append(changes, fmt.Sprintf("routing.hedging.models: %v -> %v", oldCfg.Routing.Hedging.Models, newCfg.Routing.Hedging.Models))
+	}
+	if oldCfg.Routing.Queue.Enabled != newCfg.Routing.Queue.Enabled {
+		changes = append(changes, fmt.Sprintf("routing.queue.enabled: %t -> %t", oldCfg.Routing.Queue.Enabled, newCfg.Routing.Queue.Enabled))
+	}
+	if oldCfg.Routing.Queue.MaxDepth != newCfg.Routing.Queue.MaxDepth {
+		changes = append(changes, fmt.Sprintf("routing.queue.max-depth: %d -> %d", oldCfg.Routing.Queue.MaxDepth, newCfg.Routing.Queue.MaxDepth))
+	}
+	if oldCfg.Routing.Queue.MaxPerKey != newCfg.Routing.Queue.MaxPerKey {
+		changes = append(changes, fmt.Sprintf("routing.queue.max-per-key: %d -> %d", oldCfg.Routing.Queue.MaxPerKey, newCfg.Routing.Queue.MaxPerKey))
+	}
+	if oldCfg.Routing.Queue.MaxWaitSeconds != newCfg.Routing.Queue.MaxWaitSeconds {
+		changes = append(changes, fmt.Sprintf("routing.queue.max-wait-seconds: %d -> %d", oldCfg.Routing.Queue.MaxWaitSeconds, newCfg.Routing.Queue.MaxWaitSeconds))
+	}
+	if !reflect.DeepEqual(oldCfg.Routing.Queue.Priorities, newCfg.Routing.Queue.Priorities) {
+		changes = append(changes, fmt.Sprintf("routing.queue.priorities: updated (%d -> %d keys)", len(oldCfg.Routing.Queue.Priorities), len(newCfg.Routing.Queue.Priorities)))
+	}
+	if oldCfg.HealthCheck.Enabled != newCfg.HealthCheck.Enabled {
+		changes = append(changes, fmt.Sprintf("health-check.enabled: %t -> %t", oldCfg.HealthCheck.Enabled, newCfg.HealthCheck.Enabled))
+	}
+	if oldCfg.HealthCheck.IntervalSeconds != newCfg.HealthCheck.IntervalSeconds {
+		changes = append(changes, fmt.Sprintf("health-check.interval-seconds: %d -> %d", oldCfg.HealthCheck.IntervalSeconds, newCfg.HealthCheck.IntervalSeconds))
+	}
+	if oldCfg.HealthCheck.TimeoutSeconds != newCfg.HealthCheck.TimeoutSeconds {
+		changes = append(changes, fmt.Sprintf("health-check.timeout-seconds: %d -> %d", oldCfg.HealthCheck.TimeoutSeconds, newCfg.HealthCheck.TimeoutSeconds))
+	}
+	if !reflect.DeepEqual(oldCfg.HealthCheck.Models, newCfg.HealthCheck.Models) {
+		changes = append(changes, fmt.Sprintf("health-check.models: %v -> %v", oldCfg.HealthCheck.Models, newCfg.HealthCheck.Models))
+	}
+	if !reflect.DeepEqual(oldCfg.ModelRouting, newCfg.ModelRouting) {
+		changes = append(changes, fmt.Sprintf("model-routing: updated (%d -> %d entries)", len(oldCfg.ModelRouting), len(newCfg.ModelRouting)))
+	}
+	if oldCfg.ContextOverflow.NormalizedMode() != newCfg.ContextOverflow.NormalizedMode() {
+		changes = append(changes, fmt.Sprintf("context-overflow.mode: %s -> %s", oldCfg.ContextOverflow.NormalizedMode(), newCfg.ContextOverflow.NormalizedMode()))
+	}
+	if !reflect.DeepEqual(oldCfg.ContextOverflow.RerouteModels, newCfg.ContextOverflow.RerouteModels) {
+		changes = append(changes, fmt.Sprintf("context-overflow.reroute-models: updated (%d -> %d entries)", len(oldCfg.ContextOverflow.RerouteModels), len(newCfg.ContextOverflow.RerouteModels)))
+	}
+
 	if entries, _ := DiffOAuthExcludedModelChanges(oldCfg.OAuthExcludedModels, newCfg.OAuthExcludedModels); len(entries) > 0 {
 		changes = append(changes, entries...)
 	}
@@ -212,6 +269,7 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			changes = append(changes, "remote-management.secret-key: updated")
 		}
 	}
+	changes = append(changes, diffManagementKeys(oldCfg.RemoteManagement.Keys, newCfg.RemoteManagement.Keys)...)
 
 	// OpenAI compatibility providers (summarized)
 	if compat := DiffOpenAICompatibility(oldCfg.OpenAICompatibility, newCfg.OpenAICompatibility); len(compat) > 0 {
@@ -301,3 +359,29 @@ func formatProxyURL(raw string) string {
 	}
 	return scheme + "://" + host
 }
+
+// diffManagementKeys reports scoped management keys by name; secrets are never printed.
+func diffManagementKeys(oldKeys, newKeys []config.ManagementKey) []string {
+	var changes []string
+	oldByName := make(map[string]config.ManagementKey, len(oldKeys))
+	for _, key := range oldKeys {
+		oldByName[key.Name] = key
+	}
+	seen := make(map[string]struct{}, len(newKeys))
+	for _, key := range newKeys {
+		seen[key.Name] = struct{}{}
+		previous, existed := oldByName[key.Name]
+		switch {
+		case !existed:
+			changes = append(changes, fmt.Sprintf("remote-management.keys: added %s", key.Name))
+		case !reflect.DeepEqual(previous, key):
+			changes = append(changes, fmt.Sprintf("remote-management.keys: updated %s", key.Name))
+		}
+	}
+	for _, key := range oldKeys {
+		if _, exists := seen[key.Name]; !exists {
+			changes = append(changes, fmt.Sprintf("remote-management.keys: removed %s", key.Name))
+		}
+	}
+	return changes
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+// apiKeyPolicy returns the virtual key policy of the client key that authenticated ctx.
+func (h *BaseAPIHandler) apiKeyPolicy(ctx context.Context) (config.APIKeyPolicy, bool) {
+	if h == nil || h.Cfg == nil || len(h.Cfg.APIKeyPolicies) == 0 || ctx == nil {
+		return config.APIKeyPolicy{}, false
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return config.APIKeyPolicy{}, false
+	}
+	return h.Cfg.APIKeyPolicyFor(ginCtx.GetString("apiKey"))
+}
+
+// enforceAPIKeyPolicy applies the client key's policy to a resolved request. It returns
+// the providers the key may use, or an error when the key has expired or the model is
+// outside its allowlist.
+func (h *BaseAPIHandler) enforceAPIKeyPolicy(ctx context.Context, requestedModel, resolvedModel string, providers []string) ([]string, *interfaces.ErrorMessage) {
+	policy, ok := h.apiKeyPolicy(ctx)
+	if !ok {
+		return providers, nil
+	}
+	if policy.Expired(time.Now()) {
+		return nil, &interfaces.ErrorMessage{StatusCode: http.StatusUnauthorized, Error: fmt.Errorf("API key has expired")}
+	}
+	baseModel, _ := normalizeModelMetadata(requestedModel)
+	if !policyAllowsModel(policy, requestedModel) && !policyAllowsModel(policy, baseModel) {
+		return nil, &interfaces.ErrorMessage{StatusCode: http.StatusForbidden, Error: fmt.Errorf("model %s is not allowed for this API key", requestedModel)}
+	}
+	if !policyAllowsPrefix(policy, resolvedModel) {
+		return nil, &interfaces.ErrorMessage{StatusCode: http.StatusForbidden, Error: fmt.Errorf("model %s must use one of the prefixes allowed for this API key", requestedModel)}
+	}
+	if len(policy.AllowedProviders) > 0 && len(providers) > 0 {
+		filtered := make([]string, 0, len(providers))
+		for _, provider := range providers {
+			if policyAllowsProvider(policy, provider) {
+				filtered = append(filtered, provider)
+			}
+		}
+		if len(filtered) == 0 {
+			return nil, &interfaces.ErrorMessage{StatusCode: http.StatusForbidden, Error: fmt.Errorf("no provider allowed for this API key serves model %s", requestedModel)}
+		}
+		providers = filtered
+	}
+	return providers, nil
+}
+
+// FilterModelsForAPIKey removes models the requesting client key may not use from a
+// listing produced for handlerType. Expired keys see an empty listing.
+func (h *BaseAPIHandler) FilterModelsForAPIKey(c *gin.Context, handlerType string, models []map[string]any) []map[string]any {
+	if c == nil || h == nil || h.Cfg == nil {
+		return models
+	}
+	policy, ok := h.Cfg.APIKeyPolicyFor(c.GetString("apiKey"))
+	if !ok {
+		return models
+	}
+	if policy.Expired(time.Now()) {
+		return []map[string]any{}
+	}
+	idKey := "id"
+	if handlerType == "gemini" {
+		idKey = "name"
+	}
+	router := h.modelRouter()
+	out := make([]map[string]any, 0, len(models))
+	for _, model := range models {
+		id, _ := model[idKey].(string)
+		id = strings.TrimPrefix(id, "models/")
+		if id == "" || !policyAllowsModel(policy, id) {
+			continue
+		}
+		resolved := id
+		if route, routed := router.Resolve(id); routed {
+			resolved = route.Model
+		}
+		if !policyAllowsPrefix(policy, resolved) {
+			continue
+		}
+		if len(policy.AllowedProviders) > 0 {
+			allowed := false
+			for _, provider := range util.GetProviderName(resolved) {
+				if policyAllowsProvider(policy, provider) {
+					allowed = true
+					break
+				}
+			}
+			if !allowed {
+				continue
+			}
+		}
+		out = append(out, model)
+	}
+	return out
+}
+
+func policyAllowsModel(policy config.APIKeyPolicy, model string) bool {
+	if len(policy.AllowedModels) == 0 {
+		return true
+	}
+	for _, pattern := range policy.AllowedModels {
+		if matchModelPattern(pattern, model) {
+			return true
+		}
+	}
+	return false
+}
+
+func policyAllowsPrefix(policy config.APIKeyPolicy, model string) bool {
+	if len(policy.AllowedPrefixes) == 0 {
+		return true
+	}
+	for _, prefix := range policy.AllowedPrefixes {
+		if strings.HasPrefix(model, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func policyAllowsProvider(policy config.APIKeyPolicy, provider string) bool {
+	for _, allowed := range policy.AllowedProviders {
+		if strings.EqualFold(allowed, provider) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchModelPattern reports whether model matches pattern case-insensitively, where "*"
+// matches any run of characters.
+func matchModelPattern(pattern, model string) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	model = strings.ToLower(strings.TrimSpace(model))
+	if !strings.Contains(pattern, "*") {
+		return pattern == model
+	}
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(model, parts[0]) {
+		return false
+	}
+	model = model[len(parts[0]):]
+	last := len(parts) - 1
+	for _, part := range parts[1:last] {
+		idx := strings.Index(model, part)
+		if idx < 0 {
+			return false
+		}
+		model = model[idx+len(part):]
+	}
+	return strings.HasSuffix(model, parts[last])
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestAPIKeyPolicy_RestrictsModelsProvidersAndExpiry(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &BaseAPIHandler{Cfg: &sdkconfig.SDKConfig{APIKeyPolicies: []sdkconfig.APIKeyPolicy{
+		{APIKey: "limited", AllowedModels: []string{"gemini-2.5-*"}, AllowedProviders: []string{"gemini"}},
+		{APIKey: "models-only", AllowedModels: []string{"gemini-2.5-*"}},
+		{APIKey: "expired", ExpiresAt: "2020-01-01T00:00:00Z"},
+	}}}
+	ctxFor := func(key string) (context.Context, *gin.Context) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Set("apiKey", key)
+		return context.WithValue(context.Background(), "gin", c), c
+	}
+
+	ctx, _ := ctxFor("limited")
+	providers, errMsg := h.enforceAPIKeyPolicy(ctx, "gemini-2.5-pro", "gemini-2.5-pro", []string{"gemini", "vertex"})
+	if errMsg != nil || len(providers) != 1 || providers[0] != "gemini" {
+		t.Fatalf("allowed model = %v, %v; want [gemini]", providers, errMsg)
+	}
+	if _, errMsg = h.enforceAPIKeyPolicy(ctx, "gpt-5", "gpt-5", []string{"codex"}); errMsg == nil || errMsg.StatusCode != http.StatusForbidden {
+		t.Fatalf("disallowed model error = %v, want 403", errMsg)
+	}
+	_, c := ctxFor("models-only")
+	listing := h.FilterModelsForAPIKey(c, "openai", []map[string]any{{"id": "gemini-2.5-pro"}, {"id": "gpt-5"}})
+	if len(listing) != 1 || listing[0]["id"] != "gemini-2.5-pro" {
+		t.Fatalf("listing = %v, want only gemini-2.5-pro", listing)
+	}
+
+	ctx, c = ctxFor("expired")
+	if _, errMsg = h.enforceAPIKeyPolicy(ctx, "gpt-5", "gpt-5", []string{"codex"}); errMsg == nil || errMsg.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expired key error = %v, want 401", errMsg)
+	}
+	if listing = h.FilterModelsForAPIKey(c, "openai", []map[string]any{{"id": "gpt-5"}}); len(listing) != 0 {
+		t.Fatalf("expired listing = %v, want empty", listing)
+	}
+
+	ctx, c = ctxFor("unrestricted")
+	if providers, errMsg = h.enforceAPIKeyPolicy(ctx, "gpt-5", "gpt-5", []string{"codex"}); errMsg != nil || len(providers) != 1 {
+		t.Fatalf("unrestricted key = %v, %v", providers, errMsg)
+	}
+	if listing = h.FilterModelsForAPIKey(c, "openai", []map[string]any{{"id": "gpt-5"}}); len(listing) != 1 {
+		t.Fatalf("unrestricted listing = %v, want unchanged", listing)
+	}
+}
+
+// modelFailingExecutor fails requests for one model and records every model it serves.
+type modelFailingExecutor struct {
+	mu     sync.Mutex
+	fail   string
+	models []string
+}
+
+func (e *modelFailingExecutor) Identifier() string { return "policy-fallback" }
+
+func (e *modelFailingExecutor) Execute(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	e.mu.Lock()
+	e.models = append(e.models, req.Model)
+	e.mu.Unlock()
+	if req.Model == e.fail {
+		return coreexecutor.Response{}, &coreauth.Error{Code: "rate_limited", Message: "rate limited", HTTPStatus: http.StatusTooManyRequests}
+	}
+	return coreexecutor.Response{Payload: []byte(req.Model)}, nil
+}
+
+func (e *modelFailingExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "ExecuteStream not implemented"}
+}
+
+func (e *modelFailingExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *modelFailingExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func TestExecuteWithAuthManager_FallbackHonoursAPIKeyPolicy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	executor := &modelFailingExecutor{fail: "policy-fb-a"}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "policy-fallback-auth", Provider: "policy-fallback", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register() error = %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "policy-fb-a"}, {ID: "policy-fb-b"}, {ID: "policy-fb-c"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+	manager.SetModelFallbacks(map[string][]string{"policy-fb-a": {"policy-fb-b", "policy-fb-c"}})
+
+	h := NewBaseAPIHandlers(&sdkconfig.SDKConfig{APIKeyPolicies: []sdkconfig.APIKeyPolicy{
+		{APIKey: "limited", AllowedModels: []string{"policy-fb-a", "policy-fb-c"}},
+	}}, manager)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Set("apiKey", "limited")
+	ctx := context.WithValue(context.Background(), "gin", c)
+
+	resp, errMsg := h.ExecuteWithAuthManager(ctx, "openai", "policy-fb-a", []byte(`{"model":"policy-fb-a"}`), "")
+	if errMsg != nil {
+		t.Fatalf("ExecuteWithAuthManager() error = %v", errMsg.Error)
+	}
+	if string(resp) != "policy-fb-c" {
+		t.Fatalf("served model = %q, want policy-fb-c", resp)
+	}
+	executor.mu.Lock()
+	defer executor.mu.Unlock()
+	for _, model := range executor.models {
+		if model == "policy-fb-b" {
+			t.Fatalf("executor served disallowed fallback: %v", executor.models)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package gemini
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	// batchConcurrency bounds the batch items executed at once for one request.
+	batchConcurrency = 8
+	// maxBatchItems bounds the inlined requests accepted by one batchGenerateContent call,
+	// which runs them all before answering.
+	maxBatchItems = 100
+
+	batchMetadataType = "type.googleapis.com/google.ai.generativelanguage.v1beta.GenerateContentBatch"
+	batchOutputType   = "type.googleapis.com/google.ai.generativelanguage.v1beta.GenerateContentBatchOutput"
+)
+
+// finishedBatches keeps completed batches so clients can poll them with GET /v1beta/batches/{id}.
+var finishedBatches = cache.NewMemoryResponseStore(24*time.Hour, 1000, 100<<20)
+
+// handleBatchGenerateContent runs the inlined requests of a batchGenerateContent call through
+// the regular generateContent path, so every backend can serve them, and answers with the
+// finished batch operation. Items run concurrently and are each charged against the client
+// key's rate limits; each item's failure is reported in its own slot of the output.
+func (h *GeminiAPIHandler) handleBatchGenerateContent(c *gin.Context, modelName string, rawJSON []byte) {
+	batchJSON := gjson.GetBytes(rawJSON, "batch")
+	if !batchJSON.Exists() {
+		batchJSON = gjson.ParseBytes(rawJSON)
+	}
+	requests := batchJSON.Get("inputConfig.requests.requests")
+	if !requests.IsArray() {
+		message := "batch.inputConfig.requests is required; file-based batch input is not supported."
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": http.StatusBadRequest, "message": message, "status": "INVALID_ARGUMENT"}})
+		return
+	}
+	items := requests.Array()
+	if len(items) > maxBatchItems {
+		message := fmt.Sprintf("batch.inputConfig.requests has %d requests; at most %d are supported.", len(items), maxBatchItems)
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": http.StatusBadRequest, "message": message, "status": "INVALID_ARGUMENT"}})
+		return
+	}
+	createdAt := time.Now().UTC()
+
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	defer cliCancel()
+	responses := make([]json.RawMessage, len(items))
+	var succeeded int
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, batchConcurrency)
+	for i, item := range items {
+		select {
+		case slots <- struct{}{}:
+		case <-cliCtx.Done():
+		}
+		if cliCtx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(i int, item gjson.Result) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			out, ok := h.executeBatchItem(cliCtx, modelName, item)
+			mu.Lock()
+			responses[i] = out
+			if ok {
+				succeeded++
+			}
+			mu.Unlock()
+		}(i, item)
+	}
+	wg.Wait()
+	if cliCtx.Err() != nil {
+		// The client went away; nobody is left to receive the batch.
+		return
+	}
+
+	var buf [8]byte
+	_, _ = rand.Read(buf[:])
+	name := "batches/" + hex.EncodeToString(buf[:])
+	model := modelName
+	if !strings.HasPrefix(model, "models/") {
+		model = "models/" + model
+	}
+	inlined, _ := json.Marshal(map[string]any{"inlinedResponses": responses})
+	metadata := map[string]any{
+		"@type":       batchMetadataType,
+		"name":        name,
+		"model":       model,
+		"displayName": batchJSON.Get("displayName").String(),
+		"state":       "BATCH_STATE_SUCCEEDED",
+		"createTime":  createdAt.Format(time.RFC3339Nano),
+		"updateTime":  time.Now().UTC().Format(time.RFC3339Nano),
+		"endTime":     time.Now().UTC().Format(time.RFC3339Nano),
+		"output":      map[string]json.RawMessage{"inlinedResponses": inlined},
+		"batchStats": map[string]string{
+			"requestCount":           strconv.Itoa(len(items)),
+			"successfulRequestCount": strconv.Itoa(succeeded),
+			"failedRequestCount":     strconv.Itoa(len(items) - succeeded),
+		},
+	}
+	operation, err := json.Marshal(map[string]any{
+		"name":     name,
+		"metadata": metadata,
+		"done":     true,
+		"response": map[string]any{"@type": batchOutputType, "inlinedResponses": json.RawMessage(inlined)},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": gin.H{"code": http.StatusInternalServerError, "message": err.Error(), "status": "INTERNAL"}})
+		return
+	}
+	finishedBatches.Set(batchStoreKey(c, name), operation)
+	c.Data(http.StatusOK, "application/json", operation)
+}
+
+// executeBatchItem runs one inlined request and renders its InlinedResponse, reporting
+// whether it succeeded.
+func (h *GeminiAPIHandler) executeBatchItem(ctx context.Context, modelName string, item gjson.Result) (json.RawMessage, bool) {
+	out := `{}`
+	if metadata := item.Get("metadata"); metadata.Exists() {
+		out, _ = sjson.SetRaw(out, "metadata", metadata.Raw)
+	}
+	request := item.Get("request")
+	if !request.IsObject() {
+		out, _ = sjson.SetRaw(out, "error", `{"code":400,"message":"request is required","status":"INVALID_ARGUMENT"}`)
+		return json.RawMessage(out), false
+	}
+	payload, _ := sjson.DeleteBytes([]byte(request.Raw), "model")
+	release, errMsg := h.AcquireRequests(ctx, h.HandlerType(), 1, false)
+	var resp []byte
+	if errMsg == nil {
+		resp, errMsg = h.ExecuteWithAuthManager(ctx, h.HandlerType(), modelName, payload, "")
+		release()
+	}
+	if errMsg != nil {
+		status := http.StatusInternalServerError
+		if errMsg.StatusCode > 0 {
+			status = errMsg.StatusCode
+		}
+		errText := http.StatusText(status)
+		if errMsg.Error != nil && errMsg.Error.Error() != "" {
+			errText = errMsg.Error.Error()
+		}
+		body := handlers.BuildErrorResponseBodyForFormat(h.HandlerType(), status, errText)
+		errJSON := gjson.GetBytes(body, "error")
+		if !errJSON.IsObject() {
+			errJSON = gjson.Parse(fmt.Sprintf(`{"code":%d,"message":%q}`, status, errText))
+		}
+		out, _ = sjson.SetRaw(out, "error", errJSON.Raw)
+		return json.RawMessage(out), false
+	}
+	if !gjson.ValidBytes(resp) {
+		out, _ = sjson.SetRaw(out, "error", `{"code":500,"message":"invalid upstream response","status":"INTERNAL"}`)
+		return json.RawMessage(out), false
+	}
+	out, _ = sjson.SetRaw(out, "response", string(resp))
+	return json.RawMessage(out), true
+}
+
+// GeminiBatchGet handles GET /v1beta/batches/{id} for batches created by batchGenerateContent.
+func (h *GeminiAPIHandler) GeminiBatchGet(c *gin.Context) {
+	name := "batches/" + strings.TrimPrefix(c.Param("id"), "batches/")
+	operation, ok := finishedBatches.Get(batchStoreKey(c, name))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": gin.H{"code": http.StatusNotFound, "message": fmt.Sprintf("Batch %s not found.", name), "status": "NOT_FOUND"}})
+		return
+	}
+	c.Data(http.StatusOK, "application/json", operation)
+}
+
+// batchStoreKey scopes a stored batch to the client key that created it.
+func batchStoreKey(c *gin.Context, name string) string {
+	return c.GetString("apiKey") + "\x00" + name
+}
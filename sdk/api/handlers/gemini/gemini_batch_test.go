@@ -0,0 +1,162 @@
+package gemini
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/ratelimit"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+type batchTestExecutor struct{}
+
+func (batchTestExecutor) Identifier() string { return "gemini" }
+
+func (batchTestExecutor) Execute(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	if strings.Contains(string(req.Payload), "fail") {
+		return coreexecutor.Response{}, &coreauth.Error{Code: "invalid_request", Message: "rejected", HTTPStatus: http.StatusBadRequest}
+	}
+	return coreexecutor.Response{Payload: []byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":"ok"}]}}]}`)}, nil
+}
+
+func (batchTestExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "ExecuteStream not implemented"}
+}
+
+func (batchTestExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (batchTestExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func newBatchTestHandler(t *testing.T) *GeminiAPIHandler {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(batchTestExecutor{})
+	auth := &coreauth.Auth{ID: "batch-test-auth", Provider: "gemini", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register() error: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "batch-test-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+	return NewGeminiAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager))
+}
+
+func serveGemini(h *GeminiAPIHandler, apiKey, method, path, body string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Set("apiKey", apiKey)
+	c.Request = httptest.NewRequest(method, "/v1beta"+path, bytes.NewBufferString(body))
+	if strings.HasPrefix(path, "/batches/") {
+		c.Params = gin.Params{{Key: "id", Value: strings.TrimPrefix(path, "/batches/")}}
+		h.GeminiBatchGet(c)
+	} else {
+		c.Params = gin.Params{{Key: "action", Value: strings.TrimPrefix(path, "/models")}}
+		h.GeminiHandler(c)
+	}
+	return recorder
+}
+
+func TestBatchGenerateContent_FansOutAndReassembles(t *testing.T) {
+	h := newBatchTestHandler(t)
+	body := `{"batch":{"displayName":"nightly","inputConfig":{"requests":{"requests":[` +
+		`{"request":{"contents":[{"role":"user","parts":[{"text":"one"}]}]},"metadata":{"key":"a"}},` +
+		`{"request":{"contents":[{"role":"user","parts":[{"text":"fail"}]}]},"metadata":{"key":"b"}},` +
+		`{"request":{"contents":[{"role":"user","parts":[{"text":"three"}]}]},"metadata":{"key":"c"}}]}}}}`
+	recorder := serveGemini(h, "key-a", http.MethodPost, "/models/batch-test-model:batchGenerateContent", body)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", recorder.Code, recorder.Body.String())
+	}
+	operation := gjson.ParseBytes(recorder.Body.Bytes())
+	if !operation.Get("done").Bool() || operation.Get("metadata.state").String() != "BATCH_STATE_SUCCEEDED" {
+		t.Fatalf("operation = %s", operation.Raw)
+	}
+	if stats := operation.Get("metadata.batchStats"); stats.Get("successfulRequestCount").String() != "2" || stats.Get("failedRequestCount").String() != "1" {
+		t.Fatalf("batchStats = %s", stats.Raw)
+	}
+	responses := operation.Get("response.inlinedResponses.inlinedResponses").Array()
+	if len(responses) != 3 {
+		t.Fatalf("inlinedResponses = %s", operation.Get("response").Raw)
+	}
+	for i, key := range []string{"a", "b", "c"} {
+		if responses[i].Get("metadata.key").String() != key {
+			t.Fatalf("response %d metadata = %s", i, responses[i].Raw)
+		}
+	}
+	if responses[0].Get("response.candidates.0.content.parts.0.text").String() != "ok" || responses[1].Get("error.code").Int() != http.StatusBadRequest {
+		t.Fatalf("inlinedResponses = %s", operation.Get("response").Raw)
+	}
+
+	name := operation.Get("name").String()
+	if got := serveGemini(h, "key-a", http.MethodGet, "/"+name, ""); got.Code != http.StatusOK || gjson.GetBytes(got.Body.Bytes(), "name").String() != name {
+		t.Fatalf("GET %s = %d %s", name, got.Code, got.Body.String())
+	}
+	if got := serveGemini(h, "key-b", http.MethodGet, "/"+name, ""); got.Code != http.StatusNotFound {
+		t.Fatalf("GET %s by another key = %d", name, got.Code)
+	}
+
+	fileInput := `{"batch":{"inputConfig":{"fileName":"files/abc"}}}`
+	if got := serveGemini(h, "key-a", http.MethodPost, "/models/batch-test-model:batchGenerateContent", fileInput); got.Code != http.StatusBadRequest {
+		t.Fatalf("file input status = %d", got.Code)
+	}
+}
+
+func TestBatchGenerateContent_CapsItemsAndChargesEachAgainstRateLimit(t *testing.T) {
+	ratelimit.Default().SetLimits([]config.APIKeyLimit{{APIKey: "batch-limited", RequestsPerMinute: 2}})
+	t.Cleanup(func() { ratelimit.Default().SetLimits(nil) })
+	h := newBatchTestHandler(t)
+	path := "/models/batch-test-model:batchGenerateContent"
+
+	items := make([]string, maxBatchItems+1)
+	for i := range items {
+		items[i] = fmt.Sprintf(`{"request":{"contents":[{"role":"user","parts":[{"text":"%d"}]}]}}`, i)
+	}
+	oversized := `{"batch":{"inputConfig":{"requests":{"requests":[` + strings.Join(items, ",") + `]}}}}`
+	if got := serveGemini(h, "batch-limited", http.MethodPost, path, oversized); got.Code != http.StatusBadRequest {
+		t.Fatalf("oversized batch status = %d, want 400", got.Code)
+	}
+
+	body := `{"batch":{"inputConfig":{"requests":{"requests":[` + strings.Join(items[:3], ",") + `]}}}}`
+	recorder := serveGemini(h, "batch-limited", http.MethodPost, path, body)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", recorder.Code, recorder.Body.String())
+	}
+	stats := gjson.GetBytes(recorder.Body.Bytes(), "metadata.batchStats")
+	if stats.Get("successfulRequestCount").String() != "2" || stats.Get("failedRequestCount").String() != "1" {
+		t.Fatalf("batchStats = %s, want the item over the rate limit failed", stats.Raw)
+	}
+	limited := 0
+	for _, response := range gjson.GetBytes(recorder.Body.Bytes(), "response.inlinedResponses.inlinedResponses").Array() {
+		if response.Get("error.code").Int() == http.StatusTooManyRequests {
+			limited++
+		}
+	}
+	if limited != 1 {
+		t.Fatalf("inlinedResponses = %s, want one 429 item", recorder.Body.String())
+	}
+}
+
+func TestCountTokens_FallsBackToLocalEstimate(t *testing.T) {
+	h := newBatchTestHandler(t)
+	body := `{"generateContentRequest":{"model":"models/batch-test-model","contents":[{"role":"user","parts":[{"text":"how many tokens is this sentence"}]}]}}`
+	recorder := serveGemini(h, "", http.MethodPost, "/models/batch-test-model:countTokens", body)
+	if recorder.Code != http.StatusOK || gjson.GetBytes(recorder.Body.Bytes(), "totalTokens").Int() <= 0 {
+		t.Fatalf("countTokens = %d %s", recorder.Code, recorder.Body.String())
+	}
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/guardrail"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+)
+
+// checkGuardrails rejects a request matching a block rule before any credential is used,
+// returning the error in the client's dialect.
+func checkGuardrails(handlerType string, rawJSON []byte) *interfaces.ErrorMessage {
+	violation := guardrail.Active().Check(rawJSON)
+	if violation == nil {
+		return nil
+	}
+	body := BuildErrorResponseBodyForFormat(handlerType, http.StatusBadRequest, violation.Error())
+	return &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: errors.New(string(body))}
+}
+
+// redactRequest applies the redact rules to the client payload before it is routed, so every
+// executor and endpoint, embeddings included, only ever sees the redacted request.
+func redactRequest(rawJSON []byte) []byte {
+	return guardrail.Active().Apply(rawJSON)
+}
+
+// filterResponse applies guardrails to a complete response when response filtering is enabled.
+func filterResponse(payload []byte) []byte {
+	if filter := guardrail.Active(); filter.AppliesToResponses() {
+		return filter.Apply(payload)
+	}
+	return payload
+}
+
+// filterResponseChunk applies guardrails to a streamed chunk when response filtering is enabled.
+// Matches split across chunks are not detected.
+func filterResponseChunk(chunk []byte) []byte {
+	if filter := guardrail.Active(); filter.AppliesToResponses() {
+		return filter.ApplyChunk(chunk)
+	}
+	return chunk
+}
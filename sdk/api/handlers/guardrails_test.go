@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/guardrail"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+// echoExecutor answers with the payload and original request it was handed.
+type echoExecutor struct{}
+
+func (echoExecutor) Identifier() string { return "guardrail-echo" }
+
+func (echoExecutor) Execute(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, opts coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{Payload: append(append(req.Payload, '\n'), opts.OriginalRequest...)}, nil
+}
+
+func (echoExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "ExecuteStream not implemented"}
+}
+
+func (echoExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (echoExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func TestExecuteWithAuthManager_RedactsRequestBeforeExecutors(t *testing.T) {
+	guardrail.Configure(config.GuardrailConfig{Enabled: true, Rules: []config.GuardrailRule{{Detector: guardrail.DetectorEmail}}})
+	t.Cleanup(func() { guardrail.Configure(config.GuardrailConfig{}) })
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(echoExecutor{})
+	auth := &coreauth.Auth{ID: "guardrail-echo-auth", Provider: "guardrail-echo", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register() error = %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "guardrail-embedding"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	h := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager)
+	body := []byte(`{"model":"guardrail-embedding","input":["write to jane@example.com"]}`)
+	resp, errMsg := h.ExecuteWithAuthManager(context.Background(), "openai", "guardrail-embedding", body, "")
+	if errMsg != nil {
+		t.Fatalf("ExecuteWithAuthManager() error = %v", errMsg.Error)
+	}
+	if strings.Contains(string(resp), "jane@example.com") || strings.Count(string(resp), "[REDACTED:email]") != 2 {
+		t.Fatalf("executor saw %s, want the payload and original request redacted", resp)
+	}
+}
@@ -9,17 +9,23 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/routing"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/tracing"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
 	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 )
 
@@ -45,6 +51,10 @@ type ErrorDetail struct {
 
 const idempotencyKeyMetadataKey = "idempotency_key"
 
+// servedModelHeader reports the model that actually served a request, which differs from
+// the requested model when a fallback chain was used.
+const servedModelHeader = "X-CPA-Served-Model"
+
 const (
 	defaultStreamingKeepAliveSeconds = 0
 	defaultStreamingBootstrapRetries = 0
@@ -100,6 +110,71 @@ func BuildErrorResponseBody(status int, errText string) []byte {
 	return payload
 }
 
+// BuildErrorResponseBodyForFormat builds an error response body in the dialect of the given
+// client format. Claude and Gemini clients receive their native error envelopes; all other
+// formats use the OpenAI-compatible body produced by BuildErrorResponseBody.
+func BuildErrorResponseBodyForFormat(format string, status int, errText string) []byte {
+	body := BuildErrorResponseBody(status, errText)
+	if status <= 0 {
+		status = http.StatusInternalServerError
+	}
+	var detail ErrorResponse
+	if err := json.Unmarshal(body, &detail); err != nil || detail.Error.Message == "" {
+		return body
+	}
+
+	switch format {
+	case "claude":
+		payload, err := json.Marshal(map[string]any{
+			"type": "error",
+			"error": map[string]string{
+				"type":    detail.Error.Type,
+				"message": detail.Error.Message,
+			},
+		})
+		if err == nil {
+			return payload
+		}
+	case "gemini", "gemini-cli":
+		payload, err := json.Marshal(map[string]any{
+			"error": map[string]any{
+				"code":    status,
+				"message": detail.Error.Message,
+				"status":  geminiErrorStatus(status),
+			},
+		})
+		if err == nil {
+			return payload
+		}
+	}
+	return body
+}
+
+// geminiErrorStatus maps an HTTP status code to the canonical Google RPC status name.
+func geminiErrorStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "INVALID_ARGUMENT"
+	case http.StatusUnauthorized:
+		return "UNAUTHENTICATED"
+	case http.StatusForbidden:
+		return "PERMISSION_DENIED"
+	case http.StatusNotFound:
+		return "NOT_FOUND"
+	case http.StatusTooManyRequests:
+		return "RESOURCE_EXHAUSTED"
+	case http.StatusServiceUnavailable:
+		return "UNAVAILABLE"
+	case http.StatusGatewayTimeout:
+		return "DEADLINE_EXCEEDED"
+	default:
+		if status >= http.StatusInternalServerError {
+			return "INTERNAL"
+		}
+		return "UNKNOWN"
+	}
+}
+
 // StreamingKeepAliveInterval returns the SSE keep-alive interval for this server.
 // Returning 0 disables keep-alives (default when unset).
 func StreamingKeepAliveInterval(cfg *config.SDKConfig) time.Duration {
@@ -128,16 +203,22 @@ func StreamingBootstrapRetries(cfg *config.SDKConfig) int {
 func requestExecutionMetadata(ctx context.Context) map[string]any {
 	// Idempotency-Key is an optional client-supplied header used to correlate retries.
 	// It is forwarded as execution metadata; when absent we generate a UUID.
-	key := ""
+	// The client API key is forwarded so the conductor can queue requests fairly per key.
+	key, clientKey := "", ""
 	if ctx != nil {
 		if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil && ginCtx.Request != nil {
 			key = strings.TrimSpace(ginCtx.GetHeader("Idempotency-Key"))
+			clientKey = ginCtx.GetString("apiKey")
 		}
 	}
 	if key == "" {
 		key = uuid.NewString()
 	}
-	return map[string]any{idempotencyKeyMetadataKey: key}
+	meta := map[string]any{idempotencyKeyMetadataKey: key}
+	if clientKey != "" {
+		meta[coreexecutor.ClientAPIKeyMetadataKey] = clientKey
+	}
+	return meta
 }
 
 func mergeMetadata(base, overlay map[string]any) map[string]any {
@@ -163,6 +244,9 @@ type BaseAPIHandler struct {
 
 	// Cfg holds the current application configuration.
 	Cfg *config.SDKConfig
+
+	// router holds the model routing rules compiled from Cfg.
+	router atomic.Pointer[routing.ModelRouter]
 }
 
 // NewBaseAPIHandlers creates a new API handlers instance.
@@ -175,10 +259,12 @@ type BaseAPIHandler struct {
 // Returns:
 //   - *BaseAPIHandler: A new API handlers instance
 func NewBaseAPIHandlers(cfg *config.SDKConfig, authManager *coreauth.Manager) *BaseAPIHandler {
-	return &BaseAPIHandler{
+	h := &BaseAPIHandler{
 		Cfg:         cfg,
 		AuthManager: authManager,
 	}
+	h.router.Store(h.compileModelRouter())
+	return h
 }
 
 // UpdateClients updates the handlers' client list and configuration.
@@ -187,7 +273,10 @@ func NewBaseAPIHandlers(cfg *config.SDKConfig, authManager *coreauth.Manager) *B
 // Parameters:
 //   - clients: The new slice of AI service clients
 //   - cfg: The new application configuration
-func (h *BaseAPIHandler) UpdateClients(cfg *config.SDKConfig) { h.Cfg = cfg }
+func (h *BaseAPIHandler) UpdateClients(cfg *config.SDKConfig) {
+	h.Cfg = cfg
+	h.router.Store(h.compileModelRouter())
+}
 
 // GetAlt extracts the 'alt' parameter from the request query string.
 // It checks both 'alt' and '$alt' parameters and returns the appropriate value.
@@ -240,6 +329,9 @@ func (h *BaseAPIHandler) GetContextWithCancel(handler interfaces.APIHandler, c *
 			parentCtx = logging.WithRequestID(parentCtx, requestID)
 		}
 	}
+	if requestCtx != nil && tracing.SpanFromContext(parentCtx) == nil {
+		parentCtx = tracing.ContextWithSpan(parentCtx, tracing.SpanFromContext(requestCtx))
+	}
 	newCtx, cancel := context.WithCancel(parentCtx)
 	if requestCtx != nil && requestCtx != parentCtx {
 		go func() {
@@ -252,6 +344,7 @@ func (h *BaseAPIHandler) GetContextWithCancel(handler interfaces.APIHandler, c *
 	}
 	newCtx = context.WithValue(newCtx, "gin", c)
 	newCtx = context.WithValue(newCtx, "handler", handler)
+	newCtx = context.WithValue(newCtx, servedModelHeaderLockKey{}, &sync.Mutex{})
 	return newCtx, func(params ...interface{}) {
 		if h.Cfg.RequestLog && len(params) == 1 {
 			if existing, exists := c.Get("API_RESPONSE"); exists {
@@ -318,10 +411,27 @@ func appendAPIResponse(c *gin.Context, data []byte) {
 // ExecuteWithAuthManager executes a non-streaming request via the core auth manager.
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, *interfaces.ErrorMessage) {
-	providers, normalizedModel, metadata, errMsg := h.getRequestDetails(modelName)
+	providers, normalizedModel, metadata, errMsg := h.getRequestDetails(ctx, modelName)
+	if errMsg != nil {
+		return nil, errMsg
+	}
+	if errMsg = checkGuardrails(handlerType, rawJSON); errMsg != nil {
+		return nil, errMsg
+	}
+	rawJSON = redactRequest(rawJSON)
+	fittedModel, rawJSON, errMsg := h.fitContextWindow(handlerType, normalizedModel, rawJSON)
 	if errMsg != nil {
 		return nil, errMsg
 	}
+	if fittedModel != normalizedModel {
+		if providers, normalizedModel, metadata, errMsg = h.getRequestDetails(ctx, contextRerouteModel(fittedModel, normalizedModel, metadata)); errMsg != nil {
+			return nil, errMsg
+		}
+	}
+	ctx, cacheKey, cached, hit := lookupResponseCache(ctx, handlerType, normalizedModel, alt, rawJSON)
+	if hit {
+		return filterResponse(cached), nil
+	}
 	reqMeta := requestExecutionMetadata(ctx)
 	req := coreexecutor.Request{
 		Model:   normalizedModel,
@@ -337,7 +447,7 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 		SourceFormat:    sdktranslator.FromString(handlerType),
 	}
 	opts.Metadata = mergeMetadata(cloneMetadata(metadata), reqMeta)
-	resp, err := h.AuthManager.Execute(ctx, providers, req, opts)
+	resp, err := h.AuthManager.Execute(h.withFallbackPolicy(withServedModelHeader(ctx)), providers, req, opts)
 	if err != nil {
 		status := http.StatusInternalServerError
 		if se, ok := err.(interface{ StatusCode() int }); ok && se != nil {
@@ -353,16 +463,23 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 		}
 		return nil, &interfaces.ErrorMessage{StatusCode: status, Error: err, Addon: addon}
 	}
-	return cloneBytes(resp.Payload), nil
+	if cacheKey != "" {
+		cache.DefaultResponseCache().Set(cacheKey, resp.Payload)
+	}
+	return filterResponse(cloneBytes(resp.Payload)), nil
 }
 
 // ExecuteCountWithAuthManager executes a non-streaming request via the core auth manager.
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, *interfaces.ErrorMessage) {
-	providers, normalizedModel, metadata, errMsg := h.getRequestDetails(modelName)
+	providers, normalizedModel, metadata, errMsg := h.getRequestDetails(ctx, modelName)
 	if errMsg != nil {
 		return nil, errMsg
 	}
+	if errMsg = checkGuardrails(handlerType, rawJSON); errMsg != nil {
+		return nil, errMsg
+	}
+	rawJSON = redactRequest(rawJSON)
 	reqMeta := requestExecutionMetadata(ctx)
 	req := coreexecutor.Request{
 		Model:   normalizedModel,
@@ -380,12 +497,19 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 	opts.Metadata = mergeMetadata(cloneMetadata(metadata), reqMeta)
 	resp, err := h.AuthManager.ExecuteCount(ctx, providers, req, opts)
 	if err != nil {
-		status := http.StatusInternalServerError
+		var code int
 		if se, ok := err.(interface{ StatusCode() int }); ok && se != nil {
-			if code := se.StatusCode(); code > 0 {
-				status = code
+			code = se.StatusCode()
+		}
+		if lacksCountEndpoint(code) {
+			if local, ok := localTokenCount(ctx, handlerType, normalizedModel, rawJSON); ok {
+				return local, nil
 			}
 		}
+		status := http.StatusInternalServerError
+		if code > 0 {
+			status = code
+		}
 		var addon http.Header
 		if he, ok := err.(interface{ Headers() http.Header }); ok && he != nil {
 			if hdr := he.Headers(); hdr != nil {
@@ -400,7 +524,18 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 // ExecuteStreamWithAuthManager executes a streaming request via the core auth manager.
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) (<-chan []byte, <-chan *interfaces.ErrorMessage) {
-	providers, normalizedModel, metadata, errMsg := h.getRequestDetails(modelName)
+	providers, normalizedModel, metadata, errMsg := h.getRequestDetails(ctx, modelName)
+	if errMsg == nil {
+		errMsg = checkGuardrails(handlerType, rawJSON)
+	}
+	if errMsg == nil {
+		rawJSON = redactRequest(rawJSON)
+		var fittedModel string
+		fittedModel, rawJSON, errMsg = h.fitContextWindow(handlerType, normalizedModel, rawJSON)
+		if errMsg == nil && fittedModel != normalizedModel {
+			providers, normalizedModel, metadata, errMsg = h.getRequestDetails(ctx, contextRerouteModel(fittedModel, normalizedModel, metadata))
+		}
+	}
 	if errMsg != nil {
 		errChan := make(chan *interfaces.ErrorMessage, 1)
 		errChan <- errMsg
@@ -422,6 +557,7 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 		SourceFormat:    sdktranslator.FromString(handlerType),
 	}
 	opts.Metadata = mergeMetadata(cloneMetadata(metadata), reqMeta)
+	ctx = h.withFallbackPolicy(withServedModelHeader(ctx))
 	chunks, err := h.AuthManager.ExecuteStream(ctx, providers, req, opts)
 	if err != nil {
 		errChan := make(chan *interfaces.ErrorMessage, 1)
@@ -514,7 +650,7 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 				}
 				if len(chunk.Payload) > 0 {
 					sentPayload = true
-					dataChan <- cloneBytes(chunk.Payload)
+					dataChan <- filterResponseChunk(cloneBytes(chunk.Payload))
 				}
 			}
 		}
@@ -522,6 +658,59 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 	return dataChan, errChan
 }
 
+// servedModelHeaderLockKey stores the per-request mutex serializing served-model header
+// writes from upstream requests a handler fans out concurrently under one client request.
+type servedModelHeaderLockKey struct{}
+
+// servedModelHeaderOffKey marks contexts whose executions must not set the served-model header.
+type servedModelHeaderOffKey struct{}
+
+// WithoutServedModelHeader returns a context whose executions leave the served-model
+// response header alone. Handlers use it for upstream requests started after the client
+// response may already be under way, where a header write would race with it.
+func WithoutServedModelHeader(ctx context.Context) context.Context {
+	return context.WithValue(ctx, servedModelHeaderOffKey{}, true)
+}
+
+// withServedModelHeader attaches a callback that exposes the served model as a response header.
+func withServedModelHeader(ctx context.Context) context.Context {
+	if ctx == nil {
+		return ctx
+	}
+	if off, _ := ctx.Value(servedModelHeaderOffKey{}).(bool); off {
+		return ctx
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return ctx
+	}
+	mu, ok := ctx.Value(servedModelHeaderLockKey{}).(*sync.Mutex)
+	if !ok {
+		mu = &sync.Mutex{}
+	}
+	return coreauth.WithServedModelCallback(ctx, func(model string) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !ginCtx.Writer.Written() {
+			ginCtx.Header(servedModelHeader, model)
+		}
+	})
+}
+
+// withFallbackPolicy applies the client key policy to the models of fallback chains.
+func (h *BaseAPIHandler) withFallbackPolicy(ctx context.Context) context.Context {
+	if _, ok := h.apiKeyPolicy(ctx); !ok {
+		return ctx
+	}
+	return coreauth.WithFallbackFilter(ctx, func(model string, providers []string) []string {
+		allowed, errMsg := h.enforceAPIKeyPolicy(ctx, model, model, providers)
+		if errMsg != nil {
+			return nil
+		}
+		return allowed
+	})
+}
+
 func statusFromError(err error) int {
 	if err == nil {
 		return 0
@@ -534,16 +723,31 @@ func statusFromError(err error) int {
 	return 0
 }
 
-func (h *BaseAPIHandler) getRequestDetails(modelName string) (providers []string, normalizedModel string, metadata map[string]any, err *interfaces.ErrorMessage) {
+func (h *BaseAPIHandler) getRequestDetails(ctx context.Context, modelName string) (providers []string, normalizedModel string, metadata map[string]any, err *interfaces.ErrorMessage) {
 	// Resolve "auto" model to an actual available model first
 	resolvedModelName := util.ResolveAutoModel(modelName)
 
+	// Apply model routing to the requested name, or to its base name when the client
+	// added a thinking suffix, keeping the suffix metadata from the request.
+	router := h.modelRouter()
+	route, routed := router.Resolve(resolvedModelName)
+	if routed {
+		resolvedModelName = route.Model
+	}
+
 	// Normalize the model name to handle dynamic thinking suffixes before determining the provider.
 	normalizedModel, metadata = normalizeModelMetadata(resolvedModelName)
+	if !routed {
+		if route, routed = router.Resolve(normalizedModel); routed {
+			// Re-apply the client's suffix to the route target so the metadata names the
+			// model that is actually sent upstream instead of the routed alias.
+			normalizedModel, metadata = normalizeModelMetadata(withThinkingSuffix(route.Model, resolvedModelName, normalizedModel))
+		}
+	}
 
 	// Use the normalizedModel to get the provider name.
 	providers = util.GetProviderName(normalizedModel)
-	if len(providers) == 0 && metadata != nil {
+	if len(providers) == 0 && metadata != nil && !routed {
 		if originalRaw, ok := metadata[util.ThinkingOriginalModelMetadataKey]; ok {
 			if originalModel, okStr := originalRaw.(string); okStr {
 				originalModel = strings.TrimSpace(originalModel)
@@ -557,9 +761,21 @@ func (h *BaseAPIHandler) getRequestDetails(modelName string) (providers []string
 		}
 	}
 
+	// Apply the virtual key policy of the client key before reporting unknown models so
+	// expired and restricted keys learn nothing about the model catalog.
+	if providers, err = h.enforceAPIKeyPolicy(ctx, modelName, normalizedModel, providers); err != nil {
+		return nil, "", nil, err
+	}
+
 	if len(providers) == 0 {
 		return nil, "", nil, &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: fmt.Errorf("unknown provider for model %s", modelName)}
 	}
+	if routed {
+		if providers = route.FilterProviders(providers); len(providers) == 0 {
+			return nil, "", nil, &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: fmt.Errorf("model routing rule %q allows no available provider for model %s", route.Rule, modelName)}
+		}
+		log.Debugf("model routing: %s -> %s via rule %q", modelName, normalizedModel, route.Rule)
+	}
 
 	// If it's a dynamic model, the normalizedModel was already set to extractedModelName.
 	// If it's a non-dynamic model, normalizedModel was set by normalizeModelMetadata.
@@ -568,6 +784,28 @@ func (h *BaseAPIHandler) getRequestDetails(modelName string) (providers []string
 	return providers, normalizedModel, metadata, nil
 }
 
+// ResolveProviders returns the providers and normalized model a request for modelName
+// would be routed to, applying model routing and the client key policy. It returns nil
+// when the model cannot be served.
+func (h *BaseAPIHandler) ResolveProviders(ctx context.Context, modelName string) ([]string, string) {
+	providers, normalizedModel, _, errMsg := h.getRequestDetails(ctx, modelName)
+	if errMsg != nil {
+		return nil, ""
+	}
+	return providers, normalizedModel
+}
+
+// withThinkingSuffix returns target carrying the thinking suffix that requested adds on
+// top of its base name, replacing any suffix target already has.
+func withThinkingSuffix(target, requested, base string) string {
+	suffix := strings.TrimPrefix(requested, base)
+	if suffix == requested || suffix == "" {
+		return target
+	}
+	targetBase, _ := normalizeModelMetadata(target)
+	return targetBase + suffix
+}
+
 func cloneBytes(src []byte) []byte {
 	if len(src) == 0 {
 		return nil
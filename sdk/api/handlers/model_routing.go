@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/routing"
+)
+
+// modelRouter returns the router compiled from the current configuration,
+// building it on first use for handlers constructed without NewBaseAPIHandlers.
+func (h *BaseAPIHandler) modelRouter() *routing.ModelRouter {
+	if router := h.router.Load(); router != nil {
+		return router
+	}
+	router := h.compileModelRouter()
+	h.router.CompareAndSwap(nil, router)
+	return h.router.Load()
+}
+
+func (h *BaseAPIHandler) compileModelRouter() *routing.ModelRouter {
+	if h.Cfg == nil {
+		return routing.NewModelRouter(nil)
+	}
+	return routing.NewModelRouter(h.Cfg.ModelRouting)
+}
+
+// WithRoutedModels appends the exact-match routing aliases to a model listing produced
+// for handlerType. An alias is listed only when its target appears in the listing, and
+// inherits the target's metadata.
+func (h *BaseAPIHandler) WithRoutedModels(handlerType string, models []map[string]any) []map[string]any {
+	router := h.modelRouter()
+	aliases := router.Aliases()
+	if len(aliases) == 0 {
+		return models
+	}
+
+	idKey := "id"
+	if handlerType == "gemini" {
+		idKey = "name"
+	}
+	byID := make(map[string]map[string]any, len(models))
+	for _, model := range models {
+		if id, ok := model[idKey].(string); ok {
+			byID[strings.ToLower(strings.TrimPrefix(id, "models/"))] = model
+		}
+	}
+
+	for _, alias := range aliases {
+		key := strings.ToLower(alias.Name)
+		if _, exists := byID[key]; exists {
+			continue
+		}
+		target, ok := byID[strings.ToLower(alias.Target)]
+		if !ok {
+			continue
+		}
+		entry := make(map[string]any, len(target))
+		for k, v := range target {
+			entry[k] = v
+		}
+		if id, _ := target[idKey].(string); strings.HasPrefix(id, "models/") {
+			entry[idKey] = "models/" + alias.Name
+		} else {
+			entry[idKey] = alias.Name
+		}
+		models = append(models, entry)
+		byID[key] = entry
+	}
+	return models
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestGetRequestDetails_RoutedAliasKeepsSuffixOnTarget(t *testing.T) {
+	registry.GetGlobalRegistry().RegisterClient("routing-suffix-client", "codex", []*registry.ModelInfo{{ID: "routing-suffix-target"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient("routing-suffix-client") })
+
+	h := &BaseAPIHandler{Cfg: &config.SDKConfig{ModelRouting: []config.ModelRoute{{Match: "smart", Target: "routing-suffix-target"}}}}
+	providers, model, metadata, errMsg := h.getRequestDetails(context.Background(), "smart(high)")
+	if errMsg != nil {
+		t.Fatalf("getRequestDetails() error = %v", errMsg.Error)
+	}
+	if len(providers) != 1 || providers[0] != "codex" || model != "routing-suffix-target" {
+		t.Fatalf("getRequestDetails() = %v, %q; want codex, routing-suffix-target", providers, model)
+	}
+	if got := util.ResolveOriginalModel(model, metadata); got != "routing-suffix-target" {
+		t.Fatalf("upstream model = %q, want routing-suffix-target", got)
+	}
+	if metadata[util.ThinkingOriginalModelMetadataKey] != "routing-suffix-target(high)" || metadata[util.ReasoningEffortMetadataKey] != "high" {
+		t.Fatalf("metadata = %v, want the suffix carried to the route target", metadata)
+	}
+
+	if _, _, metadata, _ = h.getRequestDetails(context.Background(), "smart"); metadata != nil {
+		t.Fatalf("unsuffixed alias metadata = %v, want nil", metadata)
+	}
+}
@@ -0,0 +1,238 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/batch"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	defaultBatchListLimit = 20
+	maxBatchListLimit     = 100
+)
+
+// OpenAIBatchAPIHandler contains the handlers for the OpenAI Files and Batch API endpoints.
+type OpenAIBatchAPIHandler struct {
+	*handlers.BaseAPIHandler
+	batches *batch.Manager
+}
+
+// NewOpenAIBatchAPIHandler creates a Files and Batch API handler backed by batches.
+func NewOpenAIBatchAPIHandler(apiHandlers *handlers.BaseAPIHandler, batches *batch.Manager) *OpenAIBatchAPIHandler {
+	return &OpenAIBatchAPIHandler{BaseAPIHandler: apiHandlers, batches: batches}
+}
+
+// NewBatchExecutor returns the function batches use to run each request through the chat
+// completions, responses or embeddings handler, under the client key that created the batch.
+// Each request goes through the same handler as a direct client request, so model checks,
+// n>1 fan-out and previous_response_id expansion apply, and it is charged against the
+// key's rate limits like one.
+func NewBatchExecutor(apiHandlers *handlers.BaseAPIHandler) batch.ExecuteFunc {
+	chat := NewOpenAIAPIHandler(apiHandlers)
+	responses := NewOpenAIResponsesAPIHandler(apiHandlers)
+	embeddings := NewOpenAIEmbeddingsAPIHandler(apiHandlers)
+	targets := map[string]gin.HandlerFunc{
+		batch.EndpointChatCompletions: chat.ChatCompletions,
+		batch.EndpointResponses:       responses.Responses,
+		batch.EndpointEmbeddings:      embeddings.Embeddings,
+	}
+	return func(ctx context.Context, apiKey, endpoint string, body []byte) batch.Result {
+		target, ok := targets[endpoint]
+		if !ok {
+			return batch.Result{StatusCode: http.StatusBadRequest, Body: handlers.BuildErrorResponseBody(http.StatusBadRequest, "unsupported batch endpoint "+endpoint)}
+		}
+		body, _ = sjson.DeleteBytes(body, "stream")
+		body, _ = sjson.DeleteBytes(body, "stream_options")
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+		if err != nil {
+			return batch.Result{StatusCode: http.StatusInternalServerError, Body: handlers.BuildErrorResponseBody(http.StatusInternalServerError, err.Error())}
+		}
+		req.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(recorder)
+		c.Request = req
+		if apiKey != "" {
+			c.Set("apiKey", apiKey)
+		}
+
+		release, errMsg := apiHandlers.AcquireRequests(context.WithValue(ctx, "gin", c), chat.HandlerType(), 1, false)
+		if errMsg != nil {
+			apiHandlers.WriteErrorResponse(c, errMsg)
+		} else {
+			target(c)
+			release()
+		}
+
+		result := batch.Result{StatusCode: recorder.Code, Body: recorder.Body.Bytes()}
+		if seconds, errAtoi := strconv.Atoi(recorder.Header().Get("Retry-After")); errAtoi == nil && seconds > 0 {
+			result.RetryAfter = time.Duration(seconds) * time.Second
+		}
+		return result
+	}
+}
+
+// UploadFile handles POST /v1/files with a multipart "file" and "purpose".
+func (h *OpenAIBatchAPIHandler) UploadFile(c *gin.Context) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		writeBatchError(c, &batch.RequestError{Param: "file", Message: "A multipart file field named 'file' is required."})
+		return
+	}
+	content, err := header.Open()
+	if err != nil {
+		writeBatchError(c, err)
+		return
+	}
+	defer func() { _ = content.Close() }()
+	file, err := h.batches.CreateFile(c.GetString("apiKey"), header.Filename, c.PostForm("purpose"), content)
+	if err != nil {
+		writeBatchError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, file)
+}
+
+// ListFiles handles GET /v1/files, optionally filtered by the purpose query parameter.
+func (h *OpenAIBatchAPIHandler) ListFiles(c *gin.Context) {
+	if !h.batches.Enabled() {
+		writeBatchError(c, batch.ErrDisabled)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"object": "list", "data": h.batches.ListFiles(c.GetString("apiKey"), c.Query("purpose"))})
+}
+
+// GetFile handles GET /v1/files/{id}.
+func (h *OpenAIBatchAPIHandler) GetFile(c *gin.Context) {
+	file, err := h.batches.GetFile(c.GetString("apiKey"), c.Param("id"))
+	if err != nil {
+		writeBatchError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, file)
+}
+
+// GetFileContent handles GET /v1/files/{id}/content.
+func (h *OpenAIBatchAPIHandler) GetFileContent(c *gin.Context) {
+	content, file, err := h.batches.OpenFile(c.GetString("apiKey"), c.Param("id"))
+	if err != nil {
+		writeBatchError(c, err)
+		return
+	}
+	defer func() { _ = content.Close() }()
+	c.DataFromReader(http.StatusOK, file.Bytes, "application/jsonl", content, nil)
+}
+
+// DeleteFile handles DELETE /v1/files/{id}.
+func (h *OpenAIBatchAPIHandler) DeleteFile(c *gin.Context) {
+	id := c.Param("id")
+	if err := h.batches.DeleteFile(c.GetString("apiKey"), id); err != nil {
+		writeBatchError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "object": "file", "deleted": true})
+}
+
+// CreateBatch handles POST /v1/batches.
+func (h *OpenAIBatchAPIHandler) CreateBatch(c *gin.Context) {
+	var req batch.CreateBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeBatchError(c, &batch.RequestError{Message: fmt.Sprintf("Invalid request: %v", err)})
+		return
+	}
+	created, err := h.batches.CreateBatch(c.GetString("apiKey"), req)
+	if err != nil {
+		writeBatchError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, created)
+}
+
+// GetBatch handles GET /v1/batches/{id}.
+func (h *OpenAIBatchAPIHandler) GetBatch(c *gin.Context) {
+	found, err := h.batches.GetBatch(c.GetString("apiKey"), c.Param("id"))
+	if err != nil {
+		writeBatchError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, found)
+}
+
+// ListBatches handles GET /v1/batches, honoring the limit and after query parameters.
+func (h *OpenAIBatchAPIHandler) ListBatches(c *gin.Context) {
+	if !h.batches.Enabled() {
+		writeBatchError(c, batch.ErrDisabled)
+		return
+	}
+	limit := defaultBatchListLimit
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxBatchListLimit {
+			writeBatchError(c, &batch.RequestError{Param: "limit", Message: fmt.Sprintf("Invalid 'limit': expected an integer between 1 and %d.", maxBatchListLimit)})
+			return
+		}
+		limit = parsed
+	}
+	batches, hasMore := h.batches.ListBatches(c.GetString("apiKey"), c.Query("after"), limit)
+	var firstID, lastID any
+	if len(batches) > 0 {
+		firstID, lastID = batches[0].ID, batches[len(batches)-1].ID
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"object":   "list",
+		"data":     batches,
+		"first_id": firstID,
+		"last_id":  lastID,
+		"has_more": hasMore,
+	})
+}
+
+// CancelBatch handles POST /v1/batches/{id}/cancel.
+func (h *OpenAIBatchAPIHandler) CancelBatch(c *gin.Context) {
+	cancelled, err := h.batches.CancelBatch(c.GetString("apiKey"), c.Param("id"))
+	if err != nil {
+		writeBatchError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, cancelled)
+}
+
+// writeBatchError renders a batch manager error as an OpenAI error response.
+func writeBatchError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	detail := handlers.ErrorDetail{Message: err.Error(), Type: "server_error"}
+	var reqErr *batch.RequestError
+	switch {
+	case errors.As(err, &reqErr):
+		status = http.StatusBadRequest
+		detail = handlers.ErrorDetail{Message: reqErr.Message, Type: "invalid_request_error"}
+	case errors.Is(err, batch.ErrNotFound):
+		status = http.StatusNotFound
+		detail = handlers.ErrorDetail{Message: fmt.Sprintf("No such object: '%s'", c.Param("id")), Type: "invalid_request_error"}
+	case errors.Is(err, batch.ErrFileTooLarge):
+		status = http.StatusRequestEntityTooLarge
+		detail = handlers.ErrorDetail{Message: "The file exceeds the maximum upload size.", Type: "invalid_request_error"}
+	case errors.Is(err, batch.ErrDisabled):
+		status = http.StatusNotFound
+		detail = handlers.ErrorDetail{Message: "The batch API is not enabled on this server.", Type: "invalid_request_error"}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		status = http.StatusBadRequest
+		detail = handlers.ErrorDetail{Message: "The upload ended unexpectedly.", Type: "invalid_request_error"}
+	}
+	if strings.TrimSpace(detail.Message) == "" {
+		detail.Message = http.StatusText(status)
+	}
+	c.JSON(status, handlers.ErrorResponse{Error: detail})
+}
@@ -0,0 +1,32 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/batch"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/ratelimit"
+	"github.com/tidwall/gjson"
+)
+
+func TestBatchExecutor_RunsLinesThroughEndpointHandlers(t *testing.T) {
+	ratelimit.Default().SetLimits([]config.APIKeyLimit{{APIKey: "batch-limited", RequestsPerMinute: 2}})
+	t.Cleanup(func() { ratelimit.Default().SetLimits(nil) })
+	h, executor := newChoicesTestHandler(t)
+	execute := NewBatchExecutor(h.BaseAPIHandler)
+
+	res := execute(context.Background(), "batch-limited", batch.EndpointChatCompletions, []byte(`{"model":"choices-test-model","n":2,"stream":true,"messages":[{"role":"user","content":"hi"}]}`))
+	if res.StatusCode != http.StatusOK || len(gjson.GetBytes(res.Body, "choices").Array()) != 2 || executor.calls.Load() != 2 {
+		t.Fatalf("n=2 line = %d %s with %d calls, want two fanned-out choices", res.StatusCode, res.Body, executor.calls.Load())
+	}
+
+	res = execute(context.Background(), "batch-limited", batch.EndpointChatCompletions, []byte(`{"model":"choices-test-model","messages":[{"role":"user","content":"hi"}]}`))
+	if res.StatusCode != http.StatusTooManyRequests || res.RetryAfter <= 0 {
+		t.Fatalf("line over the rate limit = %d, retry after %v; want 429 with Retry-After", res.StatusCode, res.RetryAfter)
+	}
+	if executor.calls.Load() != 2 {
+		t.Fatalf("calls = %d, want the rate-limited line not executed", executor.calls.Load())
+	}
+}
@@ -0,0 +1,314 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	// maxChoices mirrors the upper bound OpenAI accepts for n.
+	maxChoices = 128
+	// choiceConcurrency bounds the fanned-out requests executed at once for one request.
+	choiceConcurrency = 8
+)
+
+// requestedChoices returns the number of choices the request asks for through n.
+func requestedChoices(rawJSON []byte) (int, *interfaces.ErrorMessage) {
+	n := gjson.GetBytes(rawJSON, "n")
+	if !n.Exists() || n.Type == gjson.Null {
+		return 1, nil
+	}
+	if n.Type != gjson.Number || n.Float() != float64(n.Int()) || n.Int() < 1 || n.Int() > maxChoices {
+		return 0, &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: fmt.Errorf("n must be an integer between 1 and %d", maxChoices)}
+	}
+	return int(n.Int()), nil
+}
+
+// nativeChoices reports whether every provider serving modelName honours n on its own:
+// Gemini API backends map it to candidateCount and OpenAI-compatible upstreams accept it
+// as is. Other providers return a single choice, so the handler fans the request out.
+func (h *OpenAIAPIHandler) nativeChoices(ctx context.Context, modelName string) bool {
+	providers, normalizedModel := h.ResolveProviders(ctx, modelName)
+	if len(providers) == 0 {
+		return false
+	}
+	info := registry.GetGlobalRegistry().GetModelInfo(normalizedModel)
+	compat := info != nil && info.Type == "openai-compatibility"
+	for _, provider := range providers {
+		switch provider {
+		case "gemini", "vertex", "aistudio":
+		case "claude", "codex", "gemini-cli", "antigravity", "qwen", "iflow":
+			return false
+		default:
+			if !compat {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// singleChoiceRequest strips n so each fanned-out request samples one choice.
+func singleChoiceRequest(rawJSON []byte) []byte {
+	out, _ := sjson.DeleteBytes(rawJSON, "n")
+	return out
+}
+
+// executeChoices sends n single-choice requests, at most choiceConcurrency at a time, and
+// merges the responses into one chat completion. The extra requests are charged against
+// the client key's rate limits up front. The first failure cancels the remaining requests
+// and is returned.
+func (h *OpenAIAPIHandler) executeChoices(ctx context.Context, modelName string, rawJSON []byte, n int, alt string) ([]byte, *interfaces.ErrorMessage) {
+	release, errMsg := h.AcquireRequests(ctx, h.HandlerType(), n-1, false)
+	if errMsg != nil {
+		return nil, errMsg
+	}
+	defer release()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	payload := singleChoiceRequest(rawJSON)
+	responses := make([][]byte, n)
+	var firstErr *interfaces.ErrorMessage
+	var errOnce sync.Once
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, choiceConcurrency)
+	for i := 0; i < n; i++ {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			resp, errMsg := h.ExecuteWithAuthManager(ctx, h.HandlerType(), modelName, payload, alt)
+			if errMsg != nil {
+				errOnce.Do(func() {
+					firstErr = errMsg
+					cancel()
+				})
+				return
+			}
+			responses[index] = resp
+		}(i)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, &interfaces.ErrorMessage{StatusCode: http.StatusRequestTimeout, Error: err}
+	}
+	return mergeChoiceResponses(responses), nil
+}
+
+// mergeChoiceResponses combines single-choice chat completions into one response whose
+// choices are indexed in request order. Usage is summed because every sample is a separate
+// upstream request that processed the prompt again.
+func mergeChoiceResponses(responses [][]byte) []byte {
+	out, _ := sjson.SetRawBytes(responses[0], "choices", []byte(`[]`))
+	usage := ""
+	index := 0
+	for _, resp := range responses {
+		for _, choice := range gjson.GetBytes(resp, "choices").Array() {
+			merged, _ := sjson.Set(choice.Raw, "index", index)
+			out, _ = sjson.SetRawBytes(out, "choices.-1", []byte(merged))
+			index++
+		}
+		if u := gjson.GetBytes(resp, "usage"); u.IsObject() {
+			usage = addUsage(usage, u, "")
+		}
+	}
+	if usage != "" {
+		out, _ = sjson.SetRawBytes(out, "usage", []byte(usage))
+	}
+	return out
+}
+
+// executeChoicesStream opens n single-choice streams, at most choiceConcurrency at a time,
+// and interleaves their chunks as one stream. The extra requests and streams are charged
+// against the client key's rate limits up front. Each sample's chunks carry its choice
+// index and the id of the first chunk; usage is withheld and reported once, summed, after
+// every sample finished.
+func (h *OpenAIAPIHandler) executeChoicesStream(ctx context.Context, modelName string, rawJSON []byte, n int, alt string) (<-chan []byte, <-chan *interfaces.ErrorMessage) {
+	errChan := make(chan *interfaces.ErrorMessage, 1)
+	release, errMsg := h.AcquireRequests(ctx, h.HandlerType(), n-1, true)
+	if errMsg != nil {
+		errChan <- errMsg
+		close(errChan)
+		return nil, errChan
+	}
+	dataChan := make(chan []byte)
+
+	ctx, cancel := context.WithCancel(ctx)
+	payload := singleChoiceRequest(rawJSON)
+	merger := &choiceStreamMerger{}
+	var errOnce sync.Once
+	var wg sync.WaitGroup
+	fail := func(errMsg *interfaces.ErrorMessage) {
+		errOnce.Do(func() {
+			errChan <- errMsg
+			cancel()
+		})
+	}
+	forward := func(index int, data <-chan []byte, errs <-chan *interfaces.ErrorMessage) {
+		for data != nil || errs != nil {
+			select {
+			case chunk, ok := <-data:
+				if !ok {
+					data = nil
+					continue
+				}
+				out := merger.chunk(index, chunk)
+				if out == nil {
+					continue
+				}
+				select {
+				case dataChan <- out:
+				case <-ctx.Done():
+					return
+				}
+			case errMsg, ok := <-errs:
+				if !ok {
+					errs = nil
+					continue
+				}
+				if errMsg != nil {
+					fail(errMsg)
+				}
+			}
+		}
+	}
+
+	slots := make(chan struct{}, choiceConcurrency)
+	open := func(ctx context.Context, index int) {
+		data, errs := h.ExecuteStreamWithAuthManager(ctx, h.HandlerType(), modelName, payload, alt)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			forward(index, data, errs)
+		}()
+	}
+	// The first streams open before returning so their response headers are set before
+	// the handler writes the response; later ones leave the headers alone.
+	first := min(n, choiceConcurrency)
+	for i := 0; i < first; i++ {
+		slots <- struct{}{}
+		open(ctx, i)
+	}
+	go func() {
+		defer close(errChan)
+		defer close(dataChan)
+		defer release()
+		defer cancel()
+		laterCtx := handlers.WithoutServedModelHeader(ctx)
+		for i := first; i < n; i++ {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+			}
+			if ctx.Err() != nil {
+				break
+			}
+			open(laterCtx, i)
+		}
+		wg.Wait()
+		if usage := merger.usageChunk(); usage != nil && ctx.Err() == nil {
+			select {
+			case dataChan <- usage:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return dataChan, errChan
+}
+
+// choiceStreamMerger rewrites chunks from parallel single-choice streams so they read as
+// one multi-choice stream.
+type choiceStreamMerger struct {
+	mu      sync.Mutex
+	id      string
+	created int64
+	model   string
+	usage   string
+}
+
+func (m *choiceStreamMerger) chunk(index int, chunk []byte) []byte {
+	if !gjson.ValidBytes(chunk) {
+		// A sample finishing must not end the merged stream.
+		if bytes.Equal(bytes.TrimSpace(chunk), []byte("[DONE]")) {
+			return nil
+		}
+		return chunk
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.id == "" {
+		m.id = gjson.GetBytes(chunk, "id").String()
+		m.created = gjson.GetBytes(chunk, "created").Int()
+		m.model = gjson.GetBytes(chunk, "model").String()
+	}
+	if m.id != "" {
+		chunk, _ = sjson.SetBytes(chunk, "id", m.id)
+	}
+	if u := gjson.GetBytes(chunk, "usage"); u.IsObject() {
+		m.usage = addUsage(m.usage, u, "")
+		chunk, _ = sjson.DeleteBytes(chunk, "usage")
+	}
+	choices := gjson.GetBytes(chunk, "choices").Array()
+	if len(choices) == 0 {
+		return nil
+	}
+	for i := range choices {
+		chunk, _ = sjson.SetBytes(chunk, fmt.Sprintf("choices.%d.index", i), index)
+	}
+	return chunk
+}
+
+// usageChunk returns the final chunk reporting the summed usage, or nil when no sample
+// reported any.
+func (m *choiceStreamMerger) usageChunk() []byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.usage == "" {
+		return nil
+	}
+	out := []byte(`{"id":"","object":"chat.completion.chunk","created":0,"model":"","choices":[]}`)
+	out, _ = sjson.SetBytes(out, "id", m.id)
+	out, _ = sjson.SetBytes(out, "created", m.created)
+	out, _ = sjson.SetBytes(out, "model", m.model)
+	out, _ = sjson.SetRawBytes(out, "usage", []byte(m.usage))
+	return out
+}
+
+// addUsage adds every numeric field of usage, nested details included, to total.
+func addUsage(total string, usage gjson.Result, prefix string) string {
+	if total == "" {
+		total = `{}`
+	}
+	usage.ForEach(func(key, value gjson.Result) bool {
+		path := prefix + key.String()
+		switch {
+		case value.Type == gjson.Number:
+			total, _ = sjson.Set(total, path, gjson.Get(total, path).Int()+value.Int())
+		case value.IsObject():
+			total = addUsage(total, value, path+".")
+		}
+		return true
+	})
+	return total
+}
@@ -0,0 +1,201 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/ratelimit"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+// choicesTestExecutor answers every request with one choice, as single-sample providers do.
+type choicesTestExecutor struct {
+	calls       atomic.Int64
+	sawField    atomic.Bool
+	inflight    atomic.Int64
+	maxInflight atomic.Int64
+}
+
+func (e *choicesTestExecutor) Identifier() string { return "claude" }
+
+func (e *choicesTestExecutor) Execute(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	call := e.calls.Add(1)
+	if gjson.GetBytes(req.Payload, "n").Exists() {
+		e.sawField.Store(true)
+	}
+	inflight := e.inflight.Add(1)
+	defer e.inflight.Add(-1)
+	for peak := e.maxInflight.Load(); inflight > peak && !e.maxInflight.CompareAndSwap(peak, inflight); peak = e.maxInflight.Load() {
+	}
+	time.Sleep(time.Millisecond)
+	body := fmt.Sprintf(`{"id":"chatcmpl-%d","object":"chat.completion","model":"choices-test-model","choices":[{"index":0,"message":{"role":"assistant","content":"sample"},"finish_reason":"stop"}],"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15,"completion_tokens_details":{"reasoning_tokens":2}}}`, call)
+	return coreexecutor.Response{Payload: []byte(body)}, nil
+}
+
+func (e *choicesTestExecutor) ExecuteStream(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	call := e.calls.Add(1)
+	if gjson.GetBytes(req.Payload, "n").Exists() {
+		e.sawField.Store(true)
+	}
+	out := make(chan coreexecutor.StreamChunk, 3)
+	out <- coreexecutor.StreamChunk{Payload: []byte(fmt.Sprintf(`{"id":"chatcmpl-%d","object":"chat.completion.chunk","model":"choices-test-model","choices":[{"index":0,"delta":{"role":"assistant","content":"sample"}}]}`, call))}
+	out <- coreexecutor.StreamChunk{Payload: []byte(fmt.Sprintf(`{"id":"chatcmpl-%d","object":"chat.completion.chunk","model":"choices-test-model","choices":[{"index":0,"delta":{},"finish_reason":"stop"}],"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`, call))}
+	out <- coreexecutor.StreamChunk{Payload: []byte("[DONE]")}
+	close(out)
+	return out, nil
+}
+
+func (e *choicesTestExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *choicesTestExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func newChoicesTestHandler(t *testing.T) (*OpenAIAPIHandler, *choicesTestExecutor) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	executor := &choicesTestExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "choices-test-auth", Provider: "claude", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register() error: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "choices-test-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+	return NewOpenAIAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager)), executor
+}
+
+func serveChatCompletions(h *OpenAIAPIHandler, body string) *httptest.ResponseRecorder {
+	return serveChatCompletionsAs(h, "", body)
+}
+
+func serveChatCompletionsAs(h *OpenAIAPIHandler, apiKey, body string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewBufferString(body))
+	if apiKey != "" {
+		c.Set("apiKey", apiKey)
+	}
+	h.ChatCompletions(c)
+	return recorder
+}
+
+func TestChatCompletions_FansOutChoices(t *testing.T) {
+	h, executor := newChoicesTestHandler(t)
+	recorder := serveChatCompletions(h, `{"model":"choices-test-model","n":3,"messages":[{"role":"user","content":"hi"}]}`)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", recorder.Code, recorder.Body.String())
+	}
+	if executor.calls.Load() != 3 || executor.sawField.Load() {
+		t.Fatalf("calls = %d, upstream saw n = %v", executor.calls.Load(), executor.sawField.Load())
+	}
+	resp := gjson.ParseBytes(recorder.Body.Bytes())
+	choices := resp.Get("choices").Array()
+	if len(choices) != 3 {
+		t.Fatalf("choices = %s", resp.Get("choices").Raw)
+	}
+	for i, choice := range choices {
+		if choice.Get("index").Int() != int64(i) || choice.Get("message.content").String() != "sample" {
+			t.Fatalf("choice %d = %s", i, choice.Raw)
+		}
+	}
+	usage := resp.Get("usage")
+	if usage.Get("prompt_tokens").Int() != 30 || usage.Get("completion_tokens").Int() != 15 || usage.Get("total_tokens").Int() != 45 || usage.Get("completion_tokens_details.reasoning_tokens").Int() != 6 {
+		t.Fatalf("usage = %s", usage.Raw)
+	}
+}
+
+func TestChatCompletions_FansOutStreamingChoices(t *testing.T) {
+	h, executor := newChoicesTestHandler(t)
+	recorder := serveChatCompletions(h, `{"model":"choices-test-model","n":2,"stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+	if recorder.Code != http.StatusOK || executor.calls.Load() != 2 {
+		t.Fatalf("status = %d, calls = %d, body = %s", recorder.Code, executor.calls.Load(), recorder.Body.String())
+	}
+	var ids = map[string]bool{}
+	var indexes = map[int64]int{}
+	var events []string
+	for _, line := range strings.Split(recorder.Body.String(), "\n") {
+		if payload, ok := strings.CutPrefix(line, "data: "); ok {
+			events = append(events, payload)
+		}
+	}
+	if len(events) != 6 || events[len(events)-1] != "[DONE]" {
+		t.Fatalf("events = %q", events)
+	}
+	for _, event := range events[:4] {
+		ids[gjson.Get(event, "id").String()] = true
+		indexes[gjson.Get(event, "choices.0.index").Int()]++
+		if gjson.Get(event, "usage").Exists() {
+			t.Fatalf("per-sample chunk reports usage: %s", event)
+		}
+	}
+	if len(ids) != 1 || indexes[0] != 2 || indexes[1] != 2 {
+		t.Fatalf("ids = %v, indexes = %v", ids, indexes)
+	}
+	final := gjson.Parse(events[4])
+	if len(final.Get("choices").Array()) != 0 || final.Get("usage.prompt_tokens").Int() != 20 || final.Get("usage.total_tokens").Int() != 30 {
+		t.Fatalf("usage chunk = %s", final.Raw)
+	}
+}
+
+func TestChatCompletions_RejectsInvalidChoiceCount(t *testing.T) {
+	h, executor := newChoicesTestHandler(t)
+	for _, n := range []string{"0", "1.5", `"2"`, "129"} {
+		recorder := serveChatCompletions(h, `{"model":"choices-test-model","n":`+n+`,"messages":[{"role":"user","content":"hi"}]}`)
+		if recorder.Code != http.StatusBadRequest {
+			t.Fatalf("n=%s status = %d", n, recorder.Code)
+		}
+	}
+	if executor.calls.Load() != 0 {
+		t.Fatalf("calls = %d", executor.calls.Load())
+	}
+}
+
+func TestChatCompletions_BoundsChoiceFanOut(t *testing.T) {
+	h, executor := newChoicesTestHandler(t)
+	recorder := serveChatCompletions(h, `{"model":"choices-test-model","n":40,"messages":[{"role":"user","content":"hi"}]}`)
+	if recorder.Code != http.StatusOK || executor.calls.Load() != 40 {
+		t.Fatalf("status = %d, calls = %d", recorder.Code, executor.calls.Load())
+	}
+	if peak := executor.maxInflight.Load(); peak > choiceConcurrency {
+		t.Fatalf("peak concurrent requests = %d, want at most %d", peak, choiceConcurrency)
+	}
+}
+
+func TestChatCompletions_ChargesChoicesAgainstRateLimit(t *testing.T) {
+	ratelimit.Default().SetLimits([]config.APIKeyLimit{{APIKey: "choices-limited", RequestsPerMinute: 3, MaxConcurrentStreams: 1}})
+	t.Cleanup(func() { ratelimit.Default().SetLimits(nil) })
+	h, executor := newChoicesTestHandler(t)
+
+	recorder := serveChatCompletionsAs(h, "choices-limited", `{"model":"choices-test-model","n":5,"messages":[{"role":"user","content":"hi"}]}`)
+	if recorder.Code != http.StatusTooManyRequests || recorder.Header().Get("Retry-After") == "" {
+		t.Fatalf("n above the rate limit: status = %d, headers = %v", recorder.Code, recorder.Header())
+	}
+	recorder = serveChatCompletionsAs(h, "choices-limited", `{"model":"choices-test-model","n":3,"stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+	if recorder.Code != http.StatusTooManyRequests {
+		t.Fatalf("n above the stream limit: status = %d, body = %s", recorder.Code, recorder.Body.String())
+	}
+	if executor.calls.Load() != 0 {
+		t.Fatalf("calls = %d, want none for rejected requests", executor.calls.Load())
+	}
+	if recorder = serveChatCompletionsAs(h, "choices-limited", `{"model":"choices-test-model","n":3,"messages":[{"role":"user","content":"hi"}]}`); recorder.Code != http.StatusOK {
+		t.Fatalf("n within the rate limit: status = %d, body = %s", recorder.Code, recorder.Body.String())
+	}
+}
@@ -0,0 +1,138 @@
+// Package openai provides HTTP handlers for OpenAI Embeddings API endpoints.
+// The handlers forward embedding requests through the core auth manager so
+// Gemini, Vertex and OpenAI-compatible providers can serve them transparently.
+package openai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+)
+
+// OpenAIEmbeddingsAPIHandler contains the handlers for OpenAI Embeddings API endpoints.
+type OpenAIEmbeddingsAPIHandler struct {
+	*handlers.BaseAPIHandler
+}
+
+// NewOpenAIEmbeddingsAPIHandler creates a new OpenAI Embeddings API handlers instance.
+//
+// Parameters:
+//   - apiHandlers: The base API handlers instance
+//
+// Returns:
+//   - *OpenAIEmbeddingsAPIHandler: A new OpenAI Embeddings API handlers instance
+func NewOpenAIEmbeddingsAPIHandler(apiHandlers *handlers.BaseAPIHandler) *OpenAIEmbeddingsAPIHandler {
+	return &OpenAIEmbeddingsAPIHandler{
+		BaseAPIHandler: apiHandlers,
+	}
+}
+
+// HandlerType returns the identifier for this handler implementation.
+func (h *OpenAIEmbeddingsAPIHandler) HandlerType() string {
+	return OpenAIEmbeddings
+}
+
+// Models returns the OpenAI-compatible model metadata supported by this handler.
+func (h *OpenAIEmbeddingsAPIHandler) Models() []map[string]any {
+	modelRegistry := registry.GetGlobalRegistry()
+	return h.WithRoutedModels("openai", modelRegistry.GetAvailableEmbeddingModels("openai"))
+}
+
+// Embeddings handles the /v1/embeddings endpoint.
+// Embedding requests are always non-streaming and return an OpenAI list of vectors.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OpenAIEmbeddingsAPIHandler) Embeddings(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	// If data retrieval fails, return a 400 Bad Request error.
+	if err != nil {
+		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: fmt.Sprintf("Invalid request: %v", err),
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+
+	modelName := gjson.GetBytes(rawJSON, "model").String()
+	if modelName == "" {
+		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: "Invalid request: model is required",
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+	if input := gjson.GetBytes(rawJSON, "input"); !input.Exists() || (input.IsArray() && len(input.Array()) == 0) {
+		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: "Invalid request: input is required",
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+
+	c.Header("Content-Type", "application/json")
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	if errMsg := h.checkEmbeddingRequest(cliCtx, modelName, rawJSON); errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+	resp, errMsg := h.ExecuteWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON, "")
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+	_, _ = c.Writer.Write(resp)
+	cliCancel()
+}
+
+// checkEmbeddingRequest rejects models that are known not to serve embeddings and
+// pre-tokenized input for models only Gemini backends serve, since Gemini embeds text only.
+// Unknown models and key policy errors are left to ExecuteWithAuthManager to report.
+func (h *OpenAIEmbeddingsAPIHandler) checkEmbeddingRequest(ctx context.Context, modelName string, rawJSON []byte) *interfaces.ErrorMessage {
+	providers, model := h.ResolveProviders(ctx, modelName)
+	if len(providers) == 0 {
+		return nil
+	}
+	// OpenAI-compatible upstreams are not described by the registry, so their models are trusted.
+	if info := registry.GetGlobalRegistry().GetModelInfo(model); info != nil && info.Type != registry.ModelTypeEmbedding && info.Type != "openai-compatibility" {
+		return &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: fmt.Errorf("model %s does not support embeddings", modelName)}
+	}
+	if !hasTokenInput(gjson.GetBytes(rawJSON, "input")) {
+		return nil
+	}
+	for _, provider := range providers {
+		if provider != "gemini" && provider != "vertex" {
+			return nil
+		}
+	}
+	return &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: fmt.Errorf("model %s only accepts text input; token arrays are not supported", modelName)}
+}
+
+// hasTokenInput reports whether input holds pre-tokenized text, either one array of token
+// ids or a list of them.
+func hasTokenInput(input gjson.Result) bool {
+	if !input.IsArray() {
+		return false
+	}
+	for _, item := range input.Array() {
+		if item.Type == gjson.Number || item.IsArray() {
+			return true
+		}
+	}
+	return false
+}
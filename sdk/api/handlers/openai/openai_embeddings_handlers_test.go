@@ -0,0 +1,123 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+type embeddingsTestExecutor struct {
+	calls atomic.Int64
+}
+
+func (e *embeddingsTestExecutor) Identifier() string { return "gemini" }
+
+func (e *embeddingsTestExecutor) Execute(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	e.calls.Add(1)
+	return coreexecutor.Response{Payload: []byte(`{"object":"list","data":[{"object":"embedding","index":0,"embedding":[0.1]}]}`)}, nil
+}
+
+func (e *embeddingsTestExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "ExecuteStream not implemented"}
+}
+
+func (e *embeddingsTestExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *embeddingsTestExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func TestEmbeddings_RejectsChatModelsAndGeminiTokenInput(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	executor := &embeddingsTestExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "embeddings-test-auth", Provider: "gemini", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register() error: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{
+		{ID: "embeddings-test-embed", Type: registry.ModelTypeEmbedding},
+		{ID: "embeddings-test-chat", Type: "gemini"},
+	})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+	base := handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager)
+	h := NewOpenAIEmbeddingsAPIHandler(base)
+
+	serve := func(body string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(recorder)
+		c.Request = httptest.NewRequest(http.MethodPost, "/v1/embeddings", bytes.NewBufferString(body))
+		h.Embeddings(c)
+		return recorder
+	}
+	if got := serve(`{"model":"embeddings-test-chat","input":"hi"}`); got.Code != http.StatusBadRequest {
+		t.Fatalf("chat model status = %d %s, want 400", got.Code, got.Body.String())
+	}
+	if got := serve(`{"model":"embeddings-test-embed","input":[1,2,3]}`); got.Code != http.StatusBadRequest {
+		t.Fatalf("token input status = %d %s, want 400", got.Code, got.Body.String())
+	}
+	if got := serve(`{"model":"embeddings-test-embed","input":[[1,2],[3]]}`); got.Code != http.StatusBadRequest {
+		t.Fatalf("token list input status = %d %s, want 400", got.Code, got.Body.String())
+	}
+	if executor.calls.Load() != 0 {
+		t.Fatalf("calls = %d, want rejected requests not executed", executor.calls.Load())
+	}
+	if got := serve(`{"model":"embeddings-test-embed","input":["hi","there"]}`); got.Code != http.StatusOK || executor.calls.Load() != 1 {
+		t.Fatalf("text input = %d %s with %d calls", got.Code, got.Body.String(), executor.calls.Load())
+	}
+
+	listed := func(models []map[string]any, id string) bool {
+		for _, model := range models {
+			if model["id"] == id {
+				return true
+			}
+		}
+		return false
+	}
+	if chat := NewOpenAIAPIHandler(base).Models(); listed(chat, "embeddings-test-embed") || !listed(chat, "embeddings-test-chat") {
+		t.Fatalf("chat models = %v, want the embedding model left out", chat)
+	}
+	if embed := h.Models(); !listed(embed, "embeddings-test-embed") || listed(embed, "embeddings-test-chat") {
+		t.Fatalf("embedding models = %v, want only the embedding model", embed)
+	}
+}
+
+func TestOpenAIModels_ListsEmbeddingModels(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	registry.GetGlobalRegistry().RegisterClient("embeddings-list-auth", "gemini", []*registry.ModelInfo{
+		{ID: "embeddings-list-embed", Object: "model", Type: registry.ModelTypeEmbedding},
+		{ID: "embeddings-list-chat", Object: "model", Type: "gemini"},
+	})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient("embeddings-list-auth") })
+	h := NewOpenAIAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, coreauth.NewManager(nil, nil, nil)))
+
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+	h.OpenAIModels(c)
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d %s, want 200", recorder.Code, recorder.Body.String())
+	}
+	ids := make(map[string]bool)
+	for _, model := range gjson.GetBytes(recorder.Body.Bytes(), "data.#.id").Array() {
+		ids[model.String()] = true
+	}
+	if !ids["embeddings-list-embed"] || !ids["embeddings-list-chat"] {
+		t.Fatalf("/v1/models = %s, want both chat and embedding models", recorder.Body.String())
+	}
+}
@@ -52,15 +52,18 @@ func (h *OpenAIAPIHandler) HandlerType() string {
 func (h *OpenAIAPIHandler) Models() []map[string]any {
 	// Get dynamic models from the global registry
 	modelRegistry := registry.GetGlobalRegistry()
-	return modelRegistry.GetAvailableModels("openai")
+	return h.WithRoutedModels("openai", modelRegistry.GetAvailableModels("openai"))
 }
 
 // OpenAIModels handles the /v1/models endpoint.
 // It returns a list of available AI models with their capabilities
 // and specifications in OpenAI-compatible format.
 func (h *OpenAIAPIHandler) OpenAIModels(c *gin.Context) {
-	// Get all available models
-	allModels := h.Models()
+	// Get all available models. Embedding models are listed too so clients can
+	// discover what /v1/embeddings accepts.
+	embeddings := NewOpenAIEmbeddingsAPIHandler(h.BaseAPIHandler)
+	models := append(h.Models(), embeddings.Models()...)
+	allModels := h.FilterModelsForAPIKey(c, h.HandlerType(), models)
 
 	// Filter to only include the 4 required fields: id, object, created, owned_by
 	filteredModels := make([]map[string]any, len(allModels))
@@ -120,6 +123,11 @@ func (h *OpenAIAPIHandler) ChatCompletions(c *gin.Context) {
 		stream = gjson.GetBytes(rawJSON, "stream").Bool()
 	}
 
+	if _, errMsg := requestedChoices(rawJSON); errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		return
+	}
+
 	if stream {
 		h.handleStreamingResponse(c, rawJSON)
 	} else {
@@ -425,7 +433,13 @@ func (h *OpenAIAPIHandler) handleNonStreamingResponse(c *gin.Context, rawJSON []
 
 	modelName := gjson.GetBytes(rawJSON, "model").String()
 	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
-	resp, errMsg := h.ExecuteWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON, h.GetAlt(c))
+	var resp []byte
+	var errMsg *interfaces.ErrorMessage
+	if n, _ := requestedChoices(rawJSON); n > 1 && !h.nativeChoices(cliCtx, modelName) {
+		resp, errMsg = h.executeChoices(cliCtx, modelName, rawJSON, n, h.GetAlt(c))
+	} else {
+		resp, errMsg = h.ExecuteWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON, h.GetAlt(c))
+	}
 	if errMsg != nil {
 		h.WriteErrorResponse(c, errMsg)
 		cliCancel(errMsg.Error)
@@ -457,7 +471,13 @@ func (h *OpenAIAPIHandler) handleStreamingResponse(c *gin.Context, rawJSON []byt
 
 	modelName := gjson.GetBytes(rawJSON, "model").String()
 	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
-	dataChan, errChan := h.ExecuteStreamWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON, h.GetAlt(c))
+	var dataChan <-chan []byte
+	var errChan <-chan *interfaces.ErrorMessage
+	if n, _ := requestedChoices(rawJSON); n > 1 && !h.nativeChoices(cliCtx, modelName) {
+		dataChan, errChan = h.executeChoicesStream(cliCtx, modelName, rawJSON, n, h.GetAlt(c))
+	} else {
+		dataChan, errChan = h.ExecuteStreamWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON, h.GetAlt(c))
+	}
 
 	setSSEHeaders := func() {
 		c.Header("Content-Type", "text/event-stream")
@@ -0,0 +1,258 @@
+package openai
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	defaultInputItemsLimit = 20
+	maxInputItemsLimit     = 100
+)
+
+// expandPreviousResponse prepends the stored conversation of previous_response_id to the
+// request input, so that every backend receives the full history. It returns the expanded
+// request and its complete input items, which are nil when the responses store is disabled.
+// Without the store the history cannot be recovered, so previous_response_id is passed
+// through as before and dropped by the executors that cannot honour it.
+func (h *OpenAIResponsesAPIHandler) expandPreviousResponse(c *gin.Context, rawJSON []byte) ([]byte, []json.RawMessage, *interfaces.ErrorMessage) {
+	store := cache.DefaultResponsesStore()
+	previousID := strings.TrimSpace(gjson.GetBytes(rawJSON, "previous_response_id").String())
+	if !store.Enabled() {
+		if previousID != "" {
+			log.Warnf("previous_response_id %s ignored: the responses store is disabled", previousID)
+		}
+		return rawJSON, nil, nil
+	}
+	items := inputItems(gjson.GetBytes(rawJSON, "input"))
+	if previousID == "" {
+		return rawJSON, items, nil
+	}
+	previous, ok := store.Load(previousID, responsesOwner(c))
+	if !ok {
+		return nil, nil, responseNotFound(fmt.Sprintf("Previous response with id '%s' not found.", previousID))
+	}
+
+	history := make([]json.RawMessage, 0, len(previous.Input)+len(previous.Output)+len(items))
+	for _, item := range append(append([]json.RawMessage(nil), previous.Input...), previous.Output...) {
+		if replayed, keep := replayableItem(item); keep {
+			history = append(history, replayed)
+		}
+	}
+	history = append(history, items...)
+	input, err := json.Marshal(history)
+	if err != nil {
+		return nil, nil, &interfaces.ErrorMessage{StatusCode: http.StatusInternalServerError, Error: err}
+	}
+	expanded, err := sjson.SetRawBytes(rawJSON, "input", input)
+	if err != nil {
+		return nil, nil, &interfaces.ErrorMessage{StatusCode: http.StatusInternalServerError, Error: err}
+	}
+	// The history is inlined, so upstreams must not try to resolve the ID themselves.
+	expanded, _ = sjson.DeleteBytes(expanded, "previous_response_id")
+	return expanded, history, nil
+}
+
+// storeResponse persists a completed response with the input items that produced it,
+// unless the request opted out with "store": false.
+func (h *OpenAIResponsesAPIHandler) storeResponse(c *gin.Context, rawJSON []byte, items []json.RawMessage, response []byte) {
+	if items == nil || gjson.GetBytes(rawJSON, "store").Type == gjson.False {
+		return
+	}
+	resp := gjson.ParseBytes(response)
+	id := resp.Get("id").String()
+	if id == "" {
+		return
+	}
+	stored := cache.StoredResponse{
+		ID:       id,
+		Owner:    responsesOwner(c),
+		Input:    make([]json.RawMessage, 0, len(items)),
+		Response: json.RawMessage(resp.Raw),
+	}
+	for _, item := range items {
+		stored.Input = append(stored.Input, withItemID(item))
+	}
+	resp.Get("output").ForEach(func(_, item gjson.Result) bool {
+		stored.Output = append(stored.Output, json.RawMessage(item.Raw))
+		return true
+	})
+	if err := cache.DefaultResponsesStore().Save(stored); err != nil {
+		log.Warnf("failed to store response %s: %v", id, err)
+	}
+}
+
+// completedStreamResponse extracts the response object from a response.completed event.
+func completedStreamResponse(chunk []byte) ([]byte, bool) {
+	scanner := bufio.NewScanner(bytes.NewReader(chunk))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(chunk)+1)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if !bytes.HasPrefix(line, []byte("data:")) {
+			continue
+		}
+		data := bytes.TrimSpace(line[len("data:"):])
+		if gjson.GetBytes(data, "type").String() != "response.completed" {
+			continue
+		}
+		if response := gjson.GetBytes(data, "response"); response.IsObject() {
+			return []byte(response.Raw), true
+		}
+	}
+	return nil, false
+}
+
+// GetResponse handles GET /v1/responses/{id}.
+func (h *OpenAIResponsesAPIHandler) GetResponse(c *gin.Context) {
+	stored, ok := cache.DefaultResponsesStore().Load(c.Param("id"), responsesOwner(c))
+	if !ok {
+		h.WriteErrorResponse(c, responseNotFound(fmt.Sprintf("Response with id '%s' not found.", c.Param("id"))))
+		return
+	}
+	c.Data(http.StatusOK, "application/json", stored.Response)
+}
+
+// DeleteResponse handles DELETE /v1/responses/{id}.
+func (h *OpenAIResponsesAPIHandler) DeleteResponse(c *gin.Context) {
+	id := c.Param("id")
+	if !cache.DefaultResponsesStore().Delete(id, responsesOwner(c)) {
+		h.WriteErrorResponse(c, responseNotFound(fmt.Sprintf("Response with id '%s' not found.", id)))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "object": "response", "deleted": true})
+}
+
+// ListResponseInputItems handles GET /v1/responses/{id}/input_items, honoring the limit,
+// order and after query parameters.
+func (h *OpenAIResponsesAPIHandler) ListResponseInputItems(c *gin.Context) {
+	stored, ok := cache.DefaultResponsesStore().Load(c.Param("id"), responsesOwner(c))
+	if !ok {
+		h.WriteErrorResponse(c, responseNotFound(fmt.Sprintf("Response with id '%s' not found.", c.Param("id"))))
+		return
+	}
+	limit := defaultInputItemsLimit
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxInputItemsLimit {
+			c.JSON(http.StatusBadRequest, handlers.ErrorResponse{Error: handlers.ErrorDetail{
+				Message: fmt.Sprintf("Invalid 'limit': expected an integer between 1 and %d.", maxInputItemsLimit),
+				Type:    "invalid_request_error",
+			}})
+			return
+		}
+		limit = parsed
+	}
+
+	items := append([]json.RawMessage(nil), stored.Input...)
+	if !strings.EqualFold(c.Query("order"), "asc") {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+	if after := c.Query("after"); after != "" {
+		for i, item := range items {
+			if gjson.GetBytes(item, "id").String() == after {
+				items = items[i+1:]
+				break
+			}
+		}
+	}
+	hasMore := len(items) > limit
+	if hasMore {
+		items = items[:limit]
+	}
+	var firstID, lastID any
+	if len(items) > 0 {
+		firstID = gjson.GetBytes(items[0], "id").String()
+		lastID = gjson.GetBytes(items[len(items)-1], "id").String()
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"object":   "list",
+		"data":     items,
+		"first_id": firstID,
+		"last_id":  lastID,
+		"has_more": hasMore,
+	})
+}
+
+// inputItems normalizes a Responses API input, which may be a plain string, into items.
+func inputItems(input gjson.Result) []json.RawMessage {
+	items := make([]json.RawMessage, 0)
+	switch {
+	case input.Type == gjson.String:
+		item, _ := json.Marshal(map[string]string{"type": "message", "role": "user", "content": input.String()})
+		items = append(items, item)
+	case input.IsArray():
+		input.ForEach(func(_, item gjson.Result) bool {
+			items = append(items, json.RawMessage(item.Raw))
+			return true
+		})
+	}
+	return items
+}
+
+// replayableItem prepares a stored item for use as input of a later request. Item IDs are
+// dropped because providers that do not persist items reject unknown references, and
+// reasoning items survive only when they carry their encrypted content.
+func replayableItem(item json.RawMessage) (json.RawMessage, bool) {
+	parsed := gjson.ParseBytes(item)
+	if parsed.Get("type").String() == "reasoning" && parsed.Get("encrypted_content").String() == "" {
+		return nil, false
+	}
+	if !parsed.Get("id").Exists() {
+		return item, true
+	}
+	stripped, err := sjson.DeleteBytes(item, "id")
+	if err != nil {
+		return item, true
+	}
+	return stripped, true
+}
+
+// withItemID gives input items the ID clients use to page through input_items.
+func withItemID(item json.RawMessage) json.RawMessage {
+	if gjson.GetBytes(item, "id").String() != "" {
+		return item
+	}
+	var buf [12]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return item
+	}
+	prefix := "msg_"
+	if itemType := gjson.GetBytes(item, "type").String(); itemType != "" && itemType != "message" {
+		prefix = "item_"
+	}
+	updated, err := sjson.SetBytes(item, "id", prefix+hex.EncodeToString(buf[:]))
+	if err != nil {
+		return item
+	}
+	return updated
+}
+
+func responsesOwner(c *gin.Context) string {
+	return cache.ResponsesStoreOwner(c.GetString("apiKey"))
+}
+
+func responseNotFound(message string) *interfaces.ErrorMessage {
+	body, _ := json.Marshal(handlers.ErrorResponse{Error: handlers.ErrorDetail{
+		Message: message,
+		Type:    "invalid_request_error",
+	}})
+	return &interfaces.ErrorMessage{StatusCode: http.StatusNotFound, Error: errors.New(string(body))}
+}
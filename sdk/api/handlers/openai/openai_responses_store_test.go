@@ -0,0 +1,106 @@
+package openai
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+)
+
+func TestResponsesStore_ChainsPreviousResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := cache.DefaultResponsesStore()
+	if err := store.Configure(config.ResponsesStoreConfig{Enabled: true}, t.TempDir()); err != nil {
+		t.Fatalf("Configure() error: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Configure(config.ResponsesStoreConfig{}, "") })
+
+	h := NewOpenAIResponsesAPIHandler(&handlers.BaseAPIHandler{})
+	newContext := func(apiKey string) *gin.Context {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Set("apiKey", apiKey)
+		return c
+	}
+
+	first := []byte(`{"model":"gpt-5","input":"hello"}`)
+	c := newContext("key-a")
+	_, items, errMsg := h.expandPreviousResponse(c, first)
+	if errMsg != nil {
+		t.Fatalf("expandPreviousResponse() error: %v", errMsg.Error)
+	}
+	h.storeResponse(c, first, items, []byte(`{"id":"resp_1","object":"response","output":[`+
+		`{"type":"reasoning","id":"rs_1","summary":[]},`+
+		`{"type":"message","id":"msg_out","role":"assistant","content":[{"type":"output_text","text":"hi"}]}]}`))
+
+	second := []byte(`{"model":"gpt-5","previous_response_id":"resp_1","input":[{"role":"user","content":"again"}]}`)
+	expanded, _, errMsg := h.expandPreviousResponse(newContext("key-a"), second)
+	if errMsg != nil {
+		t.Fatalf("expandPreviousResponse() chained error: %v", errMsg.Error)
+	}
+	input := gjson.GetBytes(expanded, "input").Array()
+	if len(input) != 3 || input[0].Get("content").String() != "hello" || input[1].Get("role").String() != "assistant" || input[2].Get("content").String() != "again" {
+		t.Fatalf("expanded input = %s", gjson.GetBytes(expanded, "input").Raw)
+	}
+	if input[0].Get("id").Exists() || input[1].Get("id").Exists() {
+		t.Fatalf("replayed items keep ids: %s", gjson.GetBytes(expanded, "input").Raw)
+	}
+	if gjson.GetBytes(expanded, "previous_response_id").Exists() {
+		t.Fatalf("expanded request keeps previous_response_id: %s", expanded)
+	}
+
+	if _, _, errMsg = h.expandPreviousResponse(newContext("key-b"), second); errMsg == nil || errMsg.StatusCode != http.StatusNotFound {
+		t.Fatalf("other client key expanded resp_1: %v", errMsg)
+	}
+
+	recorder := httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(recorder)
+	c.Set("apiKey", "key-a")
+	c.Request = httptest.NewRequest(http.MethodGet, "/v1/responses/resp_1/input_items?order=asc", nil)
+	c.Params = gin.Params{{Key: "id", Value: "resp_1"}}
+	h.ListResponseInputItems(c)
+	data := gjson.GetBytes(recorder.Body.Bytes(), "data").Array()
+	if recorder.Code != http.StatusOK || len(data) != 1 || !gjson.Get(data[0].Raw, "id").Exists() {
+		t.Fatalf("input_items = %d %s", recorder.Code, recorder.Body.String())
+	}
+
+	if !store.Delete("resp_1", cache.ResponsesStoreOwner("key-a")) {
+		t.Fatal("Delete() did not find resp_1")
+	}
+	if _, ok := store.Load("resp_1", cache.ResponsesStoreOwner("key-a")); ok {
+		t.Fatal("resp_1 still stored after Delete()")
+	}
+}
+
+func TestCompletedStreamResponse(t *testing.T) {
+	chunk := []byte("event: response.completed\ndata: {\"type\":\"response.completed\",\"response\":{\"id\":\"resp_9\",\"output\":[]}}")
+	response, ok := completedStreamResponse(chunk)
+	if !ok || gjson.GetBytes(response, "id").String() != "resp_9" {
+		t.Fatalf("completedStreamResponse() = %s, %v", response, ok)
+	}
+	if _, ok = completedStreamResponse([]byte(`data: {"type":"response.output_text.delta"}`)); ok {
+		t.Fatal("delta event treated as completed")
+	}
+}
+
+func TestResponsesStore_PassesPreviousResponseThroughWhenDisabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	if err := cache.DefaultResponsesStore().Configure(config.ResponsesStoreConfig{}, ""); err != nil {
+		t.Fatalf("Configure() error: %v", err)
+	}
+	h := NewOpenAIResponsesAPIHandler(&handlers.BaseAPIHandler{})
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+	plain := []byte(`{"model":"gpt-5","input":"hello"}`)
+	if out, items, errMsg := h.expandPreviousResponse(c, plain); errMsg != nil || string(out) != string(plain) || items != nil {
+		t.Fatalf("expandPreviousResponse() without previous_response_id = %s, %v, %v", out, items, errMsg)
+	}
+	chained := []byte(`{"model":"gpt-5","previous_response_id":"resp_1","input":"again"}`)
+	if out, items, errMsg := h.expandPreviousResponse(c, chained); errMsg != nil || string(out) != string(chained) || items != nil {
+		t.Fatalf("expandPreviousResponse() with the store disabled = %s, %v, %v, want the request unchanged", out, items, errMsg)
+	}
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/ratelimit"
+)
+
+// AcquireRequests charges n upstream requests, and n concurrent streams when stream is
+// set, against the rate limits of the client key that made the request in ctx. Handlers
+// call it when one client request turns into several upstream requests, because the rate
+// limiting middleware only charges the client request itself. The returned release
+// function frees the streams and is never nil.
+func (h *BaseAPIHandler) AcquireRequests(ctx context.Context, handlerType string, n int, stream bool) (func(), *interfaces.ErrorMessage) {
+	noop := func() {}
+	if ctx == nil || n <= 0 {
+		return noop, nil
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return noop, nil
+	}
+	release, err := ratelimit.Default().AcquireN(ginCtx.GetString("apiKey"), n, stream)
+	if err == nil {
+		return release, nil
+	}
+	var limitErr *ratelimit.LimitError
+	if !errors.As(err, &limitErr) {
+		return noop, nil
+	}
+	retryAfter := int(math.Ceil(limitErr.RetryAfter.Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	return noop, &interfaces.ErrorMessage{
+		StatusCode: http.StatusTooManyRequests,
+		Error:      errors.New(string(BuildErrorResponseBodyForFormat(handlerType, http.StatusTooManyRequests, limitErr.Reason))),
+		Addon:      http.Header{"Retry-After": {strconv.Itoa(retryAfter)}},
+	}
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+// responseCacheHeader reports the response cache outcome ("hit", "miss" or "bypass") and,
+// on requests, lets clients skip the cache by sending "bypass".
+const responseCacheHeader = "X-CPA-Cache"
+
+const responseCacheBypass = "bypass"
+
+// lookupResponseCache consults the response cache for a non-streaming request. It returns
+// the cached payload on a hit. Otherwise key is non-empty when the response should be
+// stored after execution, and ctx is tagged so usage records count the miss.
+func lookupResponseCache(ctx context.Context, handlerType, model, alt string, rawJSON []byte) (context.Context, string, []byte, bool) {
+	responseCache := cache.DefaultResponseCache()
+	if !responseCache.Cacheable(rawJSON) {
+		return ctx, "", nil, false
+	}
+	ginCtx, _ := ctx.Value("gin").(*gin.Context)
+	if ginCtx != nil && cacheBypassRequested(ginCtx) {
+		ginCtx.Header(responseCacheHeader, responseCacheBypass)
+		return ctx, "", nil, false
+	}
+	var apiKey string
+	if ginCtx != nil {
+		apiKey = ginCtx.GetString("apiKey")
+	}
+
+	key := cache.ResponseCacheKey(handlerType, model, alt, apiKey, rawJSON)
+	if cached, ok := responseCache.Get(key); ok {
+		if ginCtx != nil {
+			ginCtx.Header(responseCacheHeader, coreusage.CacheHit)
+		}
+		coreusage.PublishRecord(ctx, coreusage.Record{
+			Provider:    "cache",
+			Model:       model,
+			APIKey:      apiKey,
+			Source:      "response-cache",
+			RequestedAt: time.Now(),
+			Cache:       coreusage.CacheHit,
+		})
+		return ctx, key, cached, true
+	}
+	if ginCtx != nil {
+		ginCtx.Header(responseCacheHeader, coreusage.CacheMiss)
+	}
+	return coreusage.WithCacheStatus(ctx, coreusage.CacheMiss), key, nil, false
+}
+
+func cacheBypassRequested(c *gin.Context) bool {
+	if strings.EqualFold(strings.TrimSpace(c.GetHeader(responseCacheHeader)), responseCacheBypass) {
+		return true
+	}
+	for _, directive := range strings.Split(c.GetHeader("Cache-Control"), ",") {
+		switch strings.ToLower(strings.TrimSpace(directive)) {
+		case "no-cache", "no-store":
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/tokencount"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// fitContextWindow checks the estimated prompt of rawJSON against the input limit the
+// registry reports for model and applies the configured context-overflow mode when it does
+// not fit. It returns the model and payload to execute, which differ from the inputs only
+// when the request was rerouted or truncated. Payloads no larger in bytes than the limit
+// are not tokenized, since they cannot hold more tokens than bytes.
+func (h *BaseAPIHandler) fitContextWindow(handlerType, model string, rawJSON []byte) (string, []byte, *interfaces.ErrorMessage) {
+	info := registry.GetGlobalRegistry().GetModelInfo(model)
+	limit := contextLimit(info, rawJSON)
+	if limit <= 0 || int64(len(rawJSON)) <= limit {
+		return model, rawJSON, nil
+	}
+	count, err := tokencount.Estimate(handlerType, model, rawJSON)
+	if err != nil || count <= limit {
+		return model, rawJSON, nil
+	}
+
+	var overflow config.ContextOverflowConfig
+	if h.Cfg != nil {
+		overflow = h.Cfg.ContextOverflow
+	}
+	switch overflow.NormalizedMode() {
+	case config.ContextOverflowTruncate:
+		truncated, remaining, errTruncate := tokencount.Truncate(handlerType, model, rawJSON, limit)
+		if errTruncate == nil {
+			log.Debugf("context overflow: truncated %s prompt from %d to %d tokens (limit %d)", model, count, remaining, limit)
+			return model, truncated, nil
+		}
+		log.Debugf("context overflow: cannot truncate %s prompt: %v", model, errTruncate)
+	case config.ContextOverflowReroute:
+		if target := rerouteTarget(overflow, info, count, rawJSON); target != "" {
+			log.Debugf("context overflow: rerouting %d token prompt from %s to %s", count, model, target)
+			if gjson.GetBytes(rawJSON, "model").Exists() {
+				if updated, errSet := sjson.SetBytes(rawJSON, "model", target); errSet == nil {
+					rawJSON = updated
+				}
+			}
+			return target, rawJSON, nil
+		}
+	}
+	return model, rawJSON, contextLengthExceeded(handlerType, count, limit)
+}
+
+// contextLimit returns the prompt budget of a model: its input token limit, or otherwise
+// its context length less the output tokens the request reserves.
+func contextLimit(info *registry.ModelInfo, rawJSON []byte) int64 {
+	if info == nil {
+		return 0
+	}
+	if info.InputTokenLimit > 0 {
+		return int64(info.InputTokenLimit)
+	}
+	if info.ContextLength <= 0 {
+		return 0
+	}
+	limit := int64(info.ContextLength)
+	for _, path := range []string{"max_tokens", "max_completion_tokens", "max_output_tokens", "generationConfig.maxOutputTokens", "request.generationConfig.maxOutputTokens"} {
+		if reserved := gjson.GetBytes(rawJSON, path).Int(); reserved > 0 {
+			limit -= reserved
+			break
+		}
+	}
+	if limit <= 0 {
+		// The output reservation alone exceeds the context; leave it to the upstream.
+		return 0
+	}
+	return limit
+}
+
+// rerouteTarget returns the larger-context model configured for info in reroute-models,
+// or "" when none is configured or the registry reports that it cannot hold count prompt
+// tokens either. Only configured targets are used, so a reroute never silently moves a
+// request to another model tier.
+func rerouteTarget(overflow config.ContextOverflowConfig, info *registry.ModelInfo, count int64, rawJSON []byte) string {
+	target := strings.TrimSpace(overflow.RerouteModels[info.ID])
+	if target == "" {
+		return ""
+	}
+	if limit := contextLimit(registry.GetGlobalRegistry().GetModelInfo(target), rawJSON); limit > 0 && limit < count {
+		return ""
+	}
+	return target
+}
+
+// contextRerouteModel returns the name a context-overflow reroute to target is resolved
+// from, carrying over the thinking suffix the client put on model.
+func contextRerouteModel(target, model string, metadata map[string]any) string {
+	requested, _ := metadata[util.ThinkingOriginalModelMetadataKey].(string)
+	return withThinkingSuffix(target, requested, model)
+}
+
+// contextLengthExceeded builds a context_length_exceeded error worded like the native
+// error of the client's dialect.
+func contextLengthExceeded(handlerType string, count, limit int64) *interfaces.ErrorMessage {
+	var msg string
+	switch handlerType {
+	case "claude":
+		msg = fmt.Sprintf("prompt is too long: %d tokens > %d maximum", count, limit)
+	case "gemini", "gemini-cli":
+		msg = fmt.Sprintf("The input token count (%d) exceeds the maximum number of tokens allowed (%d).", count, limit)
+	default:
+		msg = fmt.Sprintf("This model's maximum context length is %d tokens. However, your messages resulted in %d tokens. Please reduce the length of the messages.", limit, count)
+	}
+	detail, _ := json.Marshal(ErrorResponse{Error: ErrorDetail{
+		Message: msg,
+		Type:    "invalid_request_error",
+		Code:    "context_length_exceeded",
+	}})
+	body := BuildErrorResponseBodyForFormat(handlerType, http.StatusBadRequest, string(detail))
+	return &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: errors.New(string(body))}
+}
+
+// lacksCountEndpoint reports whether a failed upstream token count means the provider has
+// no counting endpoint, in which case a local estimate is returned instead. Failures
+// without a status code come from executors that cannot count at all.
+func lacksCountEndpoint(status int) bool {
+	switch status {
+	case 0, http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	}
+	return false
+}
+
+// localTokenCount estimates the prompt tokens of rawJSON and renders the count as the
+// token count response of handlerType.
+func localTokenCount(ctx context.Context, handlerType, model string, rawJSON []byte) ([]byte, bool) {
+	count, err := tokencount.Estimate(handlerType, model, rawJSON)
+	if err != nil {
+		return nil, false
+	}
+	usageJSON := fmt.Sprintf(`{"usage":{"prompt_tokens":%d,"completion_tokens":0,"total_tokens":%d}}`, count, count)
+	translated := sdktranslator.TranslateTokenCount(ctx, sdktranslator.FormatOpenAI, sdktranslator.FromString(handlerType), count, []byte(usageJSON))
+	return []byte(translated), true
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestFitContextWindow_RejectsOversizedPrompt(t *testing.T) {
+	registry.GetGlobalRegistry().RegisterClient("token-limit-client", "claude", []*registry.ModelInfo{{ID: "token-limit-model", InputTokenLimit: 50}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient("token-limit-client") })
+
+	h := &BaseAPIHandler{Cfg: &config.SDKConfig{}}
+	small := []byte(`{"messages":[{"role":"user","content":"hi"}]}`)
+	if _, _, errMsg := h.fitContextWindow("claude", "token-limit-model", small); errMsg != nil {
+		t.Fatalf("small prompt rejected: %v", errMsg.Error)
+	}
+	large := []byte(`{"messages":[{"role":"user","content":"` + strings.Repeat("lorem ipsum dolor sit amet ", 40) + `"}]}`)
+	_, _, errMsg := h.fitContextWindow("claude", "token-limit-model", large)
+	if errMsg == nil || errMsg.StatusCode != http.StatusBadRequest {
+		t.Fatalf("large prompt error = %v, want 400", errMsg)
+	}
+	if body := errMsg.Error.Error(); gjson.Get(body, "type").String() != "error" || !strings.Contains(gjson.Get(body, "error.message").String(), "prompt is too long") {
+		t.Fatalf("claude error body = %s", body)
+	}
+	if _, _, errMsg = h.fitContextWindow("claude", "unknown-model", large); errMsg != nil {
+		t.Fatalf("model without a limit rejected: %v", errMsg.Error)
+	}
+	_, _, errMsg = h.fitContextWindow("openai", "token-limit-model", large)
+	if errMsg == nil || gjson.Get(errMsg.Error.Error(), "error.code").String() != "context_length_exceeded" {
+		t.Fatalf("openai error = %v, want context_length_exceeded", errMsg)
+	}
+}
+
+func TestFitContextWindow_TruncatesOldestTurns(t *testing.T) {
+	registry.GetGlobalRegistry().RegisterClient("truncate-client", "openai", []*registry.ModelInfo{{ID: "truncate-model", InputTokenLimit: 60}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient("truncate-client") })
+
+	h := &BaseAPIHandler{Cfg: &config.SDKConfig{ContextOverflow: config.ContextOverflowConfig{Mode: "truncate"}}}
+	filler := strings.Repeat("lorem ipsum dolor sit amet ", 10)
+	payload := []byte(`{"model":"truncate-model","messages":[` +
+		`{"role":"system","content":"be brief"},` +
+		`{"role":"user","content":"` + filler + `"},{"role":"assistant","content":"` + filler + `"},` +
+		`{"role":"user","content":"latest question"}]}`)
+	model, fitted, errMsg := h.fitContextWindow("openai", "truncate-model", payload)
+	if errMsg != nil {
+		t.Fatalf("truncate mode returned error: %v", errMsg.Error)
+	}
+	messages := gjson.GetBytes(fitted, "messages").Array()
+	if model != "truncate-model" || len(messages) != 2 || messages[0].Get("role").String() != "system" || messages[1].Get("content").String() != "latest question" {
+		t.Fatalf("fitContextWindow() = %s, %s; want system prompt and latest turn", model, fitted)
+	}
+}
+
+func TestFitContextWindow_ReroutesOnlyToConfiguredModel(t *testing.T) {
+	registry.GetGlobalRegistry().RegisterClient("reroute-client", "claude", []*registry.ModelInfo{
+		{ID: "reroute-small", OwnedBy: "acme", InputTokenLimit: 50},
+		{ID: "reroute-large", OwnedBy: "acme", InputTokenLimit: 100000},
+		{ID: "reroute-huge", OwnedBy: "acme", InputTokenLimit: 1000000},
+		{ID: "reroute-tiny", OwnedBy: "acme", InputTokenLimit: 60},
+	})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient("reroute-client") })
+
+	h := &BaseAPIHandler{Cfg: &config.SDKConfig{ContextOverflow: config.ContextOverflowConfig{Mode: "reroute"}}}
+	payload := []byte(`{"model":"reroute-small","messages":[{"role":"user","content":"` + strings.Repeat("lorem ipsum dolor sit amet ", 40) + `"}]}`)
+	if model, _, errMsg := h.fitContextWindow("claude", "reroute-small", payload); errMsg == nil || model != "reroute-small" {
+		t.Fatalf("unconfigured reroute = %q, %v; want a context length error", model, errMsg)
+	}
+
+	h.Cfg.ContextOverflow.RerouteModels = map[string]string{"reroute-small": "reroute-huge"}
+	model, fitted, errMsg := h.fitContextWindow("claude", "reroute-small", payload)
+	if errMsg != nil || model != "reroute-huge" || gjson.GetBytes(fitted, "model").String() != "reroute-huge" {
+		t.Fatalf("fitContextWindow() = %q, %s, %v; want reroute-huge", model, fitted, errMsg)
+	}
+
+	h.Cfg.ContextOverflow.RerouteModels = map[string]string{"reroute-small": "reroute-tiny"}
+	if _, _, errMsg = h.fitContextWindow("claude", "reroute-small", payload); errMsg == nil {
+		t.Fatal("reroute to a configured model that cannot hold the prompt succeeded")
+	}
+}
+
+func TestContextRerouteModel_KeepsThinkingSuffix(t *testing.T) {
+	registry.GetGlobalRegistry().RegisterClient("reroute-suffix-client", "claude", []*registry.ModelInfo{{ID: "reroute-suffix-small"}, {ID: "reroute-suffix-large"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient("reroute-suffix-client") })
+
+	h := &BaseAPIHandler{Cfg: &config.SDKConfig{}}
+	_, model, metadata, errMsg := h.getRequestDetails(context.Background(), "reroute-suffix-small(high)")
+	if errMsg != nil {
+		t.Fatalf("getRequestDetails() error = %v", errMsg.Error)
+	}
+	_, model, metadata, errMsg = h.getRequestDetails(context.Background(), contextRerouteModel("reroute-suffix-large", model, metadata))
+	if errMsg != nil || model != "reroute-suffix-large" {
+		t.Fatalf("rerouted details = %q, %v; want reroute-suffix-large", model, errMsg)
+	}
+	if metadata[util.ThinkingOriginalModelMetadataKey] != "reroute-suffix-large(high)" || metadata[util.ReasoningEffortMetadataKey] != "high" {
+		t.Fatalf("rerouted metadata = %v, want the client suffix carried over", metadata)
+	}
+	if got := contextRerouteModel("reroute-suffix-large", "reroute-suffix-small", nil); got != "reroute-suffix-large" {
+		t.Fatalf("unsuffixed reroute = %q", got)
+	}
+}
+
+func TestLocalTokenCount_RendersHandlerFormat(t *testing.T) {
+	payload := []byte(`{"messages":[{"role":"user","content":"hello there"}]}`)
+	out, ok := localTokenCount(context.Background(), "claude", "claude-sonnet-4", payload)
+	if !ok || gjson.GetBytes(out, "input_tokens").Int() <= 0 {
+		t.Fatalf("localTokenCount(claude) = %s, %v; want input_tokens", out, ok)
+	}
+	if !lacksCountEndpoint(http.StatusNotImplemented) || lacksCountEndpoint(http.StatusTooManyRequests) {
+		t.Fatal("lacksCountEndpoint() misclassifies upstream failures")
+	}
+}
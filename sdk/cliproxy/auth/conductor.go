@@ -14,6 +14,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/tracing"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
 	log "github.com/sirupsen/logrus"
@@ -67,6 +68,12 @@ type Result struct {
 	RetryAfter *time.Duration
 	// Error describes the failure when Success is false.
 	Error *Error
+	// Latency is the wall-clock duration of the upstream call; zero when not measured.
+	Latency time.Duration
+	// TimeToFirstToken is the delay before the first stream chunk; zero for non-streaming calls.
+	TimeToFirstToken time.Duration
+	// Probe marks results of health probes, which update auth state but are not client traffic.
+	Probe bool
 }
 
 // Selector chooses an auth candidate for execution.
@@ -74,6 +81,12 @@ type Selector interface {
 	Pick(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, auths []*Auth) (*Auth, error)
 }
 
+// ResultObserver is implemented by selectors that learn from execution outcomes.
+// The manager forwards every result passed to MarkResult.
+type ResultObserver interface {
+	ObserveResult(result Result)
+}
+
 // Hook captures lifecycle callbacks for observing auth changes.
 type Hook interface {
 	// OnAuthRegistered fires when a new auth is registered.
@@ -84,6 +97,26 @@ type Hook interface {
 	OnResult(ctx context.Context, result Result)
 }
 
+// RefreshHook is an optional extension of Hook notified after every credential refresh
+// attempt. err is nil when the refresh succeeded.
+type RefreshHook interface {
+	OnAuthRefreshed(ctx context.Context, auth *Auth, err error)
+}
+
+// ExecutionHook observes provider executions around the executor call.
+// BeforeExecute may mutate the request and options handed to the executor and
+// returns the context used for the remainder of the execution. It runs before the
+// executor translates the request, so the payload is still in the client's schema
+// (opts.SourceFormat), not the provider's.
+type ExecutionHook interface {
+	// BeforeExecute fires after an auth is selected and before the executor runs.
+	BeforeExecute(ctx context.Context, auth *Auth, req *cliproxyexecutor.Request, opts *cliproxyexecutor.Options) context.Context
+	// AfterExecute fires once the executor returns or a stream terminates.
+	AfterExecute(ctx context.Context, auth *Auth, resp cliproxyexecutor.Response, err error)
+	// OnStreamChunk fires for every chunk produced by a streaming executor.
+	OnStreamChunk(ctx context.Context, auth *Auth, chunk cliproxyexecutor.StreamChunk)
+}
+
 // NoopHook provides optional hook defaults.
 type NoopHook struct{}
 
@@ -114,6 +147,21 @@ type Manager struct {
 	// Optional HTTP RoundTripper provider injected by host.
 	rtProvider RoundTripperProvider
 
+	// Optional execution hook invoked around every executor call.
+	execHook ExecutionHook
+
+	// modelFallbacks maps a lower-cased requested model to the models tried after it fails.
+	modelFallbacks map[string][]string
+
+	// hedging configures racing a second credential against a slow first attempt.
+	hedging hedgeSettings
+
+	// queue holds requests while every credential for their model is cooling down.
+	queue *cooldownQueue
+
+	// prober sends scheduled synthetic requests to detect failing credentials early.
+	prober *healthProber
+
 	// Auto refresh state
 	refreshCancel context.CancelFunc
 }
@@ -133,6 +181,8 @@ func NewManager(store Store, selector Selector, hook Hook) *Manager {
 		hook:            hook,
 		auths:           make(map[string]*Auth),
 		providerOffsets: make(map[string]int),
+		queue:           newCooldownQueue(),
+		prober:          newHealthProber(),
 	}
 }
 
@@ -162,6 +212,22 @@ func (m *Manager) SetRoundTripperProvider(p RoundTripperProvider) {
 	m.mu.Unlock()
 }
 
+// SetExecutionHook registers a hook invoked around Execute and ExecuteStream calls.
+func (m *Manager) SetExecutionHook(hook ExecutionHook) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.execHook = hook
+	m.mu.Unlock()
+}
+
+func (m *Manager) executionHook() ExecutionHook {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.execHook
+}
+
 // SetRetryConfig updates retry attempts and cooldown wait interval.
 func (m *Manager) SetRetryConfig(retry int, maxRetryInterval time.Duration) {
 	if m == nil {
@@ -257,7 +323,41 @@ func (m *Manager) Load(ctx context.Context) error {
 
 // Execute performs a non-streaming execution using the configured selector and executor.
 // It supports multiple providers for the same model and round-robins the starting provider per model.
+// When every provider fails and a fallback chain is configured for the model, the chain is
+// walked in order and the served model is reported via WithServedModelCallback.
 func (m *Manager) Execute(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	resp, err := m.executeModel(ctx, providers, req, opts)
+	if err == nil {
+		reportServedModel(ctx, req.Model)
+		return resp, nil
+	}
+	err = m.executeFallbacks(ctx, req, err, func(fallbackProviders []string, fallbackReq cliproxyexecutor.Request) error {
+		var errFallback error
+		resp, errFallback = m.executeModel(ctx, fallbackProviders, fallbackReq, opts)
+		return errFallback
+	})
+	if err != nil {
+		return cliproxyexecutor.Response{}, err
+	}
+	return resp, nil
+}
+
+// executeModel runs executeModelAttempts and, when every credential is cooling down,
+// waits in the cooldown queue before trying again.
+func (m *Manager) executeModel(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	var ticket queueTicket
+	for {
+		resp, err := m.executeModelAttempts(ctx, providers, req, opts)
+		if err == nil {
+			return resp, nil
+		}
+		if errQueue := m.awaitCooldown(ctx, &ticket, m.normalizeProviders(providers), req.Model, opts, err); errQueue != nil {
+			return cliproxyexecutor.Response{}, errQueue
+		}
+	}
+}
+
+func (m *Manager) executeModelAttempts(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
 	normalized := m.normalizeProviders(providers)
 	if len(normalized) == 0 {
 		return cliproxyexecutor.Response{}, &Error{Code: "provider_not_found", Message: "no provider supplied"}
@@ -271,8 +371,9 @@ func (m *Manager) Execute(ctx context.Context, providers []string, req cliproxye
 	}
 
 	var lastErr error
+	attemptCtx := ctx
 	for attempt := 0; attempt < attempts; attempt++ {
-		resp, errExec := m.executeProvidersOnce(ctx, rotated, func(execCtx context.Context, provider string) (cliproxyexecutor.Response, error) {
+		resp, errExec := m.executeProvidersOnce(attemptCtx, rotated, func(execCtx context.Context, provider string) (cliproxyexecutor.Response, error) {
 			return m.executeWithProvider(execCtx, provider, req, opts)
 		})
 		if errExec == nil {
@@ -286,6 +387,7 @@ func (m *Manager) Execute(ctx context.Context, providers []string, req cliproxye
 		if errWait := waitForCooldown(ctx, wait); errWait != nil {
 			return cliproxyexecutor.Response{}, errWait
 		}
+		attemptCtx = withRetryAttempt(ctx, attempt+1, errExec)
 	}
 	if lastErr != nil {
 		return cliproxyexecutor.Response{}, lastErr
@@ -309,8 +411,9 @@ func (m *Manager) ExecuteCount(ctx context.Context, providers []string, req clip
 	}
 
 	var lastErr error
+	attemptCtx := ctx
 	for attempt := 0; attempt < attempts; attempt++ {
-		resp, errExec := m.executeProvidersOnce(ctx, rotated, func(execCtx context.Context, provider string) (cliproxyexecutor.Response, error) {
+		resp, errExec := m.executeProvidersOnce(attemptCtx, rotated, func(execCtx context.Context, provider string) (cliproxyexecutor.Response, error) {
 			return m.executeCountWithProvider(execCtx, provider, req, opts)
 		})
 		if errExec == nil {
@@ -324,6 +427,7 @@ func (m *Manager) ExecuteCount(ctx context.Context, providers []string, req clip
 		if errWait := waitForCooldown(ctx, wait); errWait != nil {
 			return cliproxyexecutor.Response{}, errWait
 		}
+		attemptCtx = withRetryAttempt(ctx, attempt+1, errExec)
 	}
 	if lastErr != nil {
 		return cliproxyexecutor.Response{}, lastErr
@@ -333,7 +437,40 @@ func (m *Manager) ExecuteCount(ctx context.Context, providers []string, req clip
 
 // ExecuteStream performs a streaming execution using the configured selector and executor.
 // It supports multiple providers for the same model and round-robins the starting provider per model.
+// Model fallback chains apply as in Execute when the stream cannot be started.
 func (m *Manager) ExecuteStream(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	chunks, err := m.executeStreamModel(ctx, providers, req, opts)
+	if err == nil {
+		reportServedModel(ctx, req.Model)
+		return chunks, nil
+	}
+	err = m.executeFallbacks(ctx, req, err, func(fallbackProviders []string, fallbackReq cliproxyexecutor.Request) error {
+		var errFallback error
+		chunks, errFallback = m.executeStreamModel(ctx, fallbackProviders, fallbackReq, opts)
+		return errFallback
+	})
+	if err != nil {
+		return nil, err
+	}
+	return chunks, nil
+}
+
+// executeStreamModel runs executeStreamModelAttempts and, when every credential is cooling
+// down, waits in the cooldown queue before trying again.
+func (m *Manager) executeStreamModel(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	var ticket queueTicket
+	for {
+		chunks, err := m.executeStreamModelAttempts(ctx, providers, req, opts)
+		if err == nil {
+			return chunks, nil
+		}
+		if errQueue := m.awaitCooldown(ctx, &ticket, m.normalizeProviders(providers), req.Model, opts, err); errQueue != nil {
+			return nil, errQueue
+		}
+	}
+}
+
+func (m *Manager) executeStreamModelAttempts(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
 	normalized := m.normalizeProviders(providers)
 	if len(normalized) == 0 {
 		return nil, &Error{Code: "provider_not_found", Message: "no provider supplied"}
@@ -347,8 +484,9 @@ func (m *Manager) ExecuteStream(ctx context.Context, providers []string, req cli
 	}
 
 	var lastErr error
+	attemptCtx := ctx
 	for attempt := 0; attempt < attempts; attempt++ {
-		chunks, errStream := m.executeStreamProvidersOnce(ctx, rotated, func(execCtx context.Context, provider string) (<-chan cliproxyexecutor.StreamChunk, error) {
+		chunks, errStream := m.executeStreamProvidersOnce(attemptCtx, rotated, func(execCtx context.Context, provider string) (<-chan cliproxyexecutor.StreamChunk, error) {
 			return m.executeStreamWithProvider(execCtx, provider, req, opts)
 		})
 		if errStream == nil {
@@ -362,6 +500,7 @@ func (m *Manager) ExecuteStream(ctx context.Context, providers []string, req cli
 		if errWait := waitForCooldown(ctx, wait); errWait != nil {
 			return nil, errWait
 		}
+		attemptCtx = withRetryAttempt(ctx, attempt+1, errStream)
 	}
 	if lastErr != nil {
 		return nil, lastErr
@@ -384,52 +523,92 @@ func (m *Manager) executeWithProvider(ctx context.Context, provider string, req
 			}
 			return cliproxyexecutor.Response{}, errPick
 		}
+		tried[auth.ID] = struct{}{}
 
-		accountType, accountInfo := auth.AccountInfo()
-		proxyInfo := auth.ProxyInfo()
-		entry := logEntryWithRequestID(ctx)
-		if accountType == "api_key" {
-			if proxyInfo != "" {
-				entry.Debugf("Use API key %s for model %s %s", util.HideAPIKey(accountInfo), req.Model, proxyInfo)
-			} else {
-				entry.Debugf("Use API key %s for model %s", util.HideAPIKey(accountInfo), req.Model)
-			}
-		} else if accountType == "oauth" {
-			if proxyInfo != "" {
-				entry.Debugf("Use OAuth %s for model %s %s", accountInfo, req.Model, proxyInfo)
-			} else {
-				entry.Debugf("Use OAuth %s for model %s", accountInfo, req.Model)
+		if delay := m.hedgeDelayFor(routeModel); delay > 0 {
+			resp, errExec := m.executeHedged(ctx, provider, auth, executor, req, opts, tried, lastErr, delay)
+			if errExec != nil {
+				lastErr = errExec
+				continue
 			}
+			return resp, nil
 		}
 
-		tried[auth.ID] = struct{}{}
-		execCtx := ctx
-		if rt := m.roundTripperFor(auth); rt != nil {
-			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
-			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
-		}
-		execReq := req
-		execReq.Model, execReq.Metadata = rewriteModelForAuth(routeModel, req.Metadata, auth)
-		resp, errExec := executor.Execute(execCtx, auth, execReq, opts)
-		result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: errExec == nil}
+		execCtx, resp, result, errExec := m.runExecuteAttempt(ctx, provider, auth, executor, req, opts, lastErr)
+		m.MarkResult(execCtx, result)
 		if errExec != nil {
-			result.Error = &Error{Message: errExec.Error()}
-			var se cliproxyexecutor.StatusError
-			if errors.As(errExec, &se) && se != nil {
-				result.Error.HTTPStatus = se.StatusCode()
-			}
-			if ra := retryAfterFromError(errExec); ra != nil {
-				result.RetryAfter = ra
-			}
-			m.MarkResult(execCtx, result)
 			lastErr = errExec
 			continue
 		}
-		m.MarkResult(execCtx, result)
 		return resp, nil
 	}
 }
 
+// runExecuteAttempt executes req on auth and returns the result to record, leaving the
+// MarkResult call to the caller.
+func (m *Manager) runExecuteAttempt(ctx context.Context, provider string, auth *Auth, executor ProviderExecutor, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, lastErr error) (context.Context, cliproxyexecutor.Response, Result, error) {
+	routeModel := req.Model
+	logAuthSelection(ctx, auth, req.Model)
+	execCtx := m.withRateLimitReporter(m.withAuthRoundTripper(ctx, auth), auth)
+	execReq := req
+	execReq.Model, execReq.Metadata = rewriteModelForAuth(routeModel, req.Metadata, auth)
+	execCtx, span := startAttemptSpan(execCtx, provider, routeModel, auth, false, lastErr)
+	execOpts := opts
+	hook := m.executionHook()
+	if hook != nil {
+		if hookCtx := hook.BeforeExecute(execCtx, auth, &execReq, &execOpts); hookCtx != nil {
+			execCtx = hookCtx
+		}
+	}
+	startedAt := time.Now()
+	resp, errExec := executor.Execute(execCtx, auth, execReq, execOpts)
+	if hook != nil {
+		hook.AfterExecute(execCtx, auth, resp, errExec)
+	}
+	endAttemptSpan(span, errExec)
+	result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: errExec == nil, Latency: time.Since(startedAt)}
+	if errExec != nil {
+		result.Error = &Error{Message: errExec.Error()}
+		var se cliproxyexecutor.StatusError
+		if errors.As(errExec, &se) && se != nil {
+			result.Error.HTTPStatus = se.StatusCode()
+		}
+		if ra := retryAfterFromError(errExec); ra != nil {
+			result.RetryAfter = ra
+		}
+	}
+	return execCtx, resp, result, errExec
+}
+
+// logAuthSelection logs the credential chosen for model at debug level.
+func logAuthSelection(ctx context.Context, auth *Auth, model string) {
+	accountType, accountInfo := auth.AccountInfo()
+	proxyInfo := auth.ProxyInfo()
+	entry := logEntryWithRequestID(ctx)
+	if accountType == "api_key" {
+		if proxyInfo != "" {
+			entry.Debugf("Use API key %s for model %s %s", util.HideAPIKey(accountInfo), model, proxyInfo)
+		} else {
+			entry.Debugf("Use API key %s for model %s", util.HideAPIKey(accountInfo), model)
+		}
+	} else if accountType == "oauth" {
+		if proxyInfo != "" {
+			entry.Debugf("Use OAuth %s for model %s %s", accountInfo, model, proxyInfo)
+		} else {
+			entry.Debugf("Use OAuth %s for model %s", accountInfo, model)
+		}
+	}
+}
+
+// withAuthRoundTripper attaches the host-provided RoundTripper for auth to ctx.
+func (m *Manager) withAuthRoundTripper(ctx context.Context, auth *Auth) context.Context {
+	if rt := m.roundTripperFor(auth); rt != nil {
+		ctx = context.WithValue(ctx, roundTripperContextKey{}, rt)
+		ctx = context.WithValue(ctx, "cliproxy.roundtripper", rt)
+	}
+	return ctx
+}
+
 func (m *Manager) executeCountWithProvider(ctx context.Context, provider string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
 	if provider == "" {
 		return cliproxyexecutor.Response{}, &Error{Code: "provider_not_found", Message: "provider identifier is empty"}
@@ -464,14 +643,16 @@ func (m *Manager) executeCountWithProvider(ctx context.Context, provider string,
 		}
 
 		tried[auth.ID] = struct{}{}
-		execCtx := ctx
+		execCtx := m.withRateLimitReporter(ctx, auth)
 		if rt := m.roundTripperFor(auth); rt != nil {
 			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
 			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
 		}
 		execReq := req
 		execReq.Model, execReq.Metadata = rewriteModelForAuth(routeModel, req.Metadata, auth)
+		execCtx, span := startAttemptSpan(execCtx, provider, routeModel, auth, false, lastErr)
 		resp, errExec := executor.CountTokens(execCtx, auth, execReq, opts)
+		endAttemptSpan(span, errExec)
 		result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: errExec == nil}
 		if errExec != nil {
 			result.Error = &Error{Message: errExec.Error()}
@@ -506,67 +687,122 @@ func (m *Manager) executeStreamWithProvider(ctx context.Context, provider string
 			}
 			return nil, errPick
 		}
+		tried[auth.ID] = struct{}{}
 
-		accountType, accountInfo := auth.AccountInfo()
-		proxyInfo := auth.ProxyInfo()
-		entry := logEntryWithRequestID(ctx)
-		if accountType == "api_key" {
-			if proxyInfo != "" {
-				entry.Debugf("Use API key %s for model %s %s", util.HideAPIKey(accountInfo), req.Model, proxyInfo)
-			} else {
-				entry.Debugf("Use API key %s for model %s", util.HideAPIKey(accountInfo), req.Model)
-			}
-		} else if accountType == "oauth" {
-			if proxyInfo != "" {
-				entry.Debugf("Use OAuth %s for model %s %s", accountInfo, req.Model, proxyInfo)
-			} else {
-				entry.Debugf("Use OAuth %s for model %s", accountInfo, req.Model)
+		if delay := m.hedgeDelayFor(routeModel); delay > 0 {
+			out, errStream := m.executeStreamHedged(ctx, provider, auth, executor, req, opts, tried, lastErr, delay)
+			if errStream != nil {
+				lastErr = errStream
+				continue
 			}
+			return out, nil
 		}
 
-		tried[auth.ID] = struct{}{}
-		execCtx := ctx
-		if rt := m.roundTripperFor(auth); rt != nil {
-			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
-			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
-		}
-		execReq := req
-		execReq.Model, execReq.Metadata = rewriteModelForAuth(routeModel, req.Metadata, auth)
-		chunks, errStream := executor.ExecuteStream(execCtx, auth, execReq, opts)
+		attempt, result, errStream := m.startStreamAttempt(ctx, provider, auth, executor, req, opts, lastErr)
 		if errStream != nil {
-			rerr := &Error{Message: errStream.Error()}
-			var se cliproxyexecutor.StatusError
-			if errors.As(errStream, &se) && se != nil {
-				rerr.HTTPStatus = se.StatusCode()
-			}
-			result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: false, Error: rerr}
-			result.RetryAfter = retryAfterFromError(errStream)
-			m.MarkResult(execCtx, result)
+			m.MarkResult(attempt.ctx, result)
 			lastErr = errStream
 			continue
 		}
-		out := make(chan cliproxyexecutor.StreamChunk)
-		go func(streamCtx context.Context, streamAuth *Auth, streamProvider string, streamChunks <-chan cliproxyexecutor.StreamChunk) {
-			defer close(out)
-			var failed bool
-			for chunk := range streamChunks {
-				if chunk.Err != nil && !failed {
-					failed = true
-					rerr := &Error{Message: chunk.Err.Error()}
-					var se cliproxyexecutor.StatusError
-					if errors.As(chunk.Err, &se) && se != nil {
-						rerr.HTTPStatus = se.StatusCode()
-					}
-					m.MarkResult(streamCtx, Result{AuthID: streamAuth.ID, Provider: streamProvider, Model: routeModel, Success: false, Error: rerr})
-				}
-				out <- chunk
+		return m.forwardStream(attempt, nil, nil), nil
+	}
+}
+
+// streamAttempt tracks a stream opened on a single credential.
+type streamAttempt struct {
+	ctx        context.Context
+	auth       *Auth
+	provider   string
+	routeModel string
+	span       *tracing.Span
+	hook       ExecutionHook
+	startedAt  time.Time
+	chunks     <-chan cliproxyexecutor.StreamChunk
+}
+
+// startStreamAttempt opens a stream for req on auth. When opening fails it returns the
+// result to record, leaving the MarkResult call to the caller.
+func (m *Manager) startStreamAttempt(ctx context.Context, provider string, auth *Auth, executor ProviderExecutor, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, lastErr error) (*streamAttempt, Result, error) {
+	routeModel := req.Model
+	logAuthSelection(ctx, auth, req.Model)
+	execCtx := m.withRateLimitReporter(m.withAuthRoundTripper(ctx, auth), auth)
+	execReq := req
+	execReq.Model, execReq.Metadata = rewriteModelForAuth(routeModel, req.Metadata, auth)
+	execCtx, span := startAttemptSpan(execCtx, provider, routeModel, auth, true, lastErr)
+	execOpts := opts
+	hook := m.executionHook()
+	if hook != nil {
+		if hookCtx := hook.BeforeExecute(execCtx, auth, &execReq, &execOpts); hookCtx != nil {
+			execCtx = hookCtx
+		}
+	}
+	attempt := &streamAttempt{ctx: execCtx, auth: auth.Clone(), provider: provider, routeModel: routeModel, span: span, hook: hook, startedAt: time.Now()}
+	chunks, errStream := executor.ExecuteStream(execCtx, auth, execReq, execOpts)
+	if errStream != nil {
+		endAttemptSpan(span, errStream)
+		if hook != nil {
+			hook.AfterExecute(execCtx, auth, cliproxyexecutor.Response{}, errStream)
+		}
+		return attempt, attempt.failure(errStream), errStream
+	}
+	attempt.chunks = chunks
+	return attempt, Result{}, nil
+}
+
+// failure builds the failed result recorded for err.
+func (a *streamAttempt) failure(err error) Result {
+	rerr := &Error{Message: err.Error()}
+	var se cliproxyexecutor.StatusError
+	if errors.As(err, &se) && se != nil {
+		rerr.HTTPStatus = se.StatusCode()
+	}
+	return Result{AuthID: a.auth.ID, Provider: a.provider, Model: a.routeModel, Success: false, Error: rerr, RetryAfter: retryAfterFromError(err), Latency: time.Since(a.startedAt)}
+}
+
+// forwardStream relays the attempt's chunks, preceded by first when it was already read,
+// and records the outcome once the stream ends. done runs after the last chunk.
+func (m *Manager) forwardStream(attempt *streamAttempt, first *cliproxyexecutor.StreamChunk, done func()) <-chan cliproxyexecutor.StreamChunk {
+	out := make(chan cliproxyexecutor.StreamChunk)
+	go func() {
+		defer close(out)
+		if done != nil {
+			defer done()
+		}
+		var failed bool
+		var streamErr error
+		var firstChunk time.Duration
+		handle := func(chunk cliproxyexecutor.StreamChunk) {
+			if firstChunk == 0 {
+				firstChunk = time.Since(attempt.startedAt)
+				attempt.span.AddEvent("first_byte")
 			}
-			if !failed {
-				m.MarkResult(streamCtx, Result{AuthID: streamAuth.ID, Provider: streamProvider, Model: routeModel, Success: true})
+			if attempt.hook != nil {
+				attempt.hook.OnStreamChunk(attempt.ctx, attempt.auth, chunk)
 			}
-		}(execCtx, auth.Clone(), provider, chunks)
-		return out, nil
-	}
+			if chunk.Err != nil && !failed {
+				failed = true
+				streamErr = chunk.Err
+				result := attempt.failure(chunk.Err)
+				result.RetryAfter = nil
+				m.MarkResult(attempt.ctx, result)
+			}
+			out <- chunk
+		}
+		if first != nil {
+			handle(*first)
+		}
+		for chunk := range attempt.chunks {
+			handle(chunk)
+		}
+		if !failed {
+			m.MarkResult(attempt.ctx, Result{AuthID: attempt.auth.ID, Provider: attempt.provider, Model: attempt.routeModel, Success: true, Latency: time.Since(attempt.startedAt), TimeToFirstToken: firstChunk})
+		}
+		endAttemptSpan(attempt.span, streamErr)
+		if attempt.hook != nil {
+			attempt.hook.AfterExecute(attempt.ctx, attempt.auth, cliproxyexecutor.Response{}, streamErr)
+		}
+	}()
+	return out
 }
 
 func rewriteModelForAuth(model string, metadata map[string]any, auth *Auth) (string, map[string]any) {
@@ -796,6 +1032,7 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 	setModelQuota := false
 
 	m.mu.Lock()
+	observer, _ := m.selector.(ResultObserver)
 	if auth, ok := m.auths[result.AuthID]; ok && auth != nil {
 		now := time.Now()
 
@@ -886,6 +1123,10 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 	}
 	m.mu.Unlock()
 
+	if observer != nil {
+		observer.ObserveResult(result)
+	}
+
 	if clearModelQuota && result.Model != "" {
 		registry.GetGlobalRegistry().ClearModelQuotaExceeded(result.AuthID, result.Model)
 	}
@@ -1551,6 +1792,7 @@ func (m *Manager) refreshAuth(ctx context.Context, id string) {
 			m.auths[id] = current
 		}
 		m.mu.Unlock()
+		m.notifyRefresh(ctx, auth, err)
 		return
 	}
 	if updated == nil {
@@ -1566,6 +1808,13 @@ func (m *Manager) refreshAuth(ctx context.Context, id string) {
 	updated.LastError = nil
 	updated.UpdatedAt = now
 	_, _ = m.Update(ctx, updated)
+	m.notifyRefresh(ctx, updated, nil)
+}
+
+func (m *Manager) notifyRefresh(ctx context.Context, auth *Auth, err error) {
+	if hook, ok := m.hook.(RefreshHook); ok {
+		hook.OnAuthRefreshed(ctx, auth.Clone(), err)
+	}
 }
 
 func (m *Manager) executorFor(provider string) ProviderExecutor {
@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+type recordingExecutor struct {
+	provider string
+	mu       sync.Mutex
+	payloads []string
+}
+
+func (e *recordingExecutor) Identifier() string { return e.provider }
+
+func (e *recordingExecutor) Execute(_ context.Context, _ *Auth, req cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	e.mu.Lock()
+	e.payloads = append(e.payloads, string(req.Payload))
+	e.mu.Unlock()
+	return cliproxyexecutor.Response{Payload: []byte("ok")}, nil
+}
+
+func (e *recordingExecutor) ExecuteStream(_ context.Context, _ *Auth, req cliproxyexecutor.Request, _ cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	e.mu.Lock()
+	e.payloads = append(e.payloads, string(req.Payload))
+	e.mu.Unlock()
+	out := make(chan cliproxyexecutor.StreamChunk, 2)
+	out <- cliproxyexecutor.StreamChunk{Payload: []byte("a")}
+	out <- cliproxyexecutor.StreamChunk{Payload: []byte("b")}
+	close(out)
+	return out, nil
+}
+
+func (e *recordingExecutor) Refresh(_ context.Context, auth *Auth) (*Auth, error) { return auth, nil }
+
+func (e *recordingExecutor) CountTokens(_ context.Context, _ *Auth, _ cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, nil
+}
+
+type recordingHook struct {
+	mu     sync.Mutex
+	before int
+	after  int
+	chunks []string
+	authID string
+}
+
+func (h *recordingHook) BeforeExecute(ctx context.Context, auth *Auth, req *cliproxyexecutor.Request, _ *cliproxyexecutor.Options) context.Context {
+	h.mu.Lock()
+	h.before++
+	h.authID = auth.ID
+	h.mu.Unlock()
+	req.Payload = []byte("mutated")
+	return ctx
+}
+
+func (h *recordingHook) AfterExecute(context.Context, *Auth, cliproxyexecutor.Response, error) {
+	h.mu.Lock()
+	h.after++
+	h.mu.Unlock()
+}
+
+func (h *recordingHook) OnStreamChunk(_ context.Context, _ *Auth, chunk cliproxyexecutor.StreamChunk) {
+	h.mu.Lock()
+	h.chunks = append(h.chunks, string(chunk.Payload))
+	h.mu.Unlock()
+}
+
+func newHookTestManager(t *testing.T, provider, model string) (*Manager, *recordingExecutor, *recordingHook) {
+	t.Helper()
+
+	executor := &recordingExecutor{provider: provider}
+	hook := &recordingHook{}
+	manager := NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	manager.SetExecutionHook(hook)
+
+	auth := &Auth{ID: provider + "-auth", Provider: provider}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, provider, []*registry.ModelInfo{{ID: model}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+	return manager, executor, hook
+}
+
+func TestManagerExecute_InvokesExecutionHook(t *testing.T) {
+	manager, executor, hook := newHookTestManager(t, "hook-exec", "hook-exec-model")
+
+	req := cliproxyexecutor.Request{Model: "hook-exec-model", Payload: []byte("original")}
+	if _, err := manager.Execute(context.Background(), []string{"hook-exec"}, req, cliproxyexecutor.Options{}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	if hook.before != 1 || hook.after != 1 {
+		t.Fatalf("hook calls before=%d after=%d, want 1 and 1", hook.before, hook.after)
+	}
+	if hook.authID != "hook-exec-auth" {
+		t.Fatalf("hook auth ID = %q, want %q", hook.authID, "hook-exec-auth")
+	}
+	if len(executor.payloads) != 1 || executor.payloads[0] != "mutated" {
+		t.Fatalf("executor payloads = %v, want [mutated]", executor.payloads)
+	}
+}
+
+func TestManagerExecuteStream_InvokesChunkHook(t *testing.T) {
+	manager, _, hook := newHookTestManager(t, "hook-stream", "hook-stream-model")
+
+	req := cliproxyexecutor.Request{Model: "hook-stream-model", Payload: []byte("original")}
+	chunks, err := manager.ExecuteStream(context.Background(), []string{"hook-stream"}, req, cliproxyexecutor.Options{Stream: true})
+	if err != nil {
+		t.Fatalf("ExecuteStream() error = %v", err)
+	}
+	for range chunks {
+	}
+
+	hook.mu.Lock()
+	defer hook.mu.Unlock()
+	if len(hook.chunks) != 2 || hook.chunks[0] != "a" || hook.chunks[1] != "b" {
+		t.Fatalf("hook chunks = %v, want [a b]", hook.chunks)
+	}
+	if hook.before != 1 || hook.after != 1 {
+		t.Fatalf("hook calls before=%d after=%d, want 1 and 1", hook.before, hook.after)
+	}
+}
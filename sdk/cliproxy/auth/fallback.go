@@ -0,0 +1,190 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// servedModelCallbackKey stores the callback notified with the model that served a request.
+type servedModelCallbackKey struct{}
+
+// WithServedModelCallback returns a context whose executions report the model that
+// actually served the request, which differs from the requested model when a fallback
+// chain was used. The callback runs before Execute or ExecuteStream returns.
+func WithServedModelCallback(ctx context.Context, fn func(model string)) context.Context {
+	if fn == nil {
+		return ctx
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, servedModelCallbackKey{}, fn)
+}
+
+func reportServedModel(ctx context.Context, model string) {
+	if ctx == nil || model == "" {
+		return
+	}
+	if fn, ok := ctx.Value(servedModelCallbackKey{}).(func(string)); ok && fn != nil {
+		fn(model)
+	}
+}
+
+// fallbackFilterKey stores the filter applied to fallback models and their providers.
+type fallbackFilterKey struct{}
+
+// WithFallbackFilter returns a context whose executions pass each fallback model and its
+// providers through fn before trying it. fn returns the providers the caller may use for
+// the model; a fallback left with none is skipped, so fallback chains honour the same
+// access policy as the requested model.
+func WithFallbackFilter(ctx context.Context, fn func(model string, providers []string) []string) context.Context {
+	if fn == nil {
+		return ctx
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, fallbackFilterKey{}, fn)
+}
+
+func filterFallbackProviders(ctx context.Context, model string, providers []string) []string {
+	if ctx == nil || len(providers) == 0 {
+		return providers
+	}
+	if fn, ok := ctx.Value(fallbackFilterKey{}).(func(string, []string) []string); ok && fn != nil {
+		return fn(model, providers)
+	}
+	return providers
+}
+
+// SetModelFallbacks replaces the fallback chains keyed by requested model name.
+// Each chain lists models tried in order once every provider for the requested model
+// has failed and retries are exhausted.
+func (m *Manager) SetModelFallbacks(chains map[string][]string) {
+	if m == nil {
+		return
+	}
+	next := make(map[string][]string, len(chains))
+	for model, fallbacks := range chains {
+		key := strings.ToLower(strings.TrimSpace(model))
+		if key == "" || len(fallbacks) == 0 {
+			continue
+		}
+		next[key] = append([]string(nil), fallbacks...)
+	}
+	m.mu.Lock()
+	m.modelFallbacks = next
+	m.mu.Unlock()
+}
+
+func (m *Manager) fallbackChain(model string) []string {
+	if m == nil {
+		return nil
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.modelFallbacks[strings.ToLower(strings.TrimSpace(model))]
+}
+
+// executeFallbacks walks the fallback chain for req.Model after the requested model failed
+// with err. run is invoked for each fallback model that has at least one provider left
+// after the context's fallback filter. The
+// original error is returned when no fallback succeeds so clients see why their model failed.
+func (m *Manager) executeFallbacks(ctx context.Context, req cliproxyexecutor.Request, err error, run func(providers []string, fallbackReq cliproxyexecutor.Request) error) error {
+	chain := m.fallbackChain(req.Model)
+	if len(chain) == 0 || !fallbackEligible(err) {
+		return err
+	}
+	entry := logEntryWithRequestID(ctx)
+	for _, model := range chain {
+		if ctx != nil && ctx.Err() != nil {
+			return err
+		}
+		fallbackReq := fallbackRequest(req, model)
+		providers := util.GetProviderName(fallbackReq.Model)
+		if len(providers) == 0 {
+			entry.Debugf("fallback model %s has no providers, skipping", model)
+			continue
+		}
+		if providers = filterFallbackProviders(ctx, fallbackReq.Model, providers); len(providers) == 0 {
+			entry.Debugf("fallback model %s is not allowed for this request, skipping", model)
+			continue
+		}
+		entry.Infof("model %s unavailable, falling back to %s", req.Model, model)
+		errRun := run(providers, fallbackReq)
+		if errRun == nil {
+			reportServedModel(ctx, model)
+			return nil
+		}
+		if !fallbackEligible(errRun) {
+			return errRun
+		}
+	}
+	return err
+}
+
+// thinkingMetadataKeys lists the metadata written by thinking-suffix normalization, which
+// describes the requested model and must not leak into a fallback request.
+var thinkingMetadataKeys = []string{
+	util.ThinkingOriginalModelMetadataKey,
+	util.ThinkingBudgetMetadataKey,
+	util.ThinkingIncludeThoughtsMetadataKey,
+	util.ReasoningEffortMetadataKey,
+	util.GeminiOriginalModelMetadataKey,
+	util.GeminiThinkingBudgetMetadataKey,
+	util.GeminiIncludeThoughtsMetadataKey,
+}
+
+// fallbackRequest returns req retargeted at model. The thinking metadata is rebuilt for
+// the fallback: a suffix on the fallback model wins, otherwise the client's suffix is
+// carried over, so executors never resolve the upstream model to the failed one.
+func fallbackRequest(req cliproxyexecutor.Request, model string) cliproxyexecutor.Request {
+	target := model
+	if _, own := util.NormalizeThinkingModel(model); own == nil {
+		if original, ok := req.Metadata[util.ThinkingOriginalModelMetadataKey].(string); ok {
+			if suffix := strings.TrimPrefix(original, req.Model); suffix != original {
+				target = model + suffix
+			}
+		}
+	}
+	base, thinking := util.NormalizeThinkingModel(target)
+
+	metadata := make(map[string]any, len(req.Metadata)+len(thinking))
+	for k, v := range req.Metadata {
+		metadata[k] = v
+	}
+	for _, key := range thinkingMetadataKeys {
+		delete(metadata, key)
+	}
+	for k, v := range thinking {
+		metadata[k] = v
+	}
+
+	fallbackReq := req
+	fallbackReq.Model = base
+	fallbackReq.Metadata = metadata
+	return fallbackReq
+}
+
+// fallbackEligible reports whether err indicates the model is unavailable rather than the
+// request being invalid, so that trying another model can succeed.
+func fallbackEligible(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	status := statusCodeFromError(err)
+	switch {
+	case status == 0:
+		return true
+	case status == http.StatusUnauthorized, status == http.StatusPaymentRequired, status == http.StatusForbidden,
+		status == http.StatusNotFound, status == http.StatusRequestTimeout, status == http.StatusTooManyRequests:
+		return true
+	default:
+		return status >= http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+type failingExecutor struct {
+	recordingExecutor
+	status int
+}
+
+func (e *failingExecutor) Execute(_ context.Context, _ *Auth, req cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	e.mu.Lock()
+	e.payloads = append(e.payloads, req.Model)
+	e.mu.Unlock()
+	return cliproxyexecutor.Response{}, &Error{Code: "upstream", Message: "failed", HTTPStatus: e.status}
+}
+
+func registerFallbackAuth(t *testing.T, manager *Manager, provider, model string) {
+	t.Helper()
+	auth := &Auth{ID: provider + "-auth", Provider: provider}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, provider, []*registry.ModelInfo{{ID: model}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+}
+
+func TestManagerExecute_WalksModelFallbackChain(t *testing.T) {
+	manager := NewManager(nil, nil, nil)
+	primary := &failingExecutor{recordingExecutor: recordingExecutor{provider: "fallback-primary"}, status: http.StatusTooManyRequests}
+	secondary := &recordingExecutor{provider: "fallback-secondary"}
+	manager.RegisterExecutor(primary)
+	manager.RegisterExecutor(secondary)
+	registerFallbackAuth(t, manager, "fallback-primary", "fallback-model-a")
+	registerFallbackAuth(t, manager, "fallback-secondary", "fallback-model-b")
+	manager.SetModelFallbacks(map[string][]string{"fallback-model-a": {"fallback-missing", "fallback-model-b"}})
+
+	var served string
+	ctx := WithServedModelCallback(context.Background(), func(model string) { served = model })
+	req := cliproxyexecutor.Request{Model: "fallback-model-a", Payload: []byte("payload")}
+	resp, err := manager.Execute(ctx, []string{"fallback-primary"}, req, cliproxyexecutor.Options{})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if string(resp.Payload) != "ok" {
+		t.Fatalf("Execute() payload = %q, want %q", resp.Payload, "ok")
+	}
+	if served != "fallback-model-b" {
+		t.Fatalf("served model = %q, want %q", served, "fallback-model-b")
+	}
+	if len(primary.payloads) != 1 || len(secondary.payloads) != 1 {
+		t.Fatalf("executor calls primary=%d secondary=%d, want 1 and 1", len(primary.payloads), len(secondary.payloads))
+	}
+}
+
+func TestManagerExecute_SkipsFallbackForInvalidRequest(t *testing.T) {
+	manager := NewManager(nil, nil, nil)
+	primary := &failingExecutor{recordingExecutor: recordingExecutor{provider: "fallback-invalid"}, status: http.StatusBadRequest}
+	secondary := &recordingExecutor{provider: "fallback-invalid-secondary"}
+	manager.RegisterExecutor(primary)
+	manager.RegisterExecutor(secondary)
+	registerFallbackAuth(t, manager, "fallback-invalid", "fallback-invalid-a")
+	registerFallbackAuth(t, manager, "fallback-invalid-secondary", "fallback-invalid-b")
+	manager.SetModelFallbacks(map[string][]string{"fallback-invalid-a": {"fallback-invalid-b"}})
+
+	req := cliproxyexecutor.Request{Model: "fallback-invalid-a"}
+	if _, err := manager.Execute(context.Background(), []string{"fallback-invalid"}, req, cliproxyexecutor.Options{}); err == nil {
+		t.Fatal("Execute() error = nil, want upstream error")
+	}
+	if len(secondary.payloads) != 0 {
+		t.Fatalf("fallback executor calls = %d, want 0", len(secondary.payloads))
+	}
+}
+
+func TestFallbackRequest_RebuildsThinkingMetadata(t *testing.T) {
+	base, metadata := util.NormalizeThinkingModel("primary(high)")
+	metadata["idempotency_key"] = "abc"
+	req := cliproxyexecutor.Request{Model: base, Metadata: metadata}
+
+	got := fallbackRequest(req, "secondary")
+	if got.Model != "secondary" || util.ResolveOriginalModel(got.Model, got.Metadata) != "secondary" {
+		t.Fatalf("fallback upstream model = %q/%q, want secondary", got.Model, util.ResolveOriginalModel(got.Model, got.Metadata))
+	}
+	if got.Metadata[util.ReasoningEffortMetadataKey] != "high" || got.Metadata["idempotency_key"] != "abc" {
+		t.Fatalf("fallback metadata = %v, want client suffix and other keys kept", got.Metadata)
+	}
+	if req.Metadata[util.ThinkingOriginalModelMetadataKey] != "primary(high)" {
+		t.Fatalf("original metadata mutated: %v", req.Metadata)
+	}
+
+	got = fallbackRequest(req, "secondary(1024)")
+	if got.Model != "secondary" || got.Metadata[util.ThinkingBudgetMetadataKey] != 1024 {
+		t.Fatalf("fallback with own suffix = %q, %v; want secondary with budget 1024", got.Model, got.Metadata)
+	}
+	if _, ok := got.Metadata[util.ReasoningEffortMetadataKey]; ok {
+		t.Fatalf("client effort leaked into fallback with its own suffix: %v", got.Metadata)
+	}
+
+	got = fallbackRequest(cliproxyexecutor.Request{Model: "primary"}, "secondary")
+	if _, ok := got.Metadata[util.ThinkingOriginalModelMetadataKey]; ok || got.Model != "secondary" {
+		t.Fatalf("unsuffixed fallback = %q, %v", got.Model, got.Metadata)
+	}
+}
@@ -0,0 +1,233 @@
+package auth
+
+import (
+	"context"
+	"path"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+// hedgeSettings holds the hedged request configuration.
+type hedgeSettings struct {
+	delay  time.Duration
+	models []string
+}
+
+// SetHedging enables hedged requests: when an attempt has produced no output after delay,
+// a second attempt is started on another credential of the same provider and the first
+// to respond wins. The losing attempt is cancelled and recorded neither through
+// MarkResult nor in usage statistics. A non-positive delay disables hedging; a non-empty
+// models list limits hedging to matching model names ("*" wildcards).
+func (m *Manager) SetHedging(delay time.Duration, models []string) {
+	if m == nil {
+		return
+	}
+	settings := hedgeSettings{}
+	if delay > 0 {
+		settings.delay = delay
+		for _, model := range models {
+			if model = strings.ToLower(strings.TrimSpace(model)); model != "" {
+				settings.models = append(settings.models, model)
+			}
+		}
+	}
+	m.mu.Lock()
+	m.hedging = settings
+	m.mu.Unlock()
+}
+
+// hedgeDelayFor returns the hedging delay for model, or zero when it is not hedged.
+func (m *Manager) hedgeDelayFor(model string) time.Duration {
+	m.mu.RLock()
+	settings := m.hedging
+	m.mu.RUnlock()
+	if settings.delay <= 0 || len(settings.models) == 0 {
+		return settings.delay
+	}
+	model = strings.ToLower(strings.TrimSpace(model))
+	for _, pattern := range settings.models {
+		if matched, _ := path.Match(pattern, model); matched {
+			return settings.delay
+		}
+	}
+	return 0
+}
+
+// hedgeAttempt is one of the racing attempts of a hedged request.
+type hedgeAttempt struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	lost   atomic.Bool
+}
+
+func newHedgeAttempt(parent context.Context) *hedgeAttempt {
+	attempt := &hedgeAttempt{}
+	ctx, cancel := context.WithCancel(parent)
+	attempt.ctx = usage.WithSuppression(ctx, attempt.lost.Load)
+	attempt.cancel = cancel
+	return attempt
+}
+
+// abandon cancels a losing attempt and suppresses its usage records.
+func (a *hedgeAttempt) abandon() {
+	a.lost.Store(true)
+	a.cancel()
+}
+
+// executeHedged runs req on primary and, when no response arrived within delay, races a
+// second credential picked by the selector. Failed attempts are recorded as usual; the
+// error of the last failure is returned when no attempt succeeds.
+func (m *Manager) executeHedged(ctx context.Context, provider string, primary *Auth, executor ProviderExecutor, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, tried map[string]struct{}, lastErr error, delay time.Duration) (cliproxyexecutor.Response, error) {
+	type outcome struct {
+		attempt *hedgeAttempt
+		execCtx context.Context
+		resp    cliproxyexecutor.Response
+		result  Result
+		err     error
+	}
+	outcomes := make(chan outcome, 2)
+	launch := func(auth *Auth, exec ProviderExecutor) *hedgeAttempt {
+		attempt := newHedgeAttempt(ctx)
+		go func() {
+			execCtx, resp, result, err := m.runExecuteAttempt(attempt.ctx, provider, auth, exec, req, opts, lastErr)
+			outcomes <- outcome{attempt: attempt, execCtx: execCtx, resp: resp, result: result, err: err}
+		}()
+		return attempt
+	}
+
+	attempts := []*hedgeAttempt{launch(primary, executor)}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	timerC := timer.C
+	pending := 1
+	var errLast error
+	for pending > 0 {
+		select {
+		case <-timerC:
+			timerC = nil
+			auth, hedgeExecutor, errPick := m.pickNext(ctx, provider, req.Model, opts, tried)
+			if errPick != nil {
+				continue
+			}
+			tried[auth.ID] = struct{}{}
+			logEntryWithRequestID(ctx).Debugf("hedging %s on %s after %s without a response", req.Model, auth.ID, delay)
+			attempts = append(attempts, launch(auth, hedgeExecutor))
+			pending++
+		case out := <-outcomes:
+			pending--
+			if out.err != nil {
+				out.attempt.cancel()
+				m.MarkResult(out.execCtx, out.result)
+				errLast = out.err
+				continue
+			}
+			for _, other := range attempts {
+				if other != out.attempt {
+					other.abandon()
+				}
+			}
+			m.MarkResult(out.execCtx, out.result)
+			out.attempt.cancel()
+			return out.resp, nil
+		}
+	}
+	return cliproxyexecutor.Response{}, errLast
+}
+
+// executeStreamHedged opens a stream on primary and, when no chunk arrived within delay,
+// races a second credential picked by the selector. The first attempt to produce a chunk
+// is streamed to the caller; the other is cancelled, drained and not recorded.
+func (m *Manager) executeStreamHedged(ctx context.Context, provider string, primary *Auth, executor ProviderExecutor, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, tried map[string]struct{}, lastErr error, delay time.Duration) (<-chan cliproxyexecutor.StreamChunk, error) {
+	type outcome struct {
+		hedge   *hedgeAttempt
+		attempt *streamAttempt
+		first   *cliproxyexecutor.StreamChunk
+		result  Result
+		err     error
+	}
+	outcomes := make(chan outcome, 2)
+	launch := func(auth *Auth, exec ProviderExecutor) *hedgeAttempt {
+		hedge := newHedgeAttempt(ctx)
+		go func() {
+			attempt, result, err := m.startStreamAttempt(hedge.ctx, provider, auth, exec, req, opts, lastErr)
+			if err != nil {
+				outcomes <- outcome{hedge: hedge, attempt: attempt, result: result, err: err}
+				return
+			}
+			first, ok := <-attempt.chunks
+			if !ok {
+				outcomes <- outcome{hedge: hedge, attempt: attempt}
+				return
+			}
+			if first.Err != nil {
+				endAttemptSpan(attempt.span, first.Err)
+				if attempt.hook != nil {
+					attempt.hook.AfterExecute(attempt.ctx, attempt.auth, cliproxyexecutor.Response{}, first.Err)
+				}
+				go drainStream(attempt.chunks)
+				outcomes <- outcome{hedge: hedge, attempt: attempt, result: attempt.failure(first.Err), err: first.Err}
+				return
+			}
+			outcomes <- outcome{hedge: hedge, attempt: attempt, first: &first}
+		}()
+		return hedge
+	}
+
+	hedges := []*hedgeAttempt{launch(primary, executor)}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	timerC := timer.C
+	pending := 1
+	var errLast error
+	for pending > 0 {
+		select {
+		case <-timerC:
+			timerC = nil
+			auth, hedgeExecutor, errPick := m.pickNext(ctx, provider, req.Model, opts, tried)
+			if errPick != nil {
+				continue
+			}
+			tried[auth.ID] = struct{}{}
+			logEntryWithRequestID(ctx).Debugf("hedging stream %s on %s after %s without output", req.Model, auth.ID, delay)
+			hedges = append(hedges, launch(auth, hedgeExecutor))
+			pending++
+		case out := <-outcomes:
+			pending--
+			if out.err != nil {
+				out.hedge.cancel()
+				m.MarkResult(out.attempt.ctx, out.result)
+				errLast = out.err
+				continue
+			}
+			for _, other := range hedges {
+				if other != out.hedge {
+					other.abandon()
+				}
+			}
+			go func(remaining int) {
+				for ; remaining > 0; remaining-- {
+					loser := <-outcomes
+					if loser.err != nil || loser.attempt.chunks == nil {
+						continue
+					}
+					drainStream(loser.attempt.chunks)
+					endAttemptSpan(loser.attempt.span, context.Canceled)
+					if loser.attempt.hook != nil {
+						loser.attempt.hook.AfterExecute(loser.attempt.ctx, loser.attempt.auth, cliproxyexecutor.Response{}, context.Canceled)
+					}
+				}
+			}(pending)
+			return m.forwardStream(out.attempt, out.first, out.hedge.cancel), nil
+		}
+	}
+	return nil, errLast
+}
+
+func drainStream(chunks <-chan cliproxyexecutor.StreamChunk) {
+	for range chunks {
+	}
+}
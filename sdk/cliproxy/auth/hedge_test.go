@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// stallFirstExecutor blocks its first call until cancelled and answers later calls at once.
+type stallFirstExecutor struct {
+	recordingExecutor
+	calls     int
+	cancelled chan struct{}
+}
+
+func (e *stallFirstExecutor) stall(ctx context.Context) bool {
+	e.mu.Lock()
+	e.calls++
+	first := e.calls == 1
+	e.mu.Unlock()
+	if first {
+		<-ctx.Done()
+		close(e.cancelled)
+	}
+	return first
+}
+
+func (e *stallFirstExecutor) Execute(ctx context.Context, auth *Auth, _ cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	if e.stall(ctx) {
+		return cliproxyexecutor.Response{}, ctx.Err()
+	}
+	return cliproxyexecutor.Response{Payload: []byte(auth.ID)}, nil
+}
+
+func (e *stallFirstExecutor) ExecuteStream(ctx context.Context, auth *Auth, _ cliproxyexecutor.Request, _ cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	out := make(chan cliproxyexecutor.StreamChunk, 1)
+	go func() {
+		defer close(out)
+		if e.stall(ctx) {
+			return
+		}
+		out <- cliproxyexecutor.StreamChunk{Payload: []byte(auth.ID)}
+	}()
+	return out, nil
+}
+
+type resultHook struct {
+	NoopHook
+	mu      sync.Mutex
+	results []Result
+}
+
+func (h *resultHook) OnResult(_ context.Context, result Result) {
+	h.mu.Lock()
+	h.results = append(h.results, result)
+	h.mu.Unlock()
+}
+
+func (h *resultHook) snapshot() []Result {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]Result(nil), h.results...)
+}
+
+func newHedgedManager(t *testing.T, provider string) (*Manager, *stallFirstExecutor, *resultHook) {
+	t.Helper()
+	hook := &resultHook{}
+	manager := NewManager(nil, nil, hook)
+	executor := &stallFirstExecutor{recordingExecutor: recordingExecutor{provider: provider}, cancelled: make(chan struct{})}
+	manager.RegisterExecutor(executor)
+	for _, id := range []string{provider + "-a", provider + "-b"} {
+		if _, err := manager.Register(context.Background(), &Auth{ID: id, Provider: provider}); err != nil {
+			t.Fatalf("Register() error = %v", err)
+		}
+		registry.GetGlobalRegistry().RegisterClient(id, provider, []*registry.ModelInfo{{ID: "hedge-model"}})
+		authID := id
+		t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(authID) })
+	}
+	manager.SetHedging(10*time.Millisecond, []string{"hedge-*"})
+	return manager, executor, hook
+}
+
+func TestManagerExecute_HedgesSlowCredential(t *testing.T) {
+	manager, executor, hook := newHedgedManager(t, "hedge-exec")
+	req := cliproxyexecutor.Request{Model: "hedge-model"}
+
+	resp, err := manager.Execute(context.Background(), []string{"hedge-exec"}, req, cliproxyexecutor.Options{})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	select {
+	case <-executor.cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("losing attempt was not cancelled")
+	}
+	results := hook.snapshot()
+	if len(results) != 1 || !results[0].Success || results[0].AuthID != string(resp.Payload) {
+		t.Fatalf("recorded results = %+v, want only the winner %s", results, resp.Payload)
+	}
+}
+
+func TestManagerExecuteStream_HedgesSlowCredential(t *testing.T) {
+	manager, executor, hook := newHedgedManager(t, "hedge-stream")
+	req := cliproxyexecutor.Request{Model: "hedge-model"}
+
+	chunks, err := manager.ExecuteStream(context.Background(), []string{"hedge-stream"}, req, cliproxyexecutor.Options{})
+	if err != nil {
+		t.Fatalf("ExecuteStream() error = %v", err)
+	}
+	var winner string
+	for chunk := range chunks {
+		winner += string(chunk.Payload)
+	}
+	<-executor.cancelled
+	results := hook.snapshot()
+	if len(results) != 1 || !results[0].Success || results[0].AuthID != winner {
+		t.Fatalf("recorded results = %+v, want only the winner %s", results, winner)
+	}
+}
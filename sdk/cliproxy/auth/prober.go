@@ -0,0 +1,317 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+)
+
+// Probe methods reported in ProbeResult.Method.
+const (
+	ProbeMethodCountTokens = "count_tokens"
+	ProbeMethodExecute     = "execute"
+)
+
+// defaultProbeTimeout bounds a single probe when ProbeSettings.Timeout is not set.
+const defaultProbeTimeout = 30 * time.Second
+
+// probeConcurrency caps how many credentials are probed at the same time.
+const probeConcurrency = 4
+
+// UpstreamTokenCounter is implemented by executors whose CountTokens calls the provider,
+// making it a cheap way to verify a credential. Other executors count locally and are
+// probed with a minimal generation request instead.
+type UpstreamTokenCounter interface {
+	CountsTokensUpstream() bool
+}
+
+// ProbeSettings configures the background credential health prober.
+type ProbeSettings struct {
+	// Interval between probe rounds. Zero disables scheduled probes.
+	Interval time.Duration
+	// Timeout bounds a single probe. Defaults to 30 seconds.
+	Timeout time.Duration
+	// Models maps a provider to the model used to probe its credentials. Providers without
+	// an entry use the first model registered for the credential.
+	Models map[string]string
+}
+
+// ProbeResult records the outcome of the latest synthetic probe of a credential.
+type ProbeResult struct {
+	CheckedAt  time.Time `json:"checked_at"`
+	Model      string    `json:"model,omitempty"`
+	Method     string    `json:"method,omitempty"`
+	Success    bool      `json:"success"`
+	LatencyMS  int64     `json:"latency_ms"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// healthProber holds the prober configuration, loop state and latest results.
+type healthProber struct {
+	mu       sync.Mutex
+	settings ProbeSettings
+	parent   context.Context
+	cancel   context.CancelFunc
+	results  map[string]ProbeResult
+}
+
+func newHealthProber() *healthProber {
+	return &healthProber{results: make(map[string]ProbeResult)}
+}
+
+// SetHealthProbes configures scheduled credential probes. A running probe loop is
+// restarted with the new settings.
+func (m *Manager) SetHealthProbes(settings ProbeSettings) {
+	if m == nil {
+		return
+	}
+	if settings.Interval < 0 {
+		settings.Interval = 0
+	}
+	models := make(map[string]string, len(settings.Models))
+	for provider, model := range settings.Models {
+		provider = strings.ToLower(strings.TrimSpace(provider))
+		if model = strings.TrimSpace(model); provider != "" && model != "" {
+			models[provider] = model
+		}
+	}
+	settings.Models = models
+	p := m.prober
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.settings = settings
+	if p.parent != nil {
+		m.restartProbeLoopLocked()
+	}
+}
+
+// StartHealthProbes launches the background probe loop. The loop only probes while an
+// interval is configured through SetHealthProbes; starting again replaces the previous run.
+func (m *Manager) StartHealthProbes(parent context.Context) {
+	if m == nil {
+		return
+	}
+	p := m.prober
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.parent = parent
+	m.restartProbeLoopLocked()
+}
+
+// StopHealthProbes cancels the background probe loop, if running.
+func (m *Manager) StopHealthProbes() {
+	if m == nil {
+		return
+	}
+	p := m.prober
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cancel != nil {
+		p.cancel()
+		p.cancel = nil
+	}
+	p.parent = nil
+}
+
+func (m *Manager) restartProbeLoopLocked() {
+	p := m.prober
+	if p.cancel != nil {
+		p.cancel()
+		p.cancel = nil
+	}
+	interval := p.settings.Interval
+	if interval <= 0 {
+		return
+	}
+	ctx, cancel := context.WithCancel(p.parent)
+	p.cancel = cancel
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.probeAll(ctx)
+			}
+		}
+	}()
+}
+
+// LastProbe returns the latest probe result of the auth with the given ID.
+func (m *Manager) LastProbe(id string) (ProbeResult, bool) {
+	if m == nil {
+		return ProbeResult{}, false
+	}
+	p := m.prober
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	result, ok := p.results[id]
+	return result, ok
+}
+
+// probeAll probes every enabled auth that has a registered executor. Auths cooling down
+// after a quota error are skipped: the provider already said when they recover.
+func (m *Manager) probeAll(ctx context.Context) {
+	sem := make(chan struct{}, probeConcurrency)
+	var wg sync.WaitGroup
+	for _, auth := range m.snapshotAuths() {
+		if auth.Disabled || auth.Status == StatusDisabled || m.executorFor(auth.Provider) == nil {
+			continue
+		}
+		if quotaCoolingDown(auth, time.Now()) {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case sem <- struct{}{}:
+		}
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if _, err := m.ProbeAuth(ctx, id); err != nil {
+				log.Debugf("health probe skipped for %s: %v", id, err)
+			}
+		}(auth.ID)
+	}
+	wg.Wait()
+}
+
+// ProbeAuth sends a synthetic request through the executor of the auth with the given ID,
+// records the outcome as its latest probe result and updates its model state: successes
+// clear cooldowns other than quota ones, which a cheap probe cannot prove are over, while
+// authentication, quota and upstream failures mark the model as they would for a real
+// request. The returned error reports why the auth could not be probed; a failed probe is
+// reported through the result.
+func (m *Manager) ProbeAuth(ctx context.Context, id string) (ProbeResult, error) {
+	auth, ok := m.GetByID(id)
+	if !ok {
+		return ProbeResult{}, &Error{Code: "auth_not_found", Message: "auth not found"}
+	}
+	if auth.Disabled || auth.Status == StatusDisabled {
+		return ProbeResult{}, &Error{Code: "auth_disabled", Message: "auth is disabled"}
+	}
+	executor := m.executorFor(auth.Provider)
+	if executor == nil {
+		return ProbeResult{}, &Error{Code: "executor_not_found", Message: "executor not registered"}
+	}
+	p := m.prober
+	p.mu.Lock()
+	settings := p.settings
+	p.mu.Unlock()
+	model := m.probeModelFor(auth, settings)
+	if model == "" {
+		return ProbeResult{}, &Error{Code: "model_not_found", Message: "no model registered for auth"}
+	}
+
+	timeout := settings.Timeout
+	if timeout <= 0 {
+		timeout = defaultProbeTimeout
+	}
+	probeCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	probeCtx = m.withRateLimitReporter(m.withAuthRoundTripper(probeCtx, auth), auth)
+	probeCtx = usage.WithSuppression(probeCtx, func() bool { return true })
+
+	req := cliproxyexecutor.Request{Model: model, Payload: probePayload(model), Format: sdktranslator.FormatOpenAI}
+	opts := cliproxyexecutor.Options{OriginalRequest: req.Payload, SourceFormat: sdktranslator.FormatOpenAI}
+	req.Model, req.Metadata = rewriteModelForAuth(model, req.Metadata, auth)
+
+	result := ProbeResult{CheckedAt: time.Now(), Model: model, Method: ProbeMethodExecute}
+	var err error
+	if counter, okCounter := executor.(UpstreamTokenCounter); okCounter && counter.CountsTokensUpstream() {
+		result.Method = ProbeMethodCountTokens
+		_, err = executor.CountTokens(probeCtx, auth, req, opts)
+	} else {
+		_, err = executor.Execute(probeCtx, auth, req, opts)
+	}
+	result.LatencyMS = time.Since(result.CheckedAt).Milliseconds()
+	result.Success = err == nil
+	if err != nil {
+		result.StatusCode = statusCodeFromError(err)
+		result.Error = err.Error()
+	}
+
+	p.mu.Lock()
+	p.results[auth.ID] = result
+	p.mu.Unlock()
+
+	if ctx.Err() != nil {
+		return result, nil
+	}
+	if err == nil {
+		if current, okCurrent := m.GetByID(auth.ID); okCurrent && quotaCoolingDown(current, time.Now()) {
+			return result, nil
+		}
+		m.MarkResult(ctx, Result{AuthID: auth.ID, Provider: auth.Provider, Model: model, Success: true, Probe: true})
+	} else if probeFailureAffectsState(result.StatusCode) {
+		m.MarkResult(ctx, Result{
+			AuthID:     auth.ID,
+			Provider:   auth.Provider,
+			Model:      model,
+			RetryAfter: retryAfterFromError(err),
+			Error:      &Error{Message: err.Error(), HTTPStatus: result.StatusCode},
+			Probe:      true,
+		})
+	}
+	return result, nil
+}
+
+// probeModelFor picks the model used to probe auth: the configured provider model when it
+// is served by the auth, otherwise the first model registered for it.
+func (m *Manager) probeModelFor(auth *Auth, settings ProbeSettings) string {
+	reg := registry.GetGlobalRegistry()
+	if model := settings.Models[strings.ToLower(strings.TrimSpace(auth.Provider))]; model != "" {
+		if reg.ClientSupportsModel(auth.ID, model) {
+			return model
+		}
+	}
+	for _, info := range reg.GetModelsForClient(auth.ID) {
+		if info != nil && info.ID != "" {
+			return info.ID
+		}
+	}
+	return ""
+}
+
+// quotaCoolingDown reports whether auth, or any of its models, is waiting out a quota error.
+func quotaCoolingDown(auth *Auth, now time.Time) bool {
+	if auth.Quota.Exceeded && auth.Quota.NextRecoverAt.After(now) {
+		return true
+	}
+	for _, state := range auth.ModelStates {
+		if state != nil && state.Quota.Exceeded && state.Quota.NextRecoverAt.After(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// probeFailureAffectsState reports whether a failed probe says something about the
+// credential. Other failures, such as a provider rejecting the synthetic payload or a
+// probe timeout, are only recorded in the probe result.
+func probeFailureAffectsState(statusCode int) bool {
+	switch statusCode {
+	case http.StatusUnauthorized, http.StatusPaymentRequired, http.StatusForbidden, http.StatusTooManyRequests:
+		return true
+	}
+	return statusCode >= http.StatusInternalServerError
+}
+
+func probePayload(model string) []byte {
+	return []byte(fmt.Sprintf(`{"model":%q,"messages":[{"role":"user","content":"ping"}],"max_tokens":1,"stream":false}`, model))
+}
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// revokedExecutor rejects every generation request as unauthorized.
+type revokedExecutor struct {
+	recordingExecutor
+}
+
+func (e *revokedExecutor) Execute(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, &Error{Message: "token revoked", HTTPStatus: http.StatusUnauthorized}
+}
+
+func TestManagerProbeAuth_MarksRevokedCredential(t *testing.T) {
+	manager := NewManager(nil, nil, nil)
+	manager.RegisterExecutor(&revokedExecutor{recordingExecutor{provider: "probe-provider"}})
+	if _, err := manager.Register(context.Background(), &Auth{ID: "probe-auth", Provider: "probe-provider"}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient("probe-auth", "probe-provider", []*registry.ModelInfo{{ID: "probe-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient("probe-auth") })
+
+	result, err := manager.ProbeAuth(context.Background(), "probe-auth")
+	if err != nil {
+		t.Fatalf("ProbeAuth() error = %v", err)
+	}
+	if result.Success || result.StatusCode != http.StatusUnauthorized || result.Model != "probe-model" || result.Method != ProbeMethodExecute {
+		t.Fatalf("ProbeAuth() = %+v, want failed execute probe of probe-model with 401", result)
+	}
+	if last, ok := manager.LastProbe("probe-auth"); !ok || last != result {
+		t.Fatalf("LastProbe() = %+v, %v; want %+v", last, ok, result)
+	}
+	auth, _ := manager.GetByID("probe-auth")
+	if blocked, _, _ := isAuthBlockedForModel(auth, "probe-model", time.Now()); !blocked {
+		t.Fatal("revoked credential is still selectable after a failed probe")
+	}
+
+	if _, err = manager.ProbeAuth(context.Background(), "missing-auth"); err == nil {
+		t.Fatal("ProbeAuth() for unknown auth error = nil")
+	}
+}
+
+func TestManagerProbeAuth_KeepsQuotaCooldown(t *testing.T) {
+	executor := &recordingExecutor{provider: "probe-quota-provider"}
+	manager := NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	if _, err := manager.Register(context.Background(), &Auth{ID: "probe-quota-auth", Provider: "probe-quota-provider"}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient("probe-quota-auth", "probe-quota-provider", []*registry.ModelInfo{{ID: "probe-quota-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient("probe-quota-auth") })
+
+	retryAfter := 10 * time.Minute
+	manager.MarkResult(context.Background(), Result{
+		AuthID:     "probe-quota-auth",
+		Provider:   "probe-quota-provider",
+		Model:      "probe-quota-model",
+		RetryAfter: &retryAfter,
+		Error:      &Error{Message: "quota exceeded", HTTPStatus: http.StatusTooManyRequests},
+	})
+	auth, _ := manager.GetByID("probe-quota-auth")
+	cooldownUntil := auth.ModelStates["probe-quota-model"].NextRetryAfter
+
+	manager.probeAll(context.Background())
+	if len(executor.payloads) != 0 {
+		t.Fatalf("scheduled probe ran %d requests, want the cooling auth skipped", len(executor.payloads))
+	}
+
+	result, err := manager.ProbeAuth(context.Background(), "probe-quota-auth")
+	if err != nil || !result.Success {
+		t.Fatalf("ProbeAuth() = %+v, %v; want a successful probe", result, err)
+	}
+	auth, _ = manager.GetByID("probe-quota-auth")
+	state := auth.ModelStates["probe-quota-model"]
+	if !state.Quota.Exceeded || !state.NextRetryAfter.Equal(cooldownUntil) {
+		t.Fatalf("model state after probe = %+v, want the quota cooldown kept", state)
+	}
+	if blocked, _, _ := isAuthBlockedForModel(auth, "probe-quota-model", time.Now()); !blocked {
+		t.Fatal("successful probe made a quota-limited credential selectable")
+	}
+}
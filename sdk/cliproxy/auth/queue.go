@@ -0,0 +1,349 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// Priority classes of queued requests, served in this order.
+const (
+	PriorityHigh   = "high"
+	PriorityNormal = "normal"
+	PriorityLow    = "low"
+)
+
+var priorityRank = map[string]int{PriorityHigh: 0, PriorityNormal: 1, PriorityLow: 2}
+
+// queueRecheckInterval paces releases while credentials are available but more requests
+// are waiting than were released in the last round.
+const queueRecheckInterval = 250 * time.Millisecond
+
+// QueueSettings configures the cooldown wait queue.
+type QueueSettings struct {
+	// MaxDepth caps the number of waiting requests. Zero disables the queue.
+	MaxDepth int
+	// MaxPerKey caps the waiting requests of a single client key. Zero means no cap.
+	MaxPerKey int
+	// MaxWait is how long a request may wait before it fails with the cooldown error.
+	MaxWait time.Duration
+	// Priorities maps client keys to a priority class; other keys are PriorityNormal.
+	Priorities map[string]string
+}
+
+// QueueStats reports the current state of the cooldown wait queue.
+type QueueStats struct {
+	Enabled    bool           `json:"enabled"`
+	Depth      int            `json:"depth"`
+	MaxDepth   int            `json:"max_depth"`
+	MaxPerKey  int            `json:"max_per_key"`
+	Models     map[string]int `json:"models"`
+	Priorities map[string]int `json:"priorities"`
+	// Keys counts waiting requests per client key, with keys masked.
+	Keys map[string]int `json:"keys"`
+}
+
+// cooldownQueue holds requests whose credentials are all cooling down and releases them
+// fairly once credentials recover: higher priority classes first, then round-robin across
+// client keys, then in arrival order.
+type cooldownQueue struct {
+	mu       sync.Mutex
+	settings QueueSettings
+	seq      uint64
+	lines    map[string]*queueLine
+	depth    int
+	perKey   map[string]int
+}
+
+// queueLine groups waiters blocked on the same model and provider set.
+type queueLine struct {
+	model     string
+	providers []string
+	waiters   []*queueWaiter
+	timer     *time.Timer
+}
+
+type queueWaiter struct {
+	key      string
+	priority string
+	seq      uint64
+	release  chan struct{}
+}
+
+// queueTicket keeps a request's place and deadline across repeated waits.
+type queueTicket struct {
+	seq      uint64
+	deadline time.Time
+}
+
+func newCooldownQueue() *cooldownQueue {
+	return &cooldownQueue{lines: make(map[string]*queueLine), perKey: make(map[string]int)}
+}
+
+// SetQueue configures the cooldown wait queue. Requests already waiting keep waiting.
+func (m *Manager) SetQueue(settings QueueSettings) {
+	if m == nil {
+		return
+	}
+	if settings.MaxDepth < 0 {
+		settings.MaxDepth = 0
+	}
+	if settings.MaxPerKey < 0 {
+		settings.MaxPerKey = 0
+	}
+	priorities := make(map[string]string, len(settings.Priorities))
+	for key, class := range settings.Priorities {
+		class = strings.ToLower(strings.TrimSpace(class))
+		if _, ok := priorityRank[class]; ok && key != "" {
+			priorities[key] = class
+		}
+	}
+	settings.Priorities = priorities
+	m.queue.mu.Lock()
+	m.queue.settings = settings
+	m.queue.mu.Unlock()
+}
+
+// QueueStats returns the current depth of the cooldown wait queue.
+func (m *Manager) QueueStats() QueueStats {
+	q := m.queue
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	stats := QueueStats{
+		Enabled:    q.settings.MaxDepth > 0,
+		Depth:      q.depth,
+		MaxDepth:   q.settings.MaxDepth,
+		MaxPerKey:  q.settings.MaxPerKey,
+		Models:     make(map[string]int),
+		Priorities: make(map[string]int),
+		Keys:       make(map[string]int),
+	}
+	for _, line := range q.lines {
+		for _, w := range line.waiters {
+			stats.Models[line.model]++
+			stats.Priorities[w.priority]++
+			key := "anonymous"
+			if w.key != "" {
+				key = util.HideAPIKey(w.key)
+			}
+			stats.Keys[key]++
+		}
+	}
+	return stats
+}
+
+// awaitCooldown parks a request that failed with err until a credential for model becomes
+// available. It returns nil when the request should be retried, or an error when the
+// request cannot be queued, was cancelled or waited past its deadline.
+func (m *Manager) awaitCooldown(ctx context.Context, ticket *queueTicket, providers []string, model string, opts cliproxyexecutor.Options, err error) error {
+	if statusCodeFromError(err) != http.StatusTooManyRequests {
+		return err
+	}
+	wait, cooling := m.closestCooldownWait(providers, model)
+	if !cooling {
+		return err
+	}
+	q := m.queue
+	key := clientKeyFromOptions(opts)
+	now := time.Now()
+
+	q.mu.Lock()
+	settings := q.settings
+	if settings.MaxDepth <= 0 || q.depth >= settings.MaxDepth || (settings.MaxPerKey > 0 && q.perKey[key] >= settings.MaxPerKey) {
+		q.mu.Unlock()
+		return err
+	}
+	if ticket.seq == 0 {
+		q.seq++
+		ticket.seq = q.seq
+		if settings.MaxWait > 0 {
+			ticket.deadline = now.Add(settings.MaxWait)
+		}
+	}
+	if !ticket.deadline.IsZero() && !now.Before(ticket.deadline) {
+		q.mu.Unlock()
+		return err
+	}
+	priority := settings.Priorities[key]
+	if priority == "" {
+		priority = PriorityNormal
+	}
+	waiter := &queueWaiter{key: key, priority: priority, seq: ticket.seq, release: make(chan struct{})}
+	lineKey := model + "|" + strings.Join(providers, ",")
+	line := q.lines[lineKey]
+	if line == nil {
+		line = &queueLine{model: model, providers: append([]string(nil), providers...)}
+		q.lines[lineKey] = line
+	}
+	line.waiters = append(line.waiters, waiter)
+	q.depth++
+	q.perKey[key]++
+	if line.timer == nil {
+		line.timer = time.AfterFunc(wait, func() { m.releaseQueued(lineKey) })
+	}
+	q.mu.Unlock()
+
+	logEntryWithRequestID(ctx).Debugf("queued request for %s (%s priority) until a credential recovers in %s", model, priority, wait)
+	var deadline <-chan time.Time
+	if !ticket.deadline.IsZero() {
+		timer := time.NewTimer(time.Until(ticket.deadline))
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	select {
+	case <-waiter.release:
+		return nil
+	case <-deadline:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if q.remove(lineKey, waiter) {
+		return err
+	}
+	// Released concurrently with the deadline or cancellation.
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return nil
+}
+
+// releaseQueued wakes waiters of a line in fair order, as many as there are available
+// credentials, and schedules the next release for the rest.
+func (m *Manager) releaseQueued(lineKey string) {
+	q := m.queue
+	q.mu.Lock()
+	line := q.lines[lineKey]
+	if line == nil {
+		q.mu.Unlock()
+		return
+	}
+	line.timer = nil
+	q.mu.Unlock()
+
+	budget := m.availableAuthCount(line.providers, line.model)
+	var next time.Duration
+	if budget == 0 {
+		wait, cooling := m.closestCooldownWait(line.providers, line.model)
+		if cooling {
+			next = wait
+		} else {
+			// Nothing will recover by itself; let every waiter fail fast.
+			budget = -1
+		}
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if line != q.lines[lineKey] {
+		return
+	}
+	sortFairly(line.waiters)
+	released := 0
+	for len(line.waiters) > 0 && (budget < 0 || released < budget) {
+		w := line.waiters[0]
+		line.waiters = line.waiters[1:]
+		q.forgetLocked(w)
+		close(w.release)
+		released++
+	}
+	if len(line.waiters) == 0 {
+		delete(q.lines, lineKey)
+		return
+	}
+	if next <= 0 {
+		next = queueRecheckInterval
+	}
+	line.timer = time.AfterFunc(next, func() { m.releaseQueued(lineKey) })
+}
+
+// remove drops waiter from its line, reporting whether it was still waiting.
+func (q *cooldownQueue) remove(lineKey string, waiter *queueWaiter) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	line := q.lines[lineKey]
+	if line == nil {
+		return false
+	}
+	for i, w := range line.waiters {
+		if w != waiter {
+			continue
+		}
+		line.waiters = append(line.waiters[:i], line.waiters[i+1:]...)
+		q.forgetLocked(w)
+		if len(line.waiters) == 0 {
+			if line.timer != nil {
+				line.timer.Stop()
+			}
+			delete(q.lines, lineKey)
+		}
+		return true
+	}
+	return false
+}
+
+func (q *cooldownQueue) forgetLocked(w *queueWaiter) {
+	q.depth--
+	if q.perKey[w.key]--; q.perKey[w.key] <= 0 {
+		delete(q.perKey, w.key)
+	}
+}
+
+// sortFairly orders waiters by priority class, then round-robin across client keys, then
+// by arrival.
+func sortFairly(waiters []*queueWaiter) {
+	sort.SliceStable(waiters, func(i, j int) bool { return waiters[i].seq < waiters[j].seq })
+	turn := make(map[*queueWaiter]int, len(waiters))
+	seen := make(map[string]int)
+	for _, w := range waiters {
+		id := w.priority + "|" + w.key
+		turn[w] = seen[id]
+		seen[id]++
+	}
+	sort.SliceStable(waiters, func(i, j int) bool {
+		a, b := waiters[i], waiters[j]
+		if priorityRank[a.priority] != priorityRank[b.priority] {
+			return priorityRank[a.priority] < priorityRank[b.priority]
+		}
+		if turn[a] != turn[b] {
+			return turn[a] < turn[b]
+		}
+		return a.seq < b.seq
+	})
+}
+
+// availableAuthCount counts credentials of providers that can serve model right now.
+func (m *Manager) availableAuthCount(providers []string, model string) int {
+	providerSet := make(map[string]struct{}, len(providers))
+	for _, provider := range providers {
+		providerSet[strings.ToLower(strings.TrimSpace(provider))] = struct{}{}
+	}
+	now := time.Now()
+	registryRef := registry.GetGlobalRegistry()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	count := 0
+	for _, auth := range m.auths {
+		if _, ok := providerSet[strings.ToLower(strings.TrimSpace(auth.Provider))]; !ok {
+			continue
+		}
+		if registryRef != nil && !registryRef.ClientSupportsModel(auth.ID, model) {
+			continue
+		}
+		if blocked, _, _ := isAuthBlockedForModel(auth, model, now); !blocked {
+			count++
+		}
+	}
+	return count
+}
+
+func clientKeyFromOptions(opts cliproxyexecutor.Options) string {
+	key, _ := opts.Metadata[cliproxyexecutor.ClientAPIKeyMetadataKey].(string)
+	return key
+}
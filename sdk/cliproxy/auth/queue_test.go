@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+func TestManagerExecute_QueuesUntilCooldownEnds(t *testing.T) {
+	manager := NewManager(nil, nil, nil)
+	executor := &recordingExecutor{provider: "queue-provider"}
+	manager.RegisterExecutor(executor)
+	auth := &Auth{ID: "queue-auth", Provider: "queue-provider", ModelStates: map[string]*ModelState{
+		"queue-model": {
+			Status:         StatusError,
+			Unavailable:    true,
+			NextRetryAfter: time.Now().Add(50 * time.Millisecond),
+			Quota:          QuotaState{Exceeded: true},
+		},
+	}}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, "queue-provider", []*registry.ModelInfo{{ID: "queue-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+	req := cliproxyexecutor.Request{Model: "queue-model"}
+
+	if _, err := manager.Execute(context.Background(), []string{"queue-provider"}, req, cliproxyexecutor.Options{}); err == nil {
+		t.Fatal("Execute() without queue error = nil, want cooldown error")
+	}
+
+	manager.SetQueue(QueueSettings{MaxDepth: 1, MaxWait: time.Second})
+	if _, err := manager.Execute(context.Background(), []string{"queue-provider"}, req, cliproxyexecutor.Options{}); err != nil {
+		t.Fatalf("Execute() with queue error = %v", err)
+	}
+	if stats := manager.QueueStats(); !stats.Enabled || stats.Depth != 0 {
+		t.Fatalf("QueueStats() = %+v, want enabled and empty", stats)
+	}
+}
+
+func TestSortFairly_PriorityThenRoundRobinAcrossKeys(t *testing.T) {
+	waiters := []*queueWaiter{
+		{key: "a", priority: PriorityNormal, seq: 1},
+		{key: "a", priority: PriorityNormal, seq: 2},
+		{key: "b", priority: PriorityNormal, seq: 3},
+		{key: "c", priority: PriorityLow, seq: 4},
+		{key: "d", priority: PriorityHigh, seq: 5},
+	}
+	sortFairly(waiters)
+	want := []uint64{5, 1, 3, 2, 4}
+	for i, w := range waiters {
+		if w.seq != want[i] {
+			t.Fatalf("position %d = seq %d, want %d", i, w.seq, want[i])
+		}
+	}
+}
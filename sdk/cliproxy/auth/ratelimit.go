@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+// rateLimitLowWatermark is the fraction of a window below which a credential counts as
+// nearly exhausted.
+const rateLimitLowWatermark = 0.05
+
+// rateLimitStaleAfter bounds how long a window without a reset time is trusted.
+const rateLimitStaleAfter = time.Minute
+
+type rateLimitReporterKey struct{}
+
+// ReportRateLimits records snapshot as the latest provider-reported rate limits of the
+// credential executing in ctx. Executors call it for every upstream response carrying
+// rate limit headers; it is a no-op outside a Manager execution.
+func ReportRateLimits(ctx context.Context, snapshot RateLimitSnapshot) {
+	if ctx == nil {
+		return
+	}
+	if report, ok := ctx.Value(rateLimitReporterKey{}).(func(RateLimitSnapshot)); ok && report != nil {
+		report(snapshot)
+	}
+}
+
+// withRateLimitReporter lets executors running in ctx report rate limits for auth.
+func (m *Manager) withRateLimitReporter(ctx context.Context, auth *Auth) context.Context {
+	if auth == nil || auth.ID == "" {
+		return ctx
+	}
+	id := auth.ID
+	return context.WithValue(ctx, rateLimitReporterKey{}, func(snapshot RateLimitSnapshot) {
+		m.recordRateLimits(id, snapshot)
+	})
+}
+
+func (m *Manager) recordRateLimits(id string, snapshot RateLimitSnapshot) {
+	if snapshot.UpdatedAt.IsZero() {
+		snapshot.UpdatedAt = time.Now()
+	}
+	m.mu.Lock()
+	if auth, ok := m.auths[id]; ok && auth != nil {
+		auth.RateLimits = &snapshot
+	}
+	m.mu.Unlock()
+}
+
+// NearlyExhausted reports whether the provider said the credential is out of, or close to
+// running out of, any of its limits at now.
+func (s *RateLimitSnapshot) NearlyExhausted(now time.Time) bool {
+	if s == nil {
+		return false
+	}
+	if now.Before(s.RetryAfter) {
+		return true
+	}
+	for _, window := range []*RateLimitWindow{s.Requests, s.Tokens, s.InputTokens, s.OutputTokens} {
+		if window == nil {
+			continue
+		}
+		if window.ResetAt.IsZero() {
+			if now.Sub(s.UpdatedAt) > rateLimitStaleAfter {
+				continue
+			}
+		} else if !now.Before(window.ResetAt) {
+			continue
+		}
+		if window.Remaining <= 0 {
+			return true
+		}
+		if window.Limit > 0 && float64(window.Remaining) < float64(window.Limit)*rateLimitLowWatermark {
+			return true
+		}
+	}
+	return false
+}
+
+// preferHeadroom drops nearly exhausted credentials from available unless all of them are.
+func preferHeadroom(available []*Auth, now time.Time) []*Auth {
+	preferred := make([]*Auth, 0, len(available))
+	for _, auth := range available {
+		if !auth.RateLimits.NearlyExhausted(now) {
+			preferred = append(preferred, auth)
+		}
+	}
+	if len(preferred) == 0 {
+		return available
+	}
+	return preferred
+}
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// rateLimitedExecutor reports an exhausted request window for one credential.
+type rateLimitedExecutor struct {
+	recordingExecutor
+	exhausted string
+}
+
+func (e *rateLimitedExecutor) Execute(ctx context.Context, auth *Auth, _ cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	if auth.ID == e.exhausted {
+		ReportRateLimits(ctx, RateLimitSnapshot{Requests: &RateLimitWindow{Limit: 50, Remaining: 1, ResetAt: time.Now().Add(time.Minute)}})
+	}
+	return cliproxyexecutor.Response{Payload: []byte(auth.ID)}, nil
+}
+
+func TestManagerExecute_AvoidsNearlyExhaustedCredential(t *testing.T) {
+	manager := NewManager(nil, nil, nil)
+	manager.RegisterExecutor(&rateLimitedExecutor{recordingExecutor: recordingExecutor{provider: "ratelimit-provider"}, exhausted: "ratelimit-a"})
+	for _, id := range []string{"ratelimit-a", "ratelimit-b"} {
+		if _, err := manager.Register(context.Background(), &Auth{ID: id, Provider: "ratelimit-provider"}); err != nil {
+			t.Fatalf("Register() error = %v", err)
+		}
+		registry.GetGlobalRegistry().RegisterClient(id, "ratelimit-provider", []*registry.ModelInfo{{ID: "ratelimit-model"}})
+		authID := id
+		t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(authID) })
+	}
+	req := cliproxyexecutor.Request{Model: "ratelimit-model"}
+
+	// Round-robin starts with ratelimit-a, which reports it is nearly exhausted.
+	if resp, err := manager.Execute(context.Background(), []string{"ratelimit-provider"}, req, cliproxyexecutor.Options{}); err != nil || string(resp.Payload) != "ratelimit-a" {
+		t.Fatalf("first Execute() = %s, %v; want ratelimit-a", resp.Payload, err)
+	}
+	if auth, _ := manager.GetByID("ratelimit-a"); auth.RateLimits == nil || auth.RateLimits.Requests.Remaining != 1 {
+		t.Fatalf("RateLimits = %+v, want the reported snapshot", auth.RateLimits)
+	}
+	for i := 0; i < 3; i++ {
+		resp, err := manager.Execute(context.Background(), []string{"ratelimit-provider"}, req, cliproxyexecutor.Options{})
+		if err != nil || string(resp.Payload) != "ratelimit-b" {
+			t.Fatalf("Execute() #%d = %s, %v; want ratelimit-b", i, resp.Payload, err)
+		}
+	}
+}
+
+func TestRateLimitSnapshotNearlyExhausted(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		name     string
+		snapshot *RateLimitSnapshot
+		want     bool
+	}{
+		{"nil", nil, false},
+		{"headroom", &RateLimitSnapshot{Tokens: &RateLimitWindow{Limit: 1000, Remaining: 500, ResetAt: now.Add(time.Minute)}, UpdatedAt: now}, false},
+		{"below watermark", &RateLimitSnapshot{Tokens: &RateLimitWindow{Limit: 1000, Remaining: 10, ResetAt: now.Add(time.Minute)}, UpdatedAt: now}, true},
+		{"window reset", &RateLimitSnapshot{Tokens: &RateLimitWindow{Limit: 1000, Remaining: 0, ResetAt: now.Add(-time.Second)}, UpdatedAt: now}, false},
+		{"stale without reset", &RateLimitSnapshot{Requests: &RateLimitWindow{Remaining: 0}, UpdatedAt: now.Add(-2 * time.Minute)}, false},
+		{"retry after", &RateLimitSnapshot{RetryAfter: now.Add(time.Second), UpdatedAt: now}, true},
+	}
+	for _, tc := range cases {
+		if got := tc.snapshot.NearlyExhausted(now); got != tc.want {
+			t.Errorf("%s: NearlyExhausted() = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -142,7 +142,7 @@ func getAvailableAuths(auths []*Auth, provider, model string, now time.Time) ([]
 		return nil, &Error{Code: "auth_unavailable", Message: "no auth available"}
 	}
 
-	return available, nil
+	return preferHeadroom(available, now), nil
 }
 
 // Pick selects the next available auth for the provider in a round-robin manner.
@@ -0,0 +1,205 @@
+package auth
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+const (
+	// latencyAlpha is the EWMA smoothing factor for latency and time-to-first-token samples.
+	latencyAlpha = 0.3
+	// errorAlpha is the EWMA smoothing factor for the error rate.
+	errorAlpha = 0.2
+	// errorPenalty scales the latency score by (1 + errorPenalty*errorRate).
+	errorPenalty = 10.0
+	// errorDecay is the half-life applied to the error rate of idle credentials so
+	// previously failing credentials are retried once they stop receiving traffic.
+	errorDecay = 5 * time.Minute
+	// failureBaselineMs is the latency assumed for credentials that have only failed so far.
+	failureBaselineMs = 10_000.0
+)
+
+// latencyStats holds exponentially weighted health metrics for one auth and model.
+type latencyStats struct {
+	latency   float64
+	ttft      float64
+	errorRate float64
+	samples   int
+	updatedAt time.Time
+}
+
+// LeastLatencySelector prefers the credential with the lowest EWMA latency, penalised by
+// its recent error rate. Streaming requests use time-to-first-token when available.
+// Credentials without samples are tried first so every candidate gets measured.
+//
+// When Weighted is true the selector picks randomly with probability inversely
+// proportional to the score instead of always choosing the best credential, which
+// spreads load while still favouring healthy credentials.
+type LeastLatencySelector struct {
+	Weighted bool
+
+	mu    sync.Mutex
+	stats map[string]*latencyStats
+	rand  *rand.Rand
+}
+
+// Pick selects the healthiest available auth for the provider and model.
+func (s *LeastLatencySelector) Pick(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, auths []*Auth) (*Auth, error) {
+	_ = ctx
+	now := time.Now()
+	available, err := getAvailableAuths(auths, provider, model, now)
+	if err != nil {
+		return nil, err
+	}
+	if len(available) == 1 {
+		return available[0], nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	scores := make([]float64, len(available))
+	for i, candidate := range available {
+		scores[i] = s.scoreLocked(candidate.ID, model, opts.Stream, now)
+	}
+
+	if s.Weighted {
+		return available[s.weightedIndexLocked(scores)], nil
+	}
+	best := 0
+	for i := 1; i < len(scores); i++ {
+		if scores[i] < scores[best] {
+			best = i
+		}
+	}
+	return available[best], nil
+}
+
+// ObserveResult implements ResultObserver by folding the result into the EWMA metrics.
+// Failures the client caused are ignored; see failureCountsAgainstCredential. Health probe
+// results are ignored too, since probes use a small request that does not reflect real traffic.
+func (s *LeastLatencySelector) ObserveResult(result Result) {
+	if result.AuthID == "" || result.Probe {
+		return
+	}
+	if !result.Success && !failureCountsAgainstCredential(statusCodeFromResult(result.Error)) {
+		return
+	}
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stats == nil {
+		s.stats = make(map[string]*latencyStats)
+	}
+	key := latencyStatsKey(result.AuthID, result.Model)
+	stats, ok := s.stats[key]
+	if !ok {
+		stats = &latencyStats{}
+		s.stats[key] = stats
+	}
+
+	outcome := 0.0
+	if !result.Success {
+		outcome = 1.0
+	}
+	stats.errorRate = decayedErrorRate(stats, now)
+	if stats.samples == 0 {
+		stats.errorRate = outcome
+	} else {
+		stats.errorRate = ewma(stats.errorRate, outcome, errorAlpha)
+	}
+
+	// Failures often return quickly and would otherwise make a broken credential look fast.
+	if result.Success && result.Latency > 0 {
+		latencyMs := float64(result.Latency) / float64(time.Millisecond)
+		if stats.latency == 0 {
+			stats.latency = latencyMs
+		} else {
+			stats.latency = ewma(stats.latency, latencyMs, latencyAlpha)
+		}
+		if result.TimeToFirstToken > 0 {
+			ttftMs := float64(result.TimeToFirstToken) / float64(time.Millisecond)
+			if stats.ttft == 0 {
+				stats.ttft = ttftMs
+			} else {
+				stats.ttft = ewma(stats.ttft, ttftMs, latencyAlpha)
+			}
+		}
+	}
+	stats.samples++
+	stats.updatedAt = now
+}
+
+func (s *LeastLatencySelector) scoreLocked(authID, model string, stream bool, now time.Time) float64 {
+	stats, ok := s.stats[latencyStatsKey(authID, model)]
+	if !ok || stats.samples == 0 {
+		return 0
+	}
+	base := stats.latency
+	if stream && stats.ttft > 0 {
+		base = stats.ttft
+	}
+	if base == 0 {
+		// Keep credentials that have never succeeded behind measured healthy ones.
+		base = failureBaselineMs
+	}
+	return base * (1 + errorPenalty*decayedErrorRate(stats, now))
+}
+
+func (s *LeastLatencySelector) weightedIndexLocked(scores []float64) int {
+	if s.rand == nil {
+		s.rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	weights := make([]float64, len(scores))
+	total := 0.0
+	for i, score := range scores {
+		weights[i] = 1 / (score + 1)
+		total += weights[i]
+	}
+	target := s.rand.Float64() * total
+	for i, weight := range weights {
+		target -= weight
+		if target < 0 {
+			return i
+		}
+	}
+	return len(scores) - 1
+}
+
+// failureCountsAgainstCredential reports whether a failed result says something about the
+// credential: server errors, rate limits, timeouts and transport errors that carry no
+// status. Other client errors, such as 400, 413 or 422, are caused by the request itself.
+func failureCountsAgainstCredential(statusCode int) bool {
+	switch {
+	case statusCode == 0, statusCode == http.StatusRequestTimeout, statusCode == http.StatusTooManyRequests:
+		return true
+	}
+	return statusCode >= http.StatusInternalServerError
+}
+
+func decayedErrorRate(stats *latencyStats, now time.Time) float64 {
+	if stats.updatedAt.IsZero() || stats.errorRate == 0 {
+		return stats.errorRate
+	}
+	idle := now.Sub(stats.updatedAt)
+	if idle <= 0 {
+		return stats.errorRate
+	}
+	return stats.errorRate * math.Pow(0.5, float64(idle)/float64(errorDecay))
+}
+
+func ewma(previous, sample, alpha float64) float64 {
+	return alpha*sample + (1-alpha)*previous
+}
+
+func latencyStatsKey(authID, model string) string {
+	return authID + "|" + model
+}
@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+func TestLeastLatencySelectorPick_PrefersFastestCredential(t *testing.T) {
+	t.Parallel()
+
+	selector := &LeastLatencySelector{}
+	auths := []*Auth{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+	selector.ObserveResult(Result{AuthID: "a", Model: "m", Success: true, Latency: 900 * time.Millisecond})
+	selector.ObserveResult(Result{AuthID: "b", Model: "m", Success: true, Latency: 200 * time.Millisecond})
+	selector.ObserveResult(Result{AuthID: "c", Model: "m", Success: true, Latency: 500 * time.Millisecond})
+
+	got, err := selector.Pick(context.Background(), "gemini", "m", cliproxyexecutor.Options{}, auths)
+	if err != nil {
+		t.Fatalf("Pick() error = %v", err)
+	}
+	if got.ID != "b" {
+		t.Fatalf("Pick() auth.ID = %q, want %q", got.ID, "b")
+	}
+}
+
+func TestLeastLatencySelectorPick_PenalisesErrors(t *testing.T) {
+	t.Parallel()
+
+	selector := &LeastLatencySelector{}
+	auths := []*Auth{{ID: "a"}, {ID: "b"}}
+	selector.ObserveResult(Result{AuthID: "a", Model: "m", Success: true, Latency: 100 * time.Millisecond})
+	selector.ObserveResult(Result{AuthID: "b", Model: "m", Success: true, Latency: 300 * time.Millisecond})
+	for i := 0; i < 3; i++ {
+		selector.ObserveResult(Result{AuthID: "a", Model: "m", Success: false, Latency: 10 * time.Millisecond})
+	}
+
+	got, err := selector.Pick(context.Background(), "gemini", "m", cliproxyexecutor.Options{}, auths)
+	if err != nil {
+		t.Fatalf("Pick() error = %v", err)
+	}
+	if got.ID != "b" {
+		t.Fatalf("Pick() auth.ID = %q, want %q", got.ID, "b")
+	}
+}
+
+func TestLeastLatencySelectorPick_StreamUsesTimeToFirstToken(t *testing.T) {
+	t.Parallel()
+
+	selector := &LeastLatencySelector{}
+	auths := []*Auth{{ID: "a"}, {ID: "b"}}
+	selector.ObserveResult(Result{AuthID: "a", Model: "m", Success: true, Latency: 2 * time.Second, TimeToFirstToken: 100 * time.Millisecond})
+	selector.ObserveResult(Result{AuthID: "b", Model: "m", Success: true, Latency: time.Second, TimeToFirstToken: 800 * time.Millisecond})
+
+	got, err := selector.Pick(context.Background(), "gemini", "m", cliproxyexecutor.Options{Stream: true}, auths)
+	if err != nil {
+		t.Fatalf("Pick() error = %v", err)
+	}
+	if got.ID != "a" {
+		t.Fatalf("Pick() auth.ID = %q, want %q", got.ID, "a")
+	}
+}
+
+func TestLeastLatencySelectorPick_ExploresUnmeasuredCredential(t *testing.T) {
+	t.Parallel()
+
+	selector := &LeastLatencySelector{}
+	auths := []*Auth{{ID: "a"}, {ID: "b"}}
+	selector.ObserveResult(Result{AuthID: "a", Model: "m", Success: true, Latency: 50 * time.Millisecond})
+
+	got, err := selector.Pick(context.Background(), "gemini", "m", cliproxyexecutor.Options{}, auths)
+	if err != nil {
+		t.Fatalf("Pick() error = %v", err)
+	}
+	if got.ID != "b" {
+		t.Fatalf("Pick() auth.ID = %q, want %q", got.ID, "b")
+	}
+}
+
+func TestLeastLatencySelectorPick_AvoidsCredentialWithOnlyFailures(t *testing.T) {
+	t.Parallel()
+
+	selector := &LeastLatencySelector{}
+	auths := []*Auth{{ID: "a"}, {ID: "b"}}
+	selector.ObserveResult(Result{AuthID: "a", Model: "m", Success: false, Latency: 5 * time.Millisecond})
+	selector.ObserveResult(Result{AuthID: "b", Model: "m", Success: true, Latency: 2 * time.Second})
+
+	got, err := selector.Pick(context.Background(), "gemini", "m", cliproxyexecutor.Options{}, auths)
+	if err != nil {
+		t.Fatalf("Pick() error = %v", err)
+	}
+	if got.ID != "b" {
+		t.Fatalf("Pick() auth.ID = %q, want %q", got.ID, "b")
+	}
+}
+
+func TestLeastLatencySelectorObserveResult_IgnoresClientErrors(t *testing.T) {
+	t.Parallel()
+
+	selector := &LeastLatencySelector{}
+	auths := []*Auth{{ID: "a"}, {ID: "b"}}
+	selector.ObserveResult(Result{AuthID: "a", Model: "m", Success: true, Latency: 100 * time.Millisecond})
+	selector.ObserveResult(Result{AuthID: "b", Model: "m", Success: true, Latency: 300 * time.Millisecond})
+	for _, status := range []int{http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity} {
+		for i := 0; i < 5; i++ {
+			selector.ObserveResult(Result{AuthID: "a", Model: "m", Success: false, Error: &Error{Message: "bad request", HTTPStatus: status}})
+		}
+	}
+
+	got, err := selector.Pick(context.Background(), "gemini", "m", cliproxyexecutor.Options{}, auths)
+	if err != nil {
+		t.Fatalf("Pick() error = %v", err)
+	}
+	if got.ID != "a" {
+		t.Fatalf("Pick() auth.ID = %q, want %q after client errors only", got.ID, "a")
+	}
+
+	for i := 0; i < 5; i++ {
+		selector.ObserveResult(Result{AuthID: "a", Model: "m", Success: false, Error: &Error{Message: "unavailable", HTTPStatus: http.StatusServiceUnavailable}})
+	}
+	if got, _ = selector.Pick(context.Background(), "gemini", "m", cliproxyexecutor.Options{}, auths); got.ID != "b" {
+		t.Fatalf("Pick() auth.ID = %q, want %q after server errors", got.ID, "b")
+	}
+}
+
+func TestLeastLatencySelectorObserveResult_IgnoresProbes(t *testing.T) {
+	t.Parallel()
+
+	selector := &LeastLatencySelector{}
+	auths := []*Auth{{ID: "a"}, {ID: "b"}}
+	selector.ObserveResult(Result{AuthID: "a", Model: "m", Success: true, Latency: 100 * time.Millisecond})
+	selector.ObserveResult(Result{AuthID: "b", Model: "m", Success: true, Latency: 300 * time.Millisecond})
+	for i := 0; i < 5; i++ {
+		selector.ObserveResult(Result{AuthID: "a", Model: "m", Success: false, Probe: true, Error: &Error{Message: "unavailable", HTTPStatus: http.StatusServiceUnavailable}})
+	}
+
+	got, err := selector.Pick(context.Background(), "gemini", "m", cliproxyexecutor.Options{}, auths)
+	if err != nil {
+		t.Fatalf("Pick() error = %v", err)
+	}
+	if got.ID != "a" {
+		t.Fatalf("Pick() auth.ID = %q, want %q after probe failures only", got.ID, "a")
+	}
+}
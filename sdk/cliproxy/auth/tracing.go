@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/tracing"
+)
+
+// retryAttemptKey carries the retry round and the error that triggered it to attempt spans.
+type retryAttemptKey struct{}
+
+type retryAttempt struct {
+	round  int
+	reason string
+}
+
+func withRetryAttempt(ctx context.Context, round int, cause error) context.Context {
+	return context.WithValue(ctx, retryAttemptKey{}, retryAttempt{round: round, reason: retryReason(cause)})
+}
+
+// startAttemptSpan opens the span covering one executor call. failover is the error of the
+// previous credential tried for the same provider, if any.
+func startAttemptSpan(ctx context.Context, provider, model string, auth *Auth, stream bool, failover error) (context.Context, *tracing.Span) {
+	ctx, span := tracing.Start(ctx, "conductor.attempt", tracing.SpanKindInternal)
+	if span == nil {
+		return ctx, nil
+	}
+	span.SetAttribute("cliproxy.provider", provider)
+	span.SetAttribute("cliproxy.model", model)
+	span.SetAttribute("cliproxy.stream", stream)
+	if auth != nil {
+		span.SetAttribute("cliproxy.auth.index", auth.EnsureIndex())
+	}
+	if retry, ok := ctx.Value(retryAttemptKey{}).(retryAttempt); ok {
+		span.SetAttribute("cliproxy.retry.round", retry.round)
+		span.SetAttribute("cliproxy.retry.reason", retry.reason)
+	}
+	if failover != nil {
+		span.SetAttribute("cliproxy.failover.reason", retryReason(failover))
+	}
+	return ctx, span
+}
+
+func endAttemptSpan(span *tracing.Span, err error) {
+	if span == nil {
+		return
+	}
+	if err != nil {
+		if status := statusCodeFromError(err); status > 0 {
+			span.SetAttribute("http.response.status_code", status)
+		}
+		span.RecordError(err)
+	}
+	span.End()
+}
+
+func retryReason(err error) string {
+	if err == nil {
+		return ""
+	}
+	if status := statusCodeFromError(err); status > 0 {
+		return fmt.Sprintf("upstream status %d", status)
+	}
+	return err.Error()
+}
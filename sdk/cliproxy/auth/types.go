@@ -44,6 +44,8 @@ type Auth struct {
 	Metadata map[string]any `json:"metadata,omitempty"`
 	// Quota captures recent quota information for load balancers.
 	Quota QuotaState `json:"quota"`
+	// RateLimits holds the rate limits reported by the provider on the latest response.
+	RateLimits *RateLimitSnapshot `json:"rate_limits,omitempty"`
 	// LastError stores the last failure encountered while executing or refreshing.
 	LastError *Error `json:"last_error,omitempty"`
 	// CreatedAt is the creation timestamp in UTC.
@@ -77,6 +79,29 @@ type QuotaState struct {
 	BackoffLevel int `json:"backoff_level,omitempty"`
 }
 
+// RateLimitWindow describes one provider-reported limit, such as requests or tokens per minute.
+type RateLimitWindow struct {
+	// Limit is the size of the window; zero when the provider did not report it.
+	Limit int64 `json:"limit,omitempty"`
+	// Remaining is what is left of the window.
+	Remaining int64 `json:"remaining"`
+	// ResetAt is when the window refills; zero when the provider did not report it.
+	ResetAt time.Time `json:"reset_at"`
+}
+
+// RateLimitSnapshot captures the rate limit headers of the latest provider response.
+// Snapshots are replaced, never modified, once recorded on an Auth.
+type RateLimitSnapshot struct {
+	Requests     *RateLimitWindow `json:"requests,omitempty"`
+	Tokens       *RateLimitWindow `json:"tokens,omitempty"`
+	InputTokens  *RateLimitWindow `json:"input_tokens,omitempty"`
+	OutputTokens *RateLimitWindow `json:"output_tokens,omitempty"`
+	// RetryAfter is the time given by a Retry-After header, if any.
+	RetryAfter time.Time `json:"retry_after"`
+	// UpdatedAt is when the snapshot was recorded.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
 // ModelState captures the execution state for a specific model under an auth entry.
 type ModelState struct {
 	// Status reflects the lifecycle status for this model.
@@ -8,9 +8,11 @@ import (
 	"strings"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/api"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/metrics"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
 	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/pipeline"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
 )
 
@@ -47,6 +49,9 @@ type Builder struct {
 
 	// serverOptions contains additional server configuration options.
 	serverOptions []api.ServerOption
+
+	// pipelineHooks are invoked around every provider execution.
+	pipelineHooks []pipeline.Hook
 }
 
 // Hooks allows callers to plug into service lifecycle stages.
@@ -143,6 +148,13 @@ func (b *Builder) WithServerOptions(opts ...api.ServerOption) *Builder {
 	return b
 }
 
+// WithPipelineHooks appends hooks invoked before and after each provider execution
+// and for every streamed chunk. Hooks run in registration order.
+func (b *Builder) WithPipelineHooks(hooks ...pipeline.Hook) *Builder {
+	b.pipelineHooks = append(b.pipelineHooks, hooks...)
+	return b
+}
+
 // WithLocalManagementPassword configures a password that is only accepted from localhost management requests.
 func (b *Builder) WithLocalManagementPassword(password string) *Builder {
 	if password == "" {
@@ -207,14 +219,21 @@ func (b *Builder) Build() (*Service, error) {
 		switch strategy {
 		case "fill-first", "fillfirst", "ff":
 			selector = &coreauth.FillFirstSelector{}
+		case "least-latency", "leastlatency", "latency", "ll":
+			selector = &coreauth.LeastLatencySelector{}
+		case "weighted":
+			selector = &coreauth.LeastLatencySelector{Weighted: true}
 		default:
 			selector = &coreauth.RoundRobinSelector{}
 		}
 
-		coreManager = coreauth.NewManager(tokenStore, selector, nil)
+		coreManager = coreauth.NewManager(tokenStore, selector, metrics.Default())
 	}
 	// Attach a default RoundTripper provider so providers can opt-in per-auth transports.
 	coreManager.SetRoundTripperProvider(newDefaultRoundTripperProvider())
+	if adapter := newPipelineHookAdapter(b.pipelineHooks); adapter != nil {
+		coreManager.SetExecutionHook(adapter)
+	}
 
 	service := &Service{
 		cfg:            b.cfg,
@@ -7,6 +7,10 @@ import (
 	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
 )
 
+// ClientAPIKeyMetadataKey carries the inbound client API key in Options.Metadata so
+// selection-time features such as the cooldown wait queue can tell clients apart.
+const ClientAPIKeyMetadataKey = "client_api_key"
+
 // Request encapsulates the translated payload that will be sent to a provider executor.
 type Request struct {
 	// Model is the upstream model identifier after translation.
@@ -1,6 +1,7 @@
 package pipeline
 
 import (
+	"bytes"
 	"context"
 	"net/http"
 
@@ -11,8 +12,11 @@ import (
 
 // Context encapsulates execution state shared across middleware, translators, and executors.
 type Context struct {
-	// Request encapsulates the provider facing request payload.
+	// Request is the request handed to the executor. Its payload is still in the client's
+	// schema (Options.SourceFormat); executors translate it after the hooks run.
 	Request cliproxyexecutor.Request
+	// TargetFormat is the schema the selected provider's executor translates the payload into.
+	TargetFormat sdktranslator.Format
 	// Options carries execution flags (streaming, headers, etc.).
 	Options cliproxyexecutor.Options
 	// Auth references the credential selected for execution.
@@ -23,6 +27,20 @@ type Context struct {
 	HTTPClient *http.Client
 }
 
+// TranslatedPayload returns a copy of the request payload translated into TargetFormat, as
+// the executor builds it before provider-specific adjustments. Changes to the copy are not
+// sent upstream; mutate Request.Payload in the client's schema instead.
+func (c *Context) TranslatedPayload() []byte {
+	if c == nil {
+		return nil
+	}
+	payload := bytes.Clone(c.Request.Payload)
+	if c.Options.SourceFormat == "" || c.TargetFormat == "" {
+		return payload
+	}
+	return sdktranslator.TranslateRequest(c.Options.SourceFormat, c.TargetFormat, c.Request.Model, payload, c.Options.Stream)
+}
+
 // Hook captures middleware callbacks around execution.
 type Hook interface {
 	BeforeExecute(ctx context.Context, execCtx *Context)
@@ -0,0 +1,109 @@
+package cliproxy
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/pipeline"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+// pipelineContextKey stores the per-execution pipeline context between hook callbacks.
+type pipelineContextKey struct{}
+
+// pipelineHookAdapter bridges pipeline.Hook implementations to coreauth.ExecutionHook.
+// The auth package cannot depend on pipeline, so the adapter owns the pipeline.Context
+// and copies request mutations back to the manager. Hooks run before translation, so the
+// adapter also records the provider's target format for pipeline.Context.TranslatedPayload. Replacing HTTPClient.Transport in
+// BeforeExecute overrides the per-auth transport for that execution.
+type pipelineHookAdapter struct {
+	hooks []pipeline.Hook
+}
+
+func newPipelineHookAdapter(hooks []pipeline.Hook) *pipelineHookAdapter {
+	filtered := make([]pipeline.Hook, 0, len(hooks))
+	for _, hook := range hooks {
+		if hook != nil {
+			filtered = append(filtered, hook)
+		}
+	}
+	if len(filtered) == 0 {
+		return nil
+	}
+	return &pipelineHookAdapter{hooks: filtered}
+}
+
+// BeforeExecute implements coreauth.ExecutionHook.
+func (a *pipelineHookAdapter) BeforeExecute(ctx context.Context, auth *coreauth.Auth, req *cliproxyexecutor.Request, opts *cliproxyexecutor.Options) context.Context {
+	execCtx := &pipeline.Context{Auth: auth}
+	if auth != nil {
+		execCtx.TargetFormat = providerRequestFormat(auth.Provider)
+	}
+	if req != nil {
+		execCtx.Request = *req
+	}
+	if opts != nil {
+		execCtx.Options = *opts
+	}
+	var baseTransport http.RoundTripper
+	if rt, ok := ctx.Value("cliproxy.roundtripper").(http.RoundTripper); ok && rt != nil {
+		baseTransport = rt
+	}
+	execCtx.HTTPClient = &http.Client{Transport: baseTransport}
+
+	for _, hook := range a.hooks {
+		hook.BeforeExecute(ctx, execCtx)
+	}
+
+	if req != nil {
+		*req = execCtx.Request
+	}
+	if opts != nil {
+		*opts = execCtx.Options
+	}
+	if execCtx.HTTPClient != nil && execCtx.HTTPClient.Transport != nil && execCtx.HTTPClient.Transport != baseTransport {
+		ctx = context.WithValue(ctx, "cliproxy.roundtripper", execCtx.HTTPClient.Transport)
+	}
+	return context.WithValue(ctx, pipelineContextKey{}, execCtx)
+}
+
+// AfterExecute implements coreauth.ExecutionHook.
+func (a *pipelineHookAdapter) AfterExecute(ctx context.Context, auth *coreauth.Auth, resp cliproxyexecutor.Response, err error) {
+	execCtx := pipelineContextFrom(ctx, auth)
+	for _, hook := range a.hooks {
+		hook.AfterExecute(ctx, execCtx, resp, err)
+	}
+}
+
+// OnStreamChunk implements coreauth.ExecutionHook.
+func (a *pipelineHookAdapter) OnStreamChunk(ctx context.Context, auth *coreauth.Auth, chunk cliproxyexecutor.StreamChunk) {
+	execCtx := pipelineContextFrom(ctx, auth)
+	for _, hook := range a.hooks {
+		hook.OnStreamChunk(ctx, execCtx, chunk)
+	}
+}
+
+// providerRequestFormat returns the schema the built-in executor for provider translates
+// requests into. Unknown providers are OpenAI-compatible.
+func providerRequestFormat(provider string) sdktranslator.Format {
+	switch provider = strings.ToLower(strings.TrimSpace(provider)); provider {
+	case "gemini", "vertex", "aistudio":
+		return sdktranslator.FromString("gemini")
+	case "gemini-cli", "antigravity", "claude", "codex":
+		return sdktranslator.FromString(provider)
+	default:
+		return sdktranslator.FromString("openai")
+	}
+}
+
+func pipelineContextFrom(ctx context.Context, auth *coreauth.Auth) *pipeline.Context {
+	if ctx != nil {
+		if execCtx, ok := ctx.Value(pipelineContextKey{}).(*pipeline.Context); ok && execCtx != nil {
+			return execCtx
+		}
+	}
+	return &pipeline.Context{Auth: auth}
+}
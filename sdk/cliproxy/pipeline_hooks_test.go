@@ -0,0 +1,37 @@
+package cliproxy
+
+import (
+	"context"
+	"testing"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/pipeline"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	_ "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator/builtin"
+	"github.com/tidwall/gjson"
+)
+
+func TestPipelineHookAdapter_ExposesTranslatedPayload(t *testing.T) {
+	var seen []byte
+	var target sdktranslator.Format
+	adapter := newPipelineHookAdapter([]pipeline.Hook{pipeline.HookFunc{Before: func(_ context.Context, execCtx *pipeline.Context) {
+		target = execCtx.TargetFormat
+		seen = execCtx.TranslatedPayload()
+	}}})
+
+	payload := []byte(`{"model":"claude-sonnet-4","messages":[{"role":"user","content":"hi"}]}`)
+	req := &cliproxyexecutor.Request{Model: "claude-sonnet-4", Payload: payload}
+	opts := &cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")}
+	adapter.BeforeExecute(context.Background(), &coreauth.Auth{Provider: "claude"}, req, opts)
+
+	if target != sdktranslator.FromString("claude") {
+		t.Fatalf("TargetFormat = %q, want claude", target)
+	}
+	if !gjson.GetBytes(seen, "max_tokens").Exists() || gjson.GetBytes(seen, "messages.0.content.0.text").String() != "hi" {
+		t.Fatalf("TranslatedPayload() = %s, want a Claude request", seen)
+	}
+	if string(req.Payload) != string(payload) {
+		t.Fatalf("request payload = %s, want the client payload unchanged", req.Payload)
+	}
+}
@@ -315,6 +315,34 @@ func (s *Service) applyRetryConfig(cfg *config.Config) {
 	}
 	maxInterval := time.Duration(cfg.MaxRetryInterval) * time.Second
 	s.coreManager.SetRetryConfig(cfg.RequestRetry, maxInterval)
+
+	fallbacks := make(map[string][]string, len(cfg.ModelFallbacks))
+	for _, entry := range cfg.ModelFallbacks {
+		fallbacks[entry.Model] = entry.Fallbacks
+	}
+	s.coreManager.SetModelFallbacks(fallbacks)
+
+	var hedgeDelay time.Duration
+	if hedging := cfg.Routing.Hedging; hedging.Enabled {
+		hedgeDelay = time.Duration(hedging.DelayMS) * time.Millisecond
+	}
+	s.coreManager.SetHedging(hedgeDelay, cfg.Routing.Hedging.Models)
+
+	queue := coreauth.QueueSettings{}
+	if queueCfg := cfg.Routing.Queue; queueCfg.Enabled {
+		queue.MaxDepth = queueCfg.MaxDepth
+		queue.MaxPerKey = queueCfg.MaxPerKey
+		queue.MaxWait = time.Duration(queueCfg.MaxWaitSeconds) * time.Second
+		queue.Priorities = queueCfg.Priorities
+	}
+	s.coreManager.SetQueue(queue)
+
+	probes := coreauth.ProbeSettings{Models: cfg.HealthCheck.Models}
+	if healthCheck := cfg.HealthCheck; healthCheck.Enabled {
+		probes.Interval = time.Duration(healthCheck.IntervalSeconds) * time.Second
+		probes.Timeout = time.Duration(healthCheck.TimeoutSeconds) * time.Second
+	}
+	s.coreManager.SetHealthProbes(probes)
 }
 
 func openAICompatInfoFromAuth(a *coreauth.Auth) (providerKey string, compatName string, ok bool) {
@@ -527,6 +555,10 @@ func (s *Service) Run(ctx context.Context) error {
 			switch strategy {
 			case "fill-first", "fillfirst", "ff":
 				return "fill-first"
+			case "least-latency", "leastlatency", "latency", "ll":
+				return "least-latency"
+			case "weighted":
+				return "weighted"
 			default:
 				return "round-robin"
 			}
@@ -538,6 +570,10 @@ func (s *Service) Run(ctx context.Context) error {
 			switch nextStrategy {
 			case "fill-first":
 				selector = &coreauth.FillFirstSelector{}
+			case "least-latency":
+				selector = &coreauth.LeastLatencySelector{}
+			case "weighted":
+				selector = &coreauth.LeastLatencySelector{Weighted: true}
 			default:
 				selector = &coreauth.RoundRobinSelector{}
 			}
@@ -578,6 +614,7 @@ func (s *Service) Run(ctx context.Context) error {
 		interval := 15 * time.Minute
 		s.coreManager.StartAutoRefresh(context.Background(), interval)
 		log.Infof("core auth auto-refresh started (interval=%s)", interval)
+		s.coreManager.StartHealthProbes(context.Background())
 	}
 
 	select {
@@ -615,6 +652,7 @@ func (s *Service) Shutdown(ctx context.Context) error {
 		}
 		if s.coreManager != nil {
 			s.coreManager.StopAutoRefresh()
+			s.coreManager.StopHealthProbes()
 		}
 		if s.watcher != nil {
 			if err := s.watcher.Stop(); err != nil {
@@ -700,7 +738,7 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 	var models []*ModelInfo
 	switch provider {
 	case "gemini":
-		models = registry.GetGeminiModels()
+		models = append(registry.GetGeminiModels(), registry.GetGeminiEmbeddingModels()...)
 		if entry := s.resolveConfigGeminiKey(a); entry != nil {
 			if authKind == "apikey" {
 				excluded = entry.ExcludedModels
@@ -709,7 +747,7 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 		models = applyExcludedModels(models, excluded)
 	case "vertex":
 		// Vertex AI Gemini supports the same model identifiers as Gemini.
-		models = registry.GetGeminiVertexModels()
+		models = append(registry.GetGeminiVertexModels(), registry.GetGeminiVertexEmbeddingModels()...)
 		if authKind == "apikey" {
 			if entry := s.resolveConfigVertexCompatKey(a); entry != nil && len(entry.Models) > 0 {
 				models = buildVertexCompatConfigModels(entry)
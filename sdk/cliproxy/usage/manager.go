@@ -18,7 +18,53 @@ type Record struct {
 	Source      string
 	RequestedAt time.Time
 	Failed      bool
-	Detail      Detail
+	// Cache is CacheHit or CacheMiss when the response cache handled the request.
+	Cache  string
+	Detail Detail
+}
+
+// Response cache outcomes reported in Record.Cache.
+const (
+	CacheHit  = "hit"
+	CacheMiss = "miss"
+)
+
+type cacheStatusKey struct{}
+
+// WithCacheStatus returns a context whose usage records report the response cache outcome.
+func WithCacheStatus(ctx context.Context, status string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, cacheStatusKey{}, status)
+}
+
+// CacheStatusFromContext returns the response cache outcome stored by WithCacheStatus.
+func CacheStatusFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	status, _ := ctx.Value(cacheStatusKey{}).(string)
+	return status
+}
+
+type suppressKey struct{}
+
+// WithSuppression returns a context whose usage records are discarded while suppressed
+// reports true. Hedged requests use it to drop records of the cancelled attempt.
+func WithSuppression(ctx context.Context, suppressed func() bool) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, suppressKey{}, suppressed)
+}
+
+func recordSuppressed(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	suppressed, ok := ctx.Value(suppressKey{}).(func() bool)
+	return ok && suppressed != nil && suppressed()
 }
 
 // Detail holds the token usage breakdown.
@@ -106,7 +152,7 @@ func (m *Manager) Register(plugin Plugin) {
 // Publish enqueues a usage record for processing. If no plugin is registered
 // the record will be discarded downstream.
 func (m *Manager) Publish(ctx context.Context, record Record) {
-	if m == nil {
+	if m == nil || recordSuppressed(ctx) {
 		return
 	}
 	// ensure worker is running even if Start was not called explicitly
@@ -13,6 +13,9 @@ type AccessProvider = internalconfig.AccessProvider
 type Config = internalconfig.Config
 
 type StreamingConfig = internalconfig.StreamingConfig
+type ModelRoute = internalconfig.ModelRoute
+type ContextOverflowConfig = internalconfig.ContextOverflowConfig
+type APIKeyPolicy = internalconfig.APIKeyPolicy
 type TLSConfig = internalconfig.TLSConfig
 type RemoteManagement = internalconfig.RemoteManagement
 type AmpCode = internalconfig.AmpCode
@@ -35,6 +38,10 @@ const (
 	AccessProviderTypeConfigAPIKey = internalconfig.AccessProviderTypeConfigAPIKey
 	DefaultAccessProviderName      = internalconfig.DefaultAccessProviderName
 	DefaultPanelGitHubRepository   = internalconfig.DefaultPanelGitHubRepository
+
+	ContextOverflowError    = internalconfig.ContextOverflowError
+	ContextOverflowTruncate = internalconfig.ContextOverflowTruncate
+	ContextOverflowReroute  = internalconfig.ContextOverflowReroute
 )
 
 func MakeInlineAPIKeyProvider(keys []string) *AccessProvider {
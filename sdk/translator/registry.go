@@ -2,6 +2,7 @@ package translator
 
 import (
 	"context"
+	"sort"
 	"sync"
 )
 
@@ -65,6 +66,32 @@ func (r *Registry) HasResponseTransformer(from, to Format) bool {
 	return false
 }
 
+// Pair identifies a registered translation from a client schema to a provider schema.
+type Pair struct {
+	From Format
+	To   Format
+}
+
+// Pairs lists every registered pair, sorted by source and then target format.
+func (r *Registry) Pairs() []Pair {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var pairs []Pair
+	for from, byTarget := range r.responses {
+		for to := range byTarget {
+			pairs = append(pairs, Pair{From: from, To: to})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].From != pairs[j].From {
+			return pairs[i].From < pairs[j].From
+		}
+		return pairs[i].To < pairs[j].To
+	})
+	return pairs
+}
+
 // TranslateStream applies the registered streaming response translator.
 func (r *Registry) TranslateStream(ctx context.Context, from, to Format, model string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) []string {
 	r.mu.RLock()
@@ -126,6 +153,11 @@ func HasResponseTransformer(from, to Format) bool {
 	return defaultRegistry.HasResponseTransformer(from, to)
 }
 
+// Pairs lists the pairs registered in the default registry.
+func Pairs() []Pair {
+	return defaultRegistry.Pairs()
+}
+
 // TranslateStream is a helper on the default registry.
 func TranslateStream(ctx context.Context, from, to Format, model string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) []string {
 	return defaultRegistry.TranslateStream(ctx, from, to, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)